@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// healthcheck - подкоманда для HEALTHCHECK в образе без shell и curl: запрашивает /healthz
+// (или /readyz с -ready) у запущенного сервера и возвращает код выхода 0, если ответ 200
+func healthcheck(args []string) int {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	ready := fs.Bool("ready", false, "проверить готовность (/readyz) вместо живости (/healthz)")
+	url := fs.String("url", "", "адрес проверки; по умолчанию http://127.0.0.1"+httpAddr+"/healthz")
+	timeout := fs.Duration("timeout", 3*time.Second, "таймаут запроса")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	target := *url
+	if target == "" {
+		path := "/healthz"
+		if *ready {
+			path = "/readyz"
+		}
+		target = "http://127.0.0.1:" + strings.TrimPrefix(httpAddr, ":") + path
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	resp, err := client.Get(target)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "healthcheck:", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintln(os.Stderr, "healthcheck:", resp.Status)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,697 @@
+package main
+
+import (
+	"api_library/pkg/client"
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type runner func(ctx context.Context, a *app, args []string) error
+
+// command - узел дерева команд: группа (commands) или конечная команда (setup)
+type command struct {
+	name    string
+	args    string
+	summary string
+	// setup объявляет флаги команды и возвращает её обработчик
+	setup    func(fs *flag.FlagSet) runner
+	commands []*command
+	// команде не нужен профиль подключения
+	noProfile bool
+	// полное имя, заполняется в init
+	path string
+}
+
+var rootCommands = []*command{
+	{name: "authors", summary: "manage authors", commands: []*command{
+		{name: "list", summary: "list authors", setup: authorsList},
+		{name: "get", args: "ID [--as-of RFC3339]", summary: "show an author", setup: authorsGet},
+		{name: "create", args: "--first-name NAME --last-name NAME [--biography TEXT] [--birth-date YYYY-MM-DD]", summary: "create an author", setup: authorsCreate},
+		{name: "delete", args: "ID", summary: "move an author to the trash", setup: authorsDelete},
+		{name: "restore", args: "ID", summary: "restore an author from the trash", setup: authorsRestore},
+		{name: "import", args: "FILE [--dry-run] [--delimiter C] [--map field=column]...", summary: "import authors from CSV", setup: importCSV(client.ImportAuthors)},
+		{name: "export", args: "[--name SUBSTRING] [--format csv|ndjson|xlsx] [--out FILE]", summary: "export authors", setup: authorsExport},
+	}},
+	{name: "books", summary: "manage books", commands: []*command{
+		{name: "list", summary: "list books", setup: booksList},
+		{name: "get", args: "ID [--as-of RFC3339]", summary: "show a book", setup: booksGet},
+		{name: "create", args: "--title TITLE --author-id ID [--year YEAR] [--isbn ISBN]", summary: "create a book", setup: booksCreate},
+		{name: "delete", args: "ID", summary: "move a book to the trash", setup: booksDelete},
+		{name: "restore", args: "ID", summary: "restore a book from the trash", setup: booksRestore},
+		{name: "import", args: "FILE [--marc] [--dry-run] [--delimiter C] [--map field=column]...", summary: "import books from CSV or MARC 21", setup: importCSV(client.ImportBooks)},
+		{name: "export", args: "[--author-id ID] [--year YEAR] [--title SUBSTRING] [--isbn ISBN] [--format csv|ndjson|xlsx] [--out FILE]", summary: "export books with their authors", setup: booksExport},
+	}},
+	{name: "jobs", summary: "background jobs", commands: []*command{
+		{name: "list", summary: "list jobs", setup: jobsList},
+		{name: "history", args: "NAME [--limit N]", summary: "show recent runs of a job", setup: jobsHistory},
+		{name: "run", args: "NAME", summary: "run a job now and wait for the result", setup: jobsRun},
+	}},
+	{name: "auth", summary: "authentication", commands: []*command{
+		{name: "login", args: "--username NAME [--password-stdin]", summary: "get a token and save it in the profile", setup: authLogin},
+	}},
+	{name: "config", summary: "connection profiles", commands: []*command{
+		{name: "list", summary: "list profiles", setup: configList, noProfile: true},
+		{name: "set", args: "NAME [--server URL] [--api-key KEY] [--token TOKEN]", summary: "create or update a profile", setup: configSet, noProfile: true},
+		{name: "use", args: "NAME", summary: "make a profile current", setup: configUse, noProfile: true},
+		{name: "delete", args: "NAME", summary: "delete a profile", setup: configDelete, noProfile: true},
+	}},
+	{name: "completion", args: "bash|zsh|fish", summary: "print a shell completion script", setup: completion, noProfile: true},
+}
+
+func init() {
+	var fill func(prefix string, commands []*command)
+	fill = func(prefix string, commands []*command) {
+		for _, cmd := range commands {
+			cmd.path = strings.TrimSpace(prefix + " " + cmd.name)
+			fill(cmd.path, cmd.commands)
+		}
+	}
+	fill("", rootCommands)
+}
+
+// findCommand спускается по дереву команд; возвращает последнюю найденную команду и оставшиеся аргументы
+func findCommand(commands []*command, args []string) (*command, []string) {
+	var found *command
+	for len(args) > 0 {
+		var next *command
+		for _, cmd := range commands {
+			if cmd.name == args[0] {
+				next = cmd
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		found, commands, args = next, next.commands, args[1:]
+		if found.setup != nil {
+			break
+		}
+	}
+	return found, args
+}
+
+func idArg(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errUsage
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID %q", args[0])
+	}
+	return id, nil
+}
+
+func nameArg(args []string) (string, error) {
+	if len(args) != 1 || args[0] == "" {
+		return "", errUsage
+	}
+	return args[0], nil
+}
+
+func authorTable(authors ...client.Author) *table {
+	t := &table{header: []string{"ID", "FIRST NAME", "LAST NAME", "BIRTH DATE"}}
+	for _, author := range authors {
+		birthDate := ""
+		if !author.BirthDate.IsZero() {
+			birthDate = author.BirthDate.Format("2006-01-02")
+		}
+		t.add(author.ID, author.FirstName, author.LastName, birthDate)
+	}
+	return t
+}
+
+func bookTable(books ...client.Book) *table {
+	t := &table{header: []string{"ID", "TITLE", "AUTHOR ID", "YEAR", "ISBN"}}
+	for _, book := range books {
+		t.add(book.ID, book.Title, book.AuthorID, book.Year, book.ISBN)
+	}
+	return t
+}
+
+func parseAsOf(v string) (time.Time, error) {
+	asOf, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return asOf, fmt.Errorf("invalid --as-of, expected RFC 3339 timestamp")
+	}
+	return asOf, nil
+}
+
+func authorsList(fs *flag.FlagSet) runner {
+	return func(ctx context.Context, a *app, args []string) error {
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		authors, err := api.ListAuthors(ctx).All()
+		if err != nil {
+			return err
+		}
+		if authors == nil {
+			authors = []client.Author{}
+		}
+		return a.print(authors, authorTable(authors...))
+	}
+}
+
+func authorsGet(fs *flag.FlagSet) runner {
+	asOf := fs.String("as-of", "", "show the author as of this time (RFC 3339)")
+	return func(ctx context.Context, a *app, args []string) error {
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		var author client.Author
+		if *asOf != "" {
+			t, err := parseAsOf(*asOf)
+			if err != nil {
+				return err
+			}
+			author, err = api.GetAuthorAsOf(ctx, id, t)
+		} else {
+			author, err = api.GetAuthor(ctx, id)
+		}
+		if err != nil {
+			return err
+		}
+		return a.print(author, authorTable(author))
+	}
+}
+
+func authorsCreate(fs *flag.FlagSet) runner {
+	firstName := fs.String("first-name", "", "first name")
+	lastName := fs.String("last-name", "", "last name")
+	biography := fs.String("biography", "", "biography")
+	birthDate := fs.String("birth-date", "", "birth date, YYYY-MM-DD")
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) != 0 || *firstName == "" || *lastName == "" {
+			return errUsage
+		}
+		author := client.Author{FirstName: *firstName, LastName: *lastName, Biography: *biography}
+		if *birthDate != "" {
+			t, err := time.Parse("2006-01-02", *birthDate)
+			if err != nil {
+				return fmt.Errorf("invalid --birth-date, expected YYYY-MM-DD")
+			}
+			author.BirthDate = client.Date{Time: t}
+		}
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		id, err := api.CreateAuthor(ctx, author)
+		if err != nil {
+			return err
+		}
+		return a.printCreated(id)
+	}
+}
+
+func (a *app) printCreated(id int) error {
+	t := &table{header: []string{"ID"}}
+	t.add(id)
+	return a.print(map[string]int{"id": id}, t)
+}
+
+func authorsDelete(fs *flag.FlagSet) runner {
+	return idCommand("author %d deleted", func(api *client.Client) func(context.Context, int) error { return api.DeleteAuthor })
+}
+
+func authorsRestore(fs *flag.FlagSet) runner {
+	return idCommand("author %d restored", func(api *client.Client) func(context.Context, int) error { return api.RestoreAuthor })
+}
+
+func booksDelete(fs *flag.FlagSet) runner {
+	return idCommand("book %d deleted", func(api *client.Client) func(context.Context, int) error { return api.DeleteBook })
+}
+
+func booksRestore(fs *flag.FlagSet) runner {
+	return idCommand("book %d restored", func(api *client.Client) func(context.Context, int) error { return api.RestoreBook })
+}
+
+// idCommand - команда вида «<действие> ID» без вывода; сообщение об успехе пишется в stderr
+func idCommand(done string, method func(api *client.Client) func(context.Context, int) error) runner {
+	return func(ctx context.Context, a *app, args []string) error {
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		if err := method(api)(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stderr, done+"\n", id)
+		return nil
+	}
+}
+
+func bookFilterFlags(fs *flag.FlagSet) *client.BookFilter {
+	filter := &client.BookFilter{}
+	fs.IntVar(&filter.AuthorID, "author-id", 0, "author ID")
+	fs.IntVar(&filter.Year, "year", 0, "publication year")
+	fs.StringVar(&filter.Title, "title", "", "substring of the title")
+	fs.StringVar(&filter.ISBN, "isbn", "", "ISBN")
+	return filter
+}
+
+func booksList(fs *flag.FlagSet) runner {
+	return func(ctx context.Context, a *app, args []string) error {
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		books, err := api.ListBooks(ctx).All()
+		if err != nil {
+			return err
+		}
+		if books == nil {
+			books = []client.Book{}
+		}
+		return a.print(books, bookTable(books...))
+	}
+}
+
+func booksGet(fs *flag.FlagSet) runner {
+	asOf := fs.String("as-of", "", "show the book as of this time (RFC 3339)")
+	return func(ctx context.Context, a *app, args []string) error {
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		var book client.Book
+		if *asOf != "" {
+			t, err := parseAsOf(*asOf)
+			if err != nil {
+				return err
+			}
+			book, err = api.GetBookAsOf(ctx, id, t)
+		} else {
+			book, err = api.GetBook(ctx, id)
+		}
+		if err != nil {
+			return err
+		}
+		return a.print(book, bookTable(book))
+	}
+}
+
+func booksCreate(fs *flag.FlagSet) runner {
+	book := &client.Book{}
+	fs.StringVar(&book.Title, "title", "", "title")
+	fs.IntVar(&book.AuthorID, "author-id", 0, "author ID")
+	fs.IntVar(&book.Year, "year", 0, "publication year")
+	fs.StringVar(&book.ISBN, "isbn", "", "ISBN")
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) != 0 || book.Title == "" || book.AuthorID == 0 {
+			return errUsage
+		}
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		id, err := api.CreateBook(ctx, *book)
+		if err != nil {
+			return err
+		}
+		return a.printCreated(id)
+	}
+}
+
+// mappingFlag - повторяемый флаг --map field=column
+type mappingFlag map[string]string
+
+func (m mappingFlag) String() string {
+	return ""
+}
+
+func (m mappingFlag) Set(v string) error {
+	field, column, ok := strings.Cut(v, "=")
+	if !ok || field == "" || column == "" {
+		return fmt.Errorf("expected field=column")
+	}
+	m[field] = column
+	return nil
+}
+
+func importCSV(entityName string) func(fs *flag.FlagSet) runner {
+	return func(fs *flag.FlagSet) runner {
+		opts := client.ImportOptions{Entity: entityName, Mapping: make(map[string]string)}
+		fs.BoolVar(&opts.DryRun, "dry-run", false, "validate the file without saving anything")
+		delimiter := fs.String("delimiter", "", "column delimiter (default ,)")
+		fs.Var(mappingFlag(opts.Mapping), "map", "map a field to a CSV column: --map title=Название (repeatable)")
+		var marc *bool
+		if entityName == client.ImportBooks {
+			marc = fs.Bool("marc", false, "the file is MARC 21 (ISO 2709 or MARCXML) instead of CSV")
+		}
+		return func(ctx context.Context, a *app, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			if *delimiter != "" {
+				r, size := utf8.DecodeRuneInString(*delimiter)
+				if size != len(*delimiter) {
+					return fmt.Errorf("--delimiter must be a single character")
+				}
+				opts.Delimiter = r
+			}
+			input, err := openInput(a, args[0])
+			if err != nil {
+				return err
+			}
+			defer input.Close()
+
+			api, err := a.api()
+			if err != nil {
+				return err
+			}
+			var result client.ImportResult
+			if marc != nil && *marc {
+				result, err = api.ImportMARC(ctx, input, opts.DryRun)
+			} else {
+				result, err = api.ImportCSV(ctx, input, opts)
+			}
+			if err != nil {
+				return err
+			}
+
+			if a.output == outputTable {
+				fmt.Fprintf(a.stderr, "total %d, imported %d, rejected %d", result.Total, result.Imported, result.Rejected)
+				if result.DryRun {
+					fmt.Fprint(a.stderr, " (dry run, nothing saved)")
+				}
+				fmt.Fprintln(a.stderr)
+			}
+			t := &table{header: []string{"LINE", "COLUMN", "ERROR"}}
+			for _, rowErr := range result.Errors {
+				t.add(rowErr.Line, rowErr.Column, rowErr.Message)
+			}
+			if a.output == outputTable && len(t.rows) == 0 {
+				return nil
+			}
+			return a.print(result, t)
+		}
+	}
+}
+
+// openInput открывает файл; «-» - стандартный ввод
+func openInput(a *app, path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(a.stdin), nil
+	}
+	return os.Open(path)
+}
+
+func exportFlags(fs *flag.FlagSet) (format, out *string) {
+	format = fs.String("format", client.FormatCSV, "csv, ndjson or xlsx")
+	out = fs.String("out", "", "write to this file instead of stdout")
+	return format, out
+}
+
+func authorsExport(fs *flag.FlagSet) runner {
+	name := fs.String("name", "", "substring of the first or last name")
+	format, out := exportFlags(fs)
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		body, err := api.ExportAuthors(ctx, client.AuthorFilter{Name: *name}, *format)
+		if err != nil {
+			return err
+		}
+		return writeExport(a, body, *out)
+	}
+}
+
+func booksExport(fs *flag.FlagSet) runner {
+	filter := bookFilterFlags(fs)
+	format, out := exportFlags(fs)
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		body, err := api.ExportBooks(ctx, *filter, *format)
+		if err != nil {
+			return err
+		}
+		return writeExport(a, body, *out)
+	}
+}
+
+func writeExport(a *app, body io.ReadCloser, out string) error {
+	defer body.Close()
+	if out == "" {
+		_, err := io.Copy(a.stdout, body)
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func jobsList(fs *flag.FlagSet) runner {
+	return func(ctx context.Context, a *app, args []string) error {
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		jobs, err := api.ListJobs(ctx)
+		if err != nil {
+			return err
+		}
+		t := &table{header: []string{"NAME", "SCHEDULE", "RUNNING", "LAST STATUS", "NEXT RUN"}}
+		for _, job := range jobs {
+			lastStatus, nextRun := "", ""
+			if job.LastRun != nil {
+				lastStatus = job.LastRun.Status
+			}
+			if job.NextRun != nil {
+				nextRun = job.NextRun.Local().Format(time.DateTime)
+			}
+			t.add(job.Name, job.Schedule, job.Running, lastStatus, nextRun)
+		}
+		return a.print(jobs, t)
+	}
+}
+
+func jobRunTable(runs ...client.JobRun) *table {
+	t := &table{header: []string{"ID", "TRIGGER", "STATUS", "STARTED", "FINISHED", "ERROR"}}
+	for _, run := range runs {
+		finished := ""
+		if run.FinishedAt != nil {
+			finished = run.FinishedAt.Local().Format(time.DateTime)
+		}
+		t.add(run.ID, run.Trigger, run.Status, run.StartedAt.Local().Format(time.DateTime), finished, run.Error)
+	}
+	return t
+}
+
+func jobsHistory(fs *flag.FlagSet) runner {
+	limit := fs.Int("limit", 0, "number of runs (default: server default)")
+	return func(ctx context.Context, a *app, args []string) error {
+		name, err := nameArg(args)
+		if err != nil {
+			return err
+		}
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		runs, err := api.GetJobHistory(ctx, name, *limit)
+		if err != nil {
+			return err
+		}
+		return a.print(runs, jobRunTable(runs...))
+	}
+}
+
+func jobsRun(fs *flag.FlagSet) runner {
+	return func(ctx context.Context, a *app, args []string) error {
+		name, err := nameArg(args)
+		if err != nil {
+			return err
+		}
+		api, err := a.api()
+		if err != nil {
+			return err
+		}
+		run, err := api.RunJob(ctx, name)
+		if err != nil {
+			return err
+		}
+		return a.print(run, jobRunTable(run))
+	}
+}
+
+func authLogin(fs *flag.FlagSet) runner {
+	username := fs.String("username", "", "staff username")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin instead of LIBCTL_PASSWORD")
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) != 0 || *username == "" {
+			return errUsage
+		}
+		password := os.Getenv("LIBCTL_PASSWORD")
+		if *passwordStdin {
+			line, err := bufio.NewReader(a.stdin).ReadString('\n')
+			if err != nil && err != io.EOF {
+				return err
+			}
+			password = strings.TrimRight(line, "\r\n")
+		}
+		if password == "" {
+			return fmt.Errorf("password is required: set LIBCTL_PASSWORD or use --password-stdin")
+		}
+
+		// вход выполняется без сохранённых учётных данных профиля
+		api, err := client.New(client.Config{BaseURL: a.profile.Server})
+		if err != nil {
+			return err
+		}
+		token, err := api.IssueToken(ctx, *username, password)
+		if err != nil {
+			return err
+		}
+
+		name := a.profileName
+		if name == "" {
+			name = "default"
+			a.cfg.CurrentProfile = name
+		}
+		profile, ok := a.cfg.Profiles[name]
+		if !ok {
+			profile = &Profile{Server: a.profile.Server}
+			a.cfg.Profiles[name] = profile
+		}
+		profile.Token = token.AccessToken
+		if err := a.cfg.save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stderr, "logged in as %s, token saved to profile %q (expires in %s)\n",
+			*username, name, time.Duration(token.ExpiresIn)*time.Second)
+		return nil
+	}
+}
+
+func configList(fs *flag.FlagSet) runner {
+	return func(ctx context.Context, a *app, args []string) error {
+		type profileInfo struct {
+			Name    string `json:"name"`
+			Current bool   `json:"current"`
+			Server  string `json:"server"`
+			Auth    string `json:"auth"`
+		}
+		profiles := []profileInfo{}
+		t := &table{header: []string{"CURRENT", "NAME", "SERVER", "AUTH"}}
+		for _, name := range a.cfg.profileNames() {
+			p := a.cfg.Profiles[name]
+			info := profileInfo{Name: name, Current: name == a.cfg.CurrentProfile, Server: p.Server, Auth: "none"}
+			if p.APIKey != "" {
+				info.Auth = "api key"
+			} else if p.Token != "" {
+				info.Auth = "token"
+			}
+			current := ""
+			if info.Current {
+				current = "*"
+			}
+			profiles = append(profiles, info)
+			t.add(current, name, p.Server, info.Auth)
+		}
+		return a.print(profiles, t)
+	}
+}
+
+func configSet(fs *flag.FlagSet) runner {
+	server := fs.String("server", "", "API address, e.g. https://library.example.org")
+	apiKey := fs.String("api-key", "", "API key")
+	token := fs.String("token", "", "JWT")
+	return func(ctx context.Context, a *app, args []string) error {
+		name, err := nameArg(args)
+		if err != nil {
+			return err
+		}
+		profile, ok := a.cfg.Profiles[name]
+		if !ok {
+			profile = &Profile{Server: defaultServer}
+			a.cfg.Profiles[name] = profile
+		}
+		// заданы только явно указанные флаги: пустое значение очищает поле
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "server":
+				profile.Server = *server
+			case "api-key":
+				profile.APIKey = *apiKey
+			case "token":
+				profile.Token = *token
+			}
+		})
+		if a.cfg.CurrentProfile == "" {
+			a.cfg.CurrentProfile = name
+		}
+		return a.cfg.save()
+	}
+}
+
+func configUse(fs *flag.FlagSet) runner {
+	return func(ctx context.Context, a *app, args []string) error {
+		name, err := nameArg(args)
+		if err != nil {
+			return err
+		}
+		if _, ok := a.cfg.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q not found", name)
+		}
+		a.cfg.CurrentProfile = name
+		return a.cfg.save()
+	}
+}
+
+func configDelete(fs *flag.FlagSet) runner {
+	return func(ctx context.Context, a *app, args []string) error {
+		name, err := nameArg(args)
+		if err != nil {
+			return err
+		}
+		if _, ok := a.cfg.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q not found", name)
+		}
+		delete(a.cfg.Profiles, name)
+		if a.cfg.CurrentProfile == name {
+			a.cfg.CurrentProfile = ""
+		}
+		return a.cfg.save()
+	}
+}
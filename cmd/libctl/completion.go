@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// скрытая команда, которую вызывают скрипты автодополнения: libctl __complete <набранные слова>
+const completeCommand = "__complete"
+
+// глобальные флаги со значением - их значение при разборе пропускается
+var globalValueFlags = map[string]bool{"profile": true, "config": true, "output": true, "o": true, "server": true}
+
+const bashCompletion = `# bash completion for libctl
+_libctl() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$(libctl __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" 2>/dev/null)" -- "$cur"))
+}
+complete -o default -F _libctl libctl
+`
+
+const zshCompletion = `#compdef libctl
+_libctl() {
+    local -a candidates
+    candidates=(${(f)"$(libctl __complete ${words[2,CURRENT-1]} 2>/dev/null)"})
+    if (( ${#candidates} )); then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+compdef _libctl libctl
+`
+
+const fishCompletion = `# fish completion for libctl
+complete -c libctl -f -a '(libctl __complete (commandline -opc)[2..-1])'
+`
+
+func completion(fs *flag.FlagSet) runner {
+	return func(ctx context.Context, a *app, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		switch args[0] {
+		case "bash":
+			fmt.Fprint(a.stdout, bashCompletion)
+		case "zsh":
+			fmt.Fprint(a.stdout, zshCompletion)
+		case "fish":
+			fmt.Fprint(a.stdout, fishCompletion)
+		default:
+			return fmt.Errorf("unsupported shell %q, expected bash, zsh or fish", args[0])
+		}
+		return nil
+	}
+}
+
+// complete печатает варианты продолжения для уже набранных слов (без текущего)
+func complete(w io.Writer, words []string) {
+	// значение флага: профили для --profile, форматы для --output
+	if n := len(words); n > 0 {
+		switch strings.TrimLeft(words[n-1], "-") {
+		case "profile":
+			printProfiles(w)
+			return
+		case "output", "o":
+			fmt.Fprintln(w, strings.Join([]string{outputTable, outputJSON, outputYAML}, "\n"))
+			return
+		case "config", "server", "format", "out":
+			if strings.HasPrefix(words[n-1], "-") {
+				if words[n-1] == "--format" || words[n-1] == "-format" {
+					fmt.Fprintln(w, "csv\nndjson\nxlsx")
+				}
+				return
+			}
+		}
+	}
+
+	// глобальные флаги до первой команды
+	var rest []string
+	for i := 0; i < len(words); i++ {
+		if name := strings.TrimLeft(words[i], "-"); strings.HasPrefix(words[i], "-") {
+			if globalValueFlags[name] && !strings.Contains(name, "=") {
+				i++
+			}
+			continue
+		}
+		rest = words[i:]
+		break
+	}
+
+	cmd, args := findCommand(rootCommands, rest)
+	switch {
+	case cmd == nil:
+		for _, c := range rootCommands {
+			fmt.Fprintln(w, c.name)
+		}
+		fmt.Fprintln(w, "--profile\n--config\n--output\n--server")
+	case cmd.setup == nil:
+		for _, c := range cmd.commands {
+			fmt.Fprintln(w, c.name)
+		}
+	default:
+		fs := flag.NewFlagSet(cmd.path, flag.ContinueOnError)
+		cmd.setup(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintln(w, "--"+f.Name)
+		})
+		switch {
+		case cmd.path == "completion" && len(args) == 0:
+			fmt.Fprintln(w, "bash\nzsh\nfish")
+		case (cmd.path == "config use" || cmd.path == "config delete" || cmd.path == "config set") && len(args) == 0:
+			printProfiles(w)
+		}
+	}
+}
+
+func printProfiles(w io.Writer) {
+	path, err := configPath(os.Getenv("LIBCTL_CONFIG"))
+	if err != nil {
+		return
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return
+	}
+	for _, name := range cfg.profileNames() {
+		fmt.Fprintln(w, name)
+	}
+}
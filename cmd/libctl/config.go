@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const defaultServer = "http://localhost:8888"
+
+// Profile - параметры подключения к одному окружению
+type Profile struct {
+	Server string `json:"server"`
+	Token  string `json:"token,omitempty"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+// Config - файл профилей: ~/.config/libctl/config.json
+type Config struct {
+	CurrentProfile string              `json:"current_profile"`
+	Profiles       map[string]*Profile `json:"profiles"`
+
+	path string
+}
+
+// configPath - путь к файлу профилей: флаг --config, LIBCTL_CONFIG или каталог настроек пользователя
+func configPath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if v := os.Getenv("LIBCTL_CONFIG"); v != "" {
+		return v, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "libctl", "config.json"), nil
+}
+
+// loadConfig читает файл профилей; отсутствующий файл - пустой конфиг
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{Profiles: make(map[string]*Profile), path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*Profile)
+	}
+	return cfg, nil
+}
+
+// save записывает файл с правами 0600: в нём хранятся токены и ключи
+func (c *Config) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o600)
+}
+
+func (c *Config) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveProfile выбирает профиль: флаг --profile, LIBCTL_PROFILE, текущий профиль конфига.
+// Переменные LIBCTL_SERVER, LIBCTL_TOKEN и LIBCTL_API_KEY переопределяют значения профиля.
+func (c *Config) resolveProfile(name string) (string, Profile, error) {
+	if name == "" {
+		name = os.Getenv("LIBCTL_PROFILE")
+	}
+	if name == "" {
+		name = c.CurrentProfile
+	}
+
+	var profile Profile
+	if name != "" {
+		p, ok := c.Profiles[name]
+		if !ok {
+			return name, profile, fmt.Errorf("profile %q not found", name)
+		}
+		profile = *p
+	}
+	if v := os.Getenv("LIBCTL_SERVER"); v != "" {
+		profile.Server = v
+	}
+	if v := os.Getenv("LIBCTL_TOKEN"); v != "" {
+		profile.Token = v
+	}
+	if v := os.Getenv("LIBCTL_API_KEY"); v != "" {
+		profile.APIKey = v
+	}
+	if profile.Server == "" {
+		profile.Server = defaultServer
+	}
+	return name, profile, nil
+}
@@ -0,0 +1,165 @@
+// libctl - консольная утилита сотрудников для работы с API библиотеки.
+// Работает через HTTP API (pkg/client), а не напрямую с базой.
+//
+//	libctl [--profile NAME] [-o table|json|yaml] <команда> <подкоманда> [флаги] [аргументы]
+package main
+
+import (
+	"api_library/pkg/client"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// app - общее состояние команд: выбранный профиль, формат вывода и клиент API
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+	stdin  io.Reader
+
+	output      string
+	cfg         *Config
+	profileName string
+	profile     Profile
+	client      *client.Client
+}
+
+// api создаёт клиент при первом обращении - командам config и completion он не нужен
+func (a *app) api() (*client.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+	c, err := client.New(client.Config{BaseURL: a.profile.Server, Token: a.profile.Token, APIKey: a.profile.APIKey})
+	if err != nil {
+		return nil, err
+	}
+	a.client = c
+	return c, nil
+}
+
+func (a *app) print(value interface{}, t *table) error {
+	return printResult(a.stdout, a.output, value, t)
+}
+
+// errUsage - ошибка в аргументах; справка уже выведена
+var errUsage = errors.New("usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == completeCommand {
+		complete(stdout, args[1:])
+		return 0
+	}
+
+	global := flag.NewFlagSet("libctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	profileName := global.String("profile", "", "profile from the config file (default: current profile)")
+	configFile := global.String("config", "", "config file (default: $LIBCTL_CONFIG or ~/.config/libctl/config.json)")
+	output := global.String("output", outputTable, "output format: table, json or yaml")
+	global.StringVar(output, "o", outputTable, "shorthand for --output")
+	server := global.String("server", "", "API address, overrides the profile")
+	global.Usage = func() { printUsage(stderr, global) }
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if *output != outputTable && *output != outputJSON && *output != outputYAML {
+		fmt.Fprintf(stderr, "libctl: unknown output format %q\n", *output)
+		return 2
+	}
+
+	path, err := configPath(*configFile)
+	if err != nil {
+		fmt.Fprintln(stderr, "libctl:", err)
+		return 1
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		fmt.Fprintln(stderr, "libctl:", err)
+		return 1
+	}
+	a := &app{stdout: stdout, stderr: stderr, stdin: stdin, output: *output, cfg: cfg}
+
+	cmd, rest := findCommand(rootCommands, global.Args())
+	if cmd == nil || cmd.setup == nil {
+		if cmd != nil {
+			printCommands(stderr, cmd.name, cmd.commands)
+		} else {
+			global.Usage()
+		}
+		return 2
+	}
+
+	// команды config работают и с ещё не существующим профилем
+	if !cmd.noProfile {
+		if a.profileName, a.profile, err = cfg.resolveProfile(*profileName); err != nil {
+			fmt.Fprintln(stderr, "libctl:", err)
+			return 1
+		}
+		if *server != "" {
+			a.profile.Server = *server
+		}
+	}
+
+	fs := flag.NewFlagSet("libctl "+cmd.path, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	runner := cmd.setup(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: libctl %s %s\n\n%s\n", cmd.path, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, rest)
+	if err != nil {
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := runner(ctx, a, positional); err != nil {
+		if errors.Is(err, errUsage) {
+			fs.Usage()
+			return 2
+		}
+		fmt.Fprintln(stderr, "libctl:", err)
+		return 1
+	}
+	return 0
+}
+
+// parseInterspersed разбирает флаги и до, и после позиционных аргументов: «config set prod --server URL»
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func printUsage(w io.Writer, global *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: libctl [global flags] <command> <subcommand> [flags] [args]")
+	printCommands(w, "", rootCommands)
+	fmt.Fprintln(w, "\nGlobal flags:")
+	global.PrintDefaults()
+}
+
+func printCommands(w io.Writer, parent string, commands []*command) {
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		name := strings.TrimSpace(parent + " " + cmd.name)
+		fmt.Fprintf(w, "  %-22s %s\n", name, cmd.summary)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// table - табличное представление результата; в JSON и YAML выводится исходное значение
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(cells ...interface{}) {
+	row := make([]string, len(cells))
+	for i, cell := range cells {
+		row[i] = fmt.Sprint(cell)
+	}
+	t.rows = append(t.rows, row)
+}
+
+func printResult(w io.Writer, format string, value interface{}, t *table) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputYAML:
+		return writeYAML(w, value)
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// writeYAML выводит значение в YAML через его JSON-представление, поэтому имена полей
+// совпадают с JSON API. Ключи объектов сортируются.
+func writeYAML(w io.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if isScalar(generic) || isEmpty(generic) {
+		buf.WriteString(yamlScalar(generic) + "\n")
+	} else {
+		writeYAMLNode(&buf, generic, 0)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func writeYAMLNode(buf *bytes.Buffer, node interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := v[key]
+			if isScalar(child) || isEmpty(child) {
+				fmt.Fprintf(buf, "%s%s: %s\n", pad, yamlString(key), yamlScalar(child))
+				continue
+			}
+			fmt.Fprintf(buf, "%s%s:\n", pad, yamlString(key))
+			writeYAMLNode(buf, child, indent+1)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if isScalar(item) || isEmpty(item) {
+				fmt.Fprintf(buf, "%s- %s\n", pad, yamlScalar(item))
+				continue
+			}
+			// первая строка вложенного узла идёт после «- », остальные - с отступом
+			var nested bytes.Buffer
+			writeYAMLNode(&nested, item, indent+1)
+			lines := strings.SplitAfter(nested.String(), "\n")
+			fmt.Fprintf(buf, "%s- %s", pad, strings.TrimPrefix(lines[0], pad+"  "))
+			for _, line := range lines[1:] {
+				buf.WriteString(line)
+			}
+		}
+	}
+}
+
+func isScalar(node interface{}) bool {
+	switch node.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
+func isEmpty(node interface{}) bool {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func yamlScalar(node interface{}) string {
+	switch v := node.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	case map[string]interface{}:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	}
+	return fmt.Sprint(node)
+}
+
+// yamlString оставляет строку без кавычек, только если YAML не прочитает её иначе
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "", "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	// строка с цифрой в начале может оказаться датой, которую YAML прочитает как timestamp
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` 0123456789") || strings.HasSuffix(s, " ") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
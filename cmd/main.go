@@ -1,35 +1,379 @@
 package main
 
 import (
+	"api_library/internal/auth"
+	"api_library/internal/cache"
+	"api_library/internal/graphqlapi"
+	"api_library/internal/grpcapi"
 	"api_library/internal/handler"
+	"api_library/internal/health"
+	"api_library/internal/idempotency"
+	"api_library/internal/metrics"
+	"api_library/internal/notify"
+	"api_library/internal/openapi"
+	"api_library/internal/ratelimit"
 	"api_library/internal/repository"
+	"api_library/internal/requestid"
+	"api_library/internal/scheduler"
+	"api_library/internal/tracing"
 	"api_library/internal/usecase"
+	"context"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// адрес HTTP-сервера; его же проверяет подкоманда healthcheck
+	httpAddr = ":8080"
+	// таймаут проверок /readyz
+	readinessTimeout = 2 * time.Second
+	// после сигнала остановки /readyz отвечает 503 это время, чтобы балансировщик перестал направлять запросы,
+	// затем текущие запросы получают shutdownTimeout на завершение
+	shutdownDelay   = 3 * time.Second
+	shutdownTimeout = 5 * time.Second
+	// максимальное время одного запуска фоновой задачи
+	jobTimeout = 10 * time.Minute
+	// сколько хранится история запусков задач, если не задан JOB_RUNS_RETENTION
+	defaultJobRunsRetention = 30 * 24 * time.Hour
+	// сколько удалённые записи хранятся в корзине, если не задан TRASH_RETENTION
+	defaultTrashRetention = 30 * 24 * time.Hour
+	// максимальное число операций в POST /batch, если не задан BATCH_MAX_SIZE
+	defaultBatchMaxSize = 1000
+	// адрес gRPC-сервера, если не задан GRPC_ADDR
+	defaultGRPCAddr = ":9090"
+	// сколько хранятся ответы на запросы с Idempotency-Key, если не задан IDEMPOTENCY_TTL
+	defaultIdempotencyTTL = 24 * time.Hour
+	// ограничения запросов GraphQL, если не заданы GRAPHQL_MAX_DEPTH и GRAPHQL_MAX_COMPLEXITY
+	defaultGraphQLMaxDepth      = 8
+	defaultGraphQLMaxComplexity = 5000
 )
 
 func main() {
-	db, err := repository.ConnectDB()
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		os.Exit(healthcheck(os.Args[2:]))
+	}
+
+	// Трассировка OpenTelemetry: спаны HTTP-запросов, методов сервиса и SQL-запросов
+	tracingConfig, err := tracing.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Подключение ждёт доступности базы с повторами; настройки пула - из переменных окружения
+	dbConfig, err := repository.DBConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := repository.ConnectDB(context.Background(), dbConfig)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
+	resilienceConfig, err := repository.ResilienceConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// общий предохранитель для всех репозиториев основной базы
+	resilience := repository.NewResilience(resilienceConfig)
+
+	// Реплики для чтения: Get* идут на реплики с допустимым отставанием, изменения - на основную базу
+	replicas, err := repository.OpenReplicas(dbConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, replica := range replicas {
+		defer replica.Close()
+	}
+	replicaConfig, err := repository.ReplicaConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	dbRouter := repository.NewRouter(db, replicas, replicaConfig)
+	monitorCtx, stopMonitor := context.WithCancel(context.Background())
+	defer stopMonitor()
+	go dbRouter.Monitor(monitorCtx)
+
+	// Метрики Prometheus: HTTP, пул соединений, вызовы репозитория и размер каталога
+	metricsRegistry := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(metricsRegistry)
+	metrics.RegisterDBStats(metricsRegistry, db)
+	metrics.RegisterReplicaStats(metricsRegistry, dbRouter)
+	metrics.RegisterCatalogStats(metricsRegistry, repository.NewStatsRepository(db))
+
+	// Готовность: база отвечает, схема из init.sql применена, сервис не останавливается
+	healthRepo := repository.NewHealthRepository(db)
+	healthChecker := health.NewChecker(readinessTimeout)
+	healthChecker.Add("database", healthRepo.Ping)
+	healthChecker.Add("schema", healthRepo.CheckSchema)
 
 	// Инициализация репозитория
-	repo := repository.NewRepository(db)
+	// повторы чтений и предохранитель - под метриками, чтобы учитывался итог вызова
+	repo := metrics.InstrumentRepository(repository.NewResilientRepository(repository.NewReplicatedRepository(dbRouter), resilience), metricsRegistry)
+
+	// Кэш GetAuthor и GetBook поверх метрик: попадания в кэш не считаются запросами к базе
+	cacheConfig, err := cache.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	readCache, err := cache.New(cacheConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if readCache != nil {
+		repo = cache.NewRepository(repo, readCache, cacheConfig.TTL)
+	}
 
 	// Инициализация сервисa
-	service := usecase.NewService(repo)
+	service := usecase.NewTracedService(usecase.NewService(repo))
+
+	// Аутентификация: JWT и API-ключи
+	tokenConfig, err := auth.TokenConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	tokenIssuer := auth.NewTokenIssuer(tokenConfig)
+	authRepo := repository.NewResilientAuthRepository(repository.NewAuthRepository(db), resilience)
+	authService := usecase.NewAuthService(authRepo, tokenIssuer)
+	// OAI-PMH открыт для сборщиков сводных каталогов без аутентификации, документация API - для всех,
+	// метрики - для Prometheus, проверки состояния - для оркестратора
+	authMiddleware := auth.NewMiddleware(tokenIssuer, authService, "/auth/token", "/oai", "/openapi.json", "/docs", "/metrics", "/healthz", "/readyz")
+
+	// Авторизация: роли и права
+	rbacService := usecase.NewRBACService(repository.NewResilientRBACRepository(repository.NewRBACRepository(db), resilience), authRepo)
+	authorizer := auth.NewAuthorizer(rbacService)
+
+	if username := os.Getenv("ADMIN_USERNAME"); username != "" {
+		password := os.Getenv("ADMIN_PASSWORD")
+		if err := auth.CheckInitialPassword(username, password); err != nil {
+			log.Fatalf("invalid ADMIN_PASSWORD: %v", err)
+		}
+		userID, created, err := authService.EnsureUser(context.Background(), username, password)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if created {
+			if err := rbacService.SetUserRoles(context.Background(), userID, []string{auth.RoleAdmin}); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	// Инициализация планировщика фоновых задач
+	jobRepo := repository.NewJobRepository(db)
+	jobScheduler := scheduler.New(jobRepo, jobTimeout)
+
+	jobRunsRetention := defaultJobRunsRetention
+	if v := os.Getenv("JOB_RUNS_RETENTION"); v != "" {
+		if jobRunsRetention, err = time.ParseDuration(v); err != nil || jobRunsRetention <= 0 {
+			log.Fatalf("Некорректный JOB_RUNS_RETENTION: %q", v)
+		}
+	}
+	err = jobScheduler.Register("job-runs-cleanup", "@daily", "Удаление старой истории запусков задач", func(ctx context.Context) error {
+		purged, err := jobRepo.PurgeJobRuns(ctx, time.Now().Add(-jobRunsRetention))
+		if err != nil {
+			return err
+		}
+		log.Printf("Удалено записей истории запусков: %d", purged)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Уведомления: SMTP, если настроен, иначе вывод в лог
+	var notifier notify.Notifier = notify.LogNotifier{}
+	if smtpConfig, ok := notify.SMTPConfigFromEnv(); ok {
+		notifier = notify.NewSMTPNotifier(smtpConfig)
+	}
+	outbox := notify.NewOutbox(repository.NewOutboxRepository(db), notifier)
+	if err := jobScheduler.Register("notifications-dispatch", "@every 1m", "Отправка уведомлений из outbox", outbox.Dispatch); err != nil {
+		log.Fatal(err)
+	}
+
+	trashRetention := defaultTrashRetention
+	if v := os.Getenv("TRASH_RETENTION"); v != "" {
+		if trashRetention, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Некорректный TRASH_RETENTION: %v", err)
+		}
+	}
+	err = jobScheduler.Register("trash-purge", "@daily", "Окончательное удаление записей из корзины", func(ctx context.Context) error {
+		purged, err := service.PurgeDeleted(ctx, trashRetention)
+		if err != nil {
+			return err
+		}
+		log.Printf("Из корзины удалено записей: %d", purged)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	idempotencyTTL := defaultIdempotencyTTL
+	if v := os.Getenv("IDEMPOTENCY_TTL"); v != "" {
+		if idempotencyTTL, err = time.ParseDuration(v); err != nil || idempotencyTTL <= 0 {
+			log.Fatalf("Некорректный IDEMPOTENCY_TTL: %q", v)
+		}
+	}
+	idempotent := idempotency.NewMiddleware(repository.NewIdempotencyRepository(db), idempotencyTTL)
+	if err := jobScheduler.Register("idempotency-cleanup", "@hourly", "Удаление истёкших ключей идемпотентности", idempotent.Cleanup); err != nil {
+		log.Fatal(err)
+	}
+
+	// Ограничение частоты запросов: корзины токенов по API-ключу или IP
+	rateLimitConfig, err := ratelimit.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var rateLimitStore ratelimit.Store
+	switch rateLimitConfig.Store {
+	case ratelimit.StoreMemory:
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case ratelimit.StorePostgres:
+		rateLimitStore = ratelimit.NewPostgresStore(repository.NewRateLimitRepository(db))
+	}
+	// проверки состояния и метрики опрашиваются часто и не ограничиваются
+	rateLimiter := ratelimit.NewMiddleware(rateLimitStore, rateLimitConfig, http.DefaultServeMux, "/healthz", "/readyz", "/metrics")
+	if err := jobScheduler.Register("rate-limit-cleanup", "@every 10m", "Удаление заполнившихся корзин ограничения частоты запросов", rateLimiter.Cleanup); err != nil {
+		log.Fatal(err)
+	}
+
+	jobScheduler.Start()
+	defer jobScheduler.Stop()
+
+	batchMaxSize := defaultBatchMaxSize
+	if v := os.Getenv("BATCH_MAX_SIZE"); v != "" {
+		if batchMaxSize, err = strconv.Atoi(v); err != nil || batchMaxSize <= 0 {
+			log.Fatalf("Некорректный BATCH_MAX_SIZE: %q", v)
+		}
+	}
+
+	graphQLLimits := graphqlapi.Limits{MaxDepth: defaultGraphQLMaxDepth, MaxComplexity: defaultGraphQLMaxComplexity}
+	if v := os.Getenv("GRAPHQL_MAX_DEPTH"); v != "" {
+		if graphQLLimits.MaxDepth, err = strconv.Atoi(v); err != nil || graphQLLimits.MaxDepth < 0 {
+			log.Fatalf("Некорректный GRAPHQL_MAX_DEPTH: %q", v)
+		}
+	}
+	if v := os.Getenv("GRAPHQL_MAX_COMPLEXITY"); v != "" {
+		if graphQLLimits.MaxComplexity, err = strconv.Atoi(v); err != nil || graphQLLimits.MaxComplexity < 0 {
+			log.Fatalf("Некорректный GRAPHQL_MAX_COMPLEXITY: %q", v)
+		}
+	}
+	graphQLExecutor, err := graphqlapi.NewExecutor(service, graphQLLimits)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Инициализация обработчиков
 	authorHandler := handler.NewAuthorHandler(service)
 	bookHandler := handler.NewBookHandler(service)
+	jobHandler := handler.NewJobHandler(jobScheduler)
+	authHandler := handler.NewAuthHandler(authService)
+	rbacHandler := handler.NewRBACHandler(rbacService)
+	trashHandler := handler.NewTrashHandler(service)
+	auditHandler := handler.NewAuditHandler(usecase.NewAuditService(repository.NewAuditRepository(db)))
+	batchRepo := repository.NewBatchRepository(dbRouter)
+	if readCache != nil {
+		batchRepo = cache.NewBatchRepository(batchRepo, repo, readCache)
+	}
+	batchHandler := handler.NewBatchHandler(usecase.NewBatchService(batchRepo, batchMaxSize), batchMaxSize)
+	oaiHandler := handler.NewOAIHandler(usecase.NewOAIService(repository.NewOAIRepository(db)), handler.OAIConfigFromEnv())
+	exportHandler := handler.NewExportHandler(service)
+	graphQLHandler := handler.NewGraphQLHandler(graphQLExecutor)
+	importHandler := handler.NewImportHandler(usecase.NewImportService(repository.NewImportRepository(dbRouter)), usecase.NewMARCService(repo))
+
+	// Маршруты и права доступа к ним; список маршрутов сверяется с описанием OpenAPI
+	var patterns []string
+	for _, rt := range routes(routeHandlers{
+		authorizer:  authorizer,
+		idempotent:  idempotent,
+		cacheMaxAge: cacheConfig.MaxAge,
+		author:      authorHandler,
+		book:        bookHandler,
+		export:      exportHandler,
+		batch:       batchHandler,
+		graphQL:     graphQLHandler,
+		importer:    importHandler,
+		trash:       trashHandler,
+		job:         jobHandler,
+		rbac:        rbacHandler,
+		audit:       auditHandler,
+		oai:         oaiHandler,
+		auth:        authHandler,
+		metrics:     metricsRegistry,
+		health:      healthChecker,
+	}) {
+		patterns = append(patterns, rt.pattern)
+		http.HandleFunc(rt.pattern, rt.handler)
+	}
 
-	// Маршруты
-	http.HandleFunc("/authors", authorHandler.HandleAuthors)
-	http.HandleFunc("/authors/", authorHandler.HandleAuthor)
-	http.HandleFunc("/books", bookHandler.HandleBooks)
-	http.HandleFunc("/books/", bookHandler.HandleBook)
+	if err := openapi.Verify(patterns); err != nil {
+		log.Fatal(err)
+	}
 
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	// gRPC на отдельном порту с тем же сервисом, аутентификацией и правами
+	grpcAddr := defaultGRPCAddr
+	if v := os.Getenv("GRPC_ADDR"); v != "" {
+		grpcAddr = v
+	}
+	grpcListener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	grpcServer := grpcapi.NewServer(service, authMiddleware, authorizer)
+	go func() {
+		// после GracefulStop Serve возвращает nil
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	// снаружи внутрь: метрики, ID запроса, трассировка, лимит по IP, аутентификация, лимит по пользователю,
+	// время последнего изменения клиента для чтений с реплик, маршрутизация
+	var server http.Handler = rateLimiter.Wrap(dbRouter.Wrap(http.DefaultServeMux))
+	server = authMiddleware.Wrap(server)
+	server = rateLimiter.WrapIP(server)
+	server = tracing.Middleware(http.DefaultServeMux, server)
+	server = requestid.Middleware(server)
+	server = httpMetrics.Wrap(http.DefaultServeMux, server)
+	httpServer := &http.Server{Addr: httpAddr, Handler: server}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Плавная остановка по SIGTERM/SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+	log.Println("Получен сигнал остановки")
+	healthChecker.SetShuttingDown()
+	time.Sleep(shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Ошибка при остановке HTTP-сервера: %v", err)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
 }
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"api_library/internal/openapi"
+	"testing"
+)
+
+// Маршрут, добавленный без описания в openapi.json, ломает тест, а не запуск сервиса
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	var patterns []string
+	for _, rt := range routes(routeHandlers{}) {
+		patterns = append(patterns, rt.pattern)
+	}
+	if err := openapi.Verify(patterns); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/cache"
+	"api_library/internal/entity"
+	"api_library/internal/handler"
+	"api_library/internal/health"
+	"api_library/internal/idempotency"
+	"api_library/internal/metrics"
+	"api_library/internal/openapi"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// routeHandlers - обработчики и промежуточные слои, из которых собираются маршруты
+type routeHandlers struct {
+	authorizer  *auth.Authorizer
+	idempotent  *idempotency.Middleware
+	cacheMaxAge time.Duration
+
+	author   *handler.AuthorHandler
+	book     *handler.BookHandler
+	export   *handler.ExportHandler
+	batch    *handler.BatchHandler
+	graphQL  *handler.GraphQLHandler
+	importer *handler.ImportHandler
+	trash    *handler.TrashHandler
+	job      *handler.JobHandler
+	rbac     *handler.RBACHandler
+	audit    *handler.AuditHandler
+	oai      *handler.OAIHandler
+	auth     *handler.AuthHandler
+	metrics  *metrics.Registry
+	health   *health.Checker
+}
+
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+// routes - маршруты HTTP API и права доступа к ним. Обработчики при сборке не вызываются,
+// поэтому список можно получить и с пустыми routeHandlers (так его сверяет с OpenAPI тест).
+func routes(h routeHandlers) []route {
+	return []route{
+		{"/authors", h.authorizer.Require(auth.Permissions{
+			http.MethodGet:  auth.PermAuthorsRead,
+			http.MethodPost: auth.PermAuthorsCreate,
+		}, cache.CacheControl(h.cacheMaxAge, h.idempotent.Wrap(h.author.HandleAuthors)))},
+		{"/authors/", h.authorizer.RequireFunc(itemPermission(
+			auth.PermAuthorsRead, auth.PermAuthorsUpdate, auth.PermAuthorsDelete,
+		), cache.CacheControl(h.cacheMaxAge, h.author.HandleAuthor))},
+		{"/books", h.authorizer.Require(auth.Permissions{
+			http.MethodGet:  auth.PermBooksRead,
+			http.MethodPost: auth.PermBooksCreate,
+		}, cache.CacheControl(h.cacheMaxAge, h.idempotent.Wrap(h.book.HandleBooks)))},
+		{"/books/", h.authorizer.RequireFunc(itemPermission(
+			auth.PermBooksRead, auth.PermBooksUpdate, auth.PermBooksDelete,
+		), cache.CacheControl(h.cacheMaxAge, h.book.HandleBook))},
+		{"/export/authors", h.authorizer.Require(auth.Permissions{
+			http.MethodGet: auth.PermAuthorsRead,
+		}, h.export.HandleExportAuthors)},
+		{"/export/books", h.authorizer.Require(auth.Permissions{
+			http.MethodGet: auth.PermBooksRead,
+		}, h.export.HandleExportBooks)},
+		// права проверяются для каждой операции пакета отдельно
+		{"/batch", h.authorizer.Resolve(h.idempotent.Wrap(h.batch.HandleBatch))},
+		// права проверяются для каждого запрошенного поля
+		{"/graphql", h.authorizer.Resolve(h.graphQL.HandleGraphQL)},
+		{"/import", h.authorizer.RequireFunc(importPermission, h.importer.HandleImport)},
+		{"/import/marc", h.authorizer.Require(auth.Permissions{
+			http.MethodPost: auth.PermBooksCreate,
+		}, h.importer.HandleImportMARC)},
+		{"/trash", h.authorizer.Require(auth.Permissions{
+			http.MethodGet: auth.PermTrashRead,
+		}, h.trash.HandleTrash)},
+		{"/admin/jobs", h.authorizer.Require(auth.Permissions{
+			http.MethodGet: auth.PermJobsRead,
+		}, h.job.HandleJobs)},
+		{"/admin/jobs/", h.authorizer.Require(auth.Permissions{
+			http.MethodGet:  auth.PermJobsRead,
+			http.MethodPost: auth.PermJobsRun,
+		}, h.job.HandleJob)},
+		{"/admin/roles", h.authorizer.Require(auth.Permissions{
+			http.MethodGet:  auth.PermRolesRead,
+			http.MethodPost: auth.PermRolesManage,
+		}, h.rbac.HandleRoles)},
+		{"/admin/roles/", h.authorizer.Require(auth.Permissions{
+			http.MethodGet:    auth.PermRolesRead,
+			http.MethodPut:    auth.PermRolesManage,
+			http.MethodDelete: auth.PermRolesManage,
+		}, h.rbac.HandleRole)},
+		{"/admin/users", h.authorizer.Require(auth.Permissions{
+			http.MethodPost: auth.PermUsersManage,
+		}, h.auth.HandleUsers)},
+		{"/admin/users/", h.authorizer.Require(auth.Permissions{
+			http.MethodGet: auth.PermRolesRead,
+			http.MethodPut: auth.PermRolesManage,
+		}, h.rbac.HandleUserRoles)},
+		{"/audit", h.authorizer.Require(auth.Permissions{
+			http.MethodGet: auth.PermAuditRead,
+		}, h.audit.HandleAudit)},
+		{"/oai", h.oai.HandleOAI},
+		{"/auth/token", h.auth.HandleToken},
+		{"/auth/api-keys", h.authorizer.Require(auth.Permissions{
+			http.MethodGet:  auth.PermAPIKeysManage,
+			http.MethodPost: auth.PermAPIKeysManage,
+		}, h.auth.HandleAPIKeys)},
+		{"/auth/api-keys/", h.authorizer.Require(auth.Permissions{
+			http.MethodDelete: auth.PermAPIKeysManage,
+		}, h.auth.HandleAPIKey)},
+		{"/openapi.json", openapi.HandleSpec},
+		{"/docs", openapi.HandleDocs},
+		{"/metrics", h.metrics.ServeHTTP},
+		{"/healthz", h.health.HandleLiveness},
+		{"/readyz", h.health.HandleReadiness},
+	}
+}
+
+// itemPermission выбирает право для /authors/{id} и /books/{id} с подресурсами:
+// восстановить из корзины может тот, кто может удалить, откатить версию - тот, кто может изменить
+func itemPermission(read, update, remove string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		switch r.Method {
+		case http.MethodGet:
+			return read
+		case http.MethodPut:
+			return update
+		case http.MethodDelete:
+			return remove
+		case http.MethodPost:
+			if strings.HasSuffix(r.URL.Path, "/restore") {
+				return remove
+			}
+			if strings.HasSuffix(r.URL.Path, "/revert") {
+				return update
+			}
+		}
+		return ""
+	}
+}
+
+// importPermission - импорт требует права на создание импортируемых записей;
+// неизвестный тип отклоняет сам обработчик
+func importPermission(r *http.Request) string {
+	if r.Method != http.MethodPost {
+		return ""
+	}
+	if r.URL.Query().Get("type") == entity.ImportAuthors {
+		return auth.PermAuthorsCreate
+	}
+	return auth.PermBooksCreate
+}
@@ -6,7 +6,10 @@ CREATE TABLE authors (
                          first_name VARCHAR(100),
                          last_name VARCHAR(100),
                          biography TEXT,
-                         birth_date DATE
+                         birth_date DATE,
+                         created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                         updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                         deleted_at TIMESTAMPTZ
 );
 
 CREATE TABLE books (
@@ -14,5 +17,161 @@ CREATE TABLE books (
                        title VARCHAR(255),
                        author_id INT,
                        year INT,
-                       isbn VARCHAR(13)
-);
\ No newline at end of file
+                       isbn VARCHAR(13),
+                       created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                       updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                       deleted_at TIMESTAMPTZ
+);
+
+-- Предыдущие состояния записей: версия действовала в интервале [valid_from, valid_to)
+CREATE TABLE author_versions (
+                                 author_id INT NOT NULL,
+                                 version INT NOT NULL,
+                                 first_name VARCHAR(100),
+                                 last_name VARCHAR(100),
+                                 biography TEXT,
+                                 birth_date DATE,
+                                 valid_from TIMESTAMPTZ NOT NULL,
+                                 valid_to TIMESTAMPTZ NOT NULL,
+                                 PRIMARY KEY (author_id, version)
+);
+
+CREATE TABLE book_versions (
+                               book_id INT NOT NULL,
+                               version INT NOT NULL,
+                               title VARCHAR(255),
+                               author_id INT,
+                               year INT,
+                               isbn VARCHAR(13),
+                               valid_from TIMESTAMPTZ NOT NULL,
+                               valid_to TIMESTAMPTZ NOT NULL,
+                               PRIMARY KEY (book_id, version)
+);
+CREATE TABLE job_runs (
+                          id SERIAL PRIMARY KEY,
+                          job_name VARCHAR(100) NOT NULL,
+                          trigger VARCHAR(20) NOT NULL,
+                          status VARCHAR(20) NOT NULL,
+                          error TEXT,
+                          scheduled_at TIMESTAMPTZ NOT NULL,
+                          started_at TIMESTAMPTZ NOT NULL,
+                          finished_at TIMESTAMPTZ,
+                          UNIQUE (job_name, scheduled_at)
+);
+CREATE INDEX job_runs_finished_idx ON job_runs (finished_at);
+
+CREATE TABLE notification_outbox (
+                                     id SERIAL PRIMARY KEY,
+                                     kind VARCHAR(50) NOT NULL,
+                                     recipient VARCHAR(255) NOT NULL,
+                                     subject TEXT NOT NULL,
+                                     body TEXT NOT NULL,
+                                     status VARCHAR(20) NOT NULL DEFAULT 'pending',
+                                     attempts INT NOT NULL DEFAULT 0,
+                                     last_error TEXT,
+                                     next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                     locked_until TIMESTAMPTZ,
+                                     created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                     sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX notification_outbox_pending_idx ON notification_outbox (next_attempt_at) WHERE status IN ('pending', 'sending');
+
+CREATE TABLE users (
+                       id SERIAL PRIMARY KEY,
+                       username VARCHAR(100) NOT NULL UNIQUE,
+                       password_hash TEXT NOT NULL,
+                       disabled BOOLEAN NOT NULL DEFAULT FALSE,
+                       created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE api_keys (
+                          id SERIAL PRIMARY KEY,
+                          name VARCHAR(100) NOT NULL,
+                          prefix VARCHAR(16) NOT NULL,
+                          key_hash CHAR(64) NOT NULL UNIQUE,
+                          user_id INT NOT NULL REFERENCES users (id),
+                          created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                          expires_at TIMESTAMPTZ,
+                          last_used_at TIMESTAMPTZ,
+                          revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE roles (
+                       name VARCHAR(50) PRIMARY KEY,
+                       description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+                                  role VARCHAR(50) NOT NULL REFERENCES roles (name) ON DELETE CASCADE ON UPDATE CASCADE,
+                                  permission VARCHAR(100) NOT NULL,
+                                  PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE user_roles (
+                            user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                            role VARCHAR(50) NOT NULL REFERENCES roles (name) ON DELETE CASCADE ON UPDATE CASCADE,
+                            PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Полный доступ'),
+    ('librarian', 'Работа с каталогом'),
+    ('patron', 'Читатель: просмотр каталога'),
+    ('read-only', 'Только чтение');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', '*'),
+    ('librarian', 'authors:*'),
+    ('librarian', 'books:*'),
+    ('librarian', 'jobs:read'),
+    ('librarian', 'trash:read'),
+    ('librarian', 'api_keys:manage'),
+    ('patron', 'authors:read'),
+    ('patron', 'books:read'),
+    ('patron', 'api_keys:manage'),
+    ('read-only', 'authors:read'),
+    ('read-only', 'books:read'),
+    ('read-only', 'api_keys:manage');
+
+CREATE TABLE audit_log (
+                           id BIGSERIAL PRIMARY KEY,
+                           actor VARCHAR(100) NOT NULL,
+                           action VARCHAR(20) NOT NULL,
+                           entity VARCHAR(50) NOT NULL,
+                           entity_id INT NOT NULL,
+                           before_data JSONB,
+                           after_data JSONB,
+                           changes JSONB,
+                           request_id VARCHAR(128),
+                           created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, created_at);
+
+-- сохранённые ответы на запросы с заголовком Idempotency-Key; status IS NULL - запрос ещё выполняется
+CREATE TABLE idempotency_keys (
+                                  scope VARCHAR(100) NOT NULL,
+                                  key VARCHAR(255) NOT NULL,
+                                  fingerprint VARCHAR(64) NOT NULL,
+                                  status INT,
+                                  headers JSONB,
+                                  body BYTEA,
+                                  -- до какого момента выполняющийся запрос держит ключ; продлевается, пока запрос идёт
+                                  locked_until TIMESTAMPTZ,
+                                  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                                  expires_at TIMESTAMPTZ NOT NULL,
+                                  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_expires_idx ON idempotency_keys (expires_at);
+
+-- корзины токенов ограничения частоты запросов, общие для всех экземпляров сервиса
+CREATE TABLE rate_limit_buckets (
+                                    key VARCHAR(100) PRIMARY KEY,
+                                    tokens DOUBLE PRECISION NOT NULL,
+                                    allowed BOOLEAN NOT NULL,
+                                    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX rate_limit_buckets_updated_idx ON rate_limit_buckets (updated_at);
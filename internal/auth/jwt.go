@@ -0,0 +1,330 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+
+	defaultTokenTTL = time.Hour
+	// допустимое расхождение часов между сервисами
+	clockSkew = 30 * time.Second
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+type TokenConfig struct {
+	Algorithm  string
+	Secret     []byte
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+	Issuer     string
+	Audience   string
+	TTL        time.Duration
+}
+
+type Claims struct {
+	Subject   string   `json:"sub"`
+	UserID    int      `json:"uid"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  audience `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// audience в JWT может быть как строкой, так и массивом строк
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func (a audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// minSecretLength - не короче выхода HMAC-SHA256, иначе ключ проще подобрать, чем подделать подпись
+const minSecretLength = 32
+
+// weakSecrets - значения из примеров и шаблонов, с которыми сервис не запускается
+var weakSecrets = []string{"change-me", "changeme", "secret", "admin", "password"}
+
+// checkSecret отклоняет пустой, короткий или взятый из примеров секрет
+func checkSecret(secret string) error {
+	for _, weak := range weakSecrets {
+		if strings.EqualFold(strings.TrimSpace(secret), weak) {
+			return fmt.Errorf("placeholder value %q is not allowed", weak)
+		}
+	}
+	if len(secret) < minSecretLength {
+		return fmt.Errorf("at least %d bytes required", minSecretLength)
+	}
+	return nil
+}
+
+// TokenConfigFromEnv читает JWT_ALGORITHM, JWT_SECRET, JWT_PRIVATE_KEY_FILE, JWT_PUBLIC_KEY_FILE, JWT_ISSUER, JWT_AUDIENCE, JWT_TTL
+func TokenConfigFromEnv() (TokenConfig, error) {
+	cfg := TokenConfig{
+		Algorithm: os.Getenv("JWT_ALGORITHM"),
+		Issuer:    os.Getenv("JWT_ISSUER"),
+		Audience:  os.Getenv("JWT_AUDIENCE"),
+		TTL:       defaultTokenTTL,
+	}
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgHS256
+	}
+	if ttl := os.Getenv("JWT_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid JWT_TTL: %v", err)
+		}
+		if d <= 0 {
+			return cfg, fmt.Errorf("invalid JWT_TTL %q: expected a positive duration", ttl)
+		}
+		cfg.TTL = d
+	}
+
+	switch cfg.Algorithm {
+	case AlgHS256:
+		cfg.Secret = []byte(os.Getenv("JWT_SECRET"))
+		if err := checkSecret(string(cfg.Secret)); err != nil {
+			return cfg, fmt.Errorf("invalid JWT_SECRET: %w", err)
+		}
+	case AlgRS256:
+		if path := os.Getenv("JWT_PRIVATE_KEY_FILE"); path != "" {
+			key, err := loadPrivateKey(path)
+			if err != nil {
+				return cfg, err
+			}
+			cfg.PrivateKey = key
+			cfg.PublicKey = &key.PublicKey
+		}
+		if path := os.Getenv("JWT_PUBLIC_KEY_FILE"); path != "" {
+			key, err := loadPublicKey(path)
+			if err != nil {
+				return cfg, err
+			}
+			cfg.PublicKey = key
+		}
+		if cfg.PublicKey == nil {
+			return cfg, fmt.Errorf("RS256 requires JWT_PUBLIC_KEY_FILE or JWT_PRIVATE_KEY_FILE")
+		}
+	default:
+		return cfg, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.Algorithm)
+	}
+	return cfg, nil
+}
+
+func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key %s: %v", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is not RSA", path)
+	}
+	return key, nil
+}
+
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key %s: %v", path, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not RSA", path)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	return block, nil
+}
+
+type TokenIssuer struct {
+	cfg TokenConfig
+}
+
+func NewTokenIssuer(cfg TokenConfig) *TokenIssuer {
+	return &TokenIssuer{cfg: cfg}
+}
+
+func (t *TokenIssuer) TTL() time.Duration {
+	return t.cfg.TTL
+}
+
+// Issue подписывает токен для пользователя; для RS256 нужен приватный ключ
+func (t *TokenIssuer) Issue(userID int, username string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		Subject:   username,
+		UserID:    userID,
+		Issuer:    t.cfg.Issuer,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(t.cfg.TTL).Unix(),
+	}
+	if t.cfg.Audience != "" {
+		claims.Audience = audience{t.cfg.Audience}
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": t.cfg.Algorithm, "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	signature, err := t.sign(signingInput)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (t *TokenIssuer) sign(signingInput string) ([]byte, error) {
+	switch t.cfg.Algorithm {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, t.cfg.Secret)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	case AlgRS256:
+		if t.cfg.PrivateKey == nil {
+			return nil, fmt.Errorf("RS256 signing requires a private key")
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		return rsa.SignPKCS1v15(rand.Reader, t.cfg.PrivateKey, crypto.SHA256, digest[:])
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", t.cfg.Algorithm)
+	}
+}
+
+// Verify проверяет подпись, срок действия, издателя и аудиторию токена
+func (t *TokenIssuer) Verify(token string) (Claims, error) {
+	var claims Claims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrInvalidToken
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	// алгоритм задаётся конфигурацией, а не токеном - иначе возможна подмена на "none" или HS256 с публичным ключом
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != t.cfg.Algorithm {
+		return claims, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	signingInput := parts[0] + "." + parts[1]
+	switch t.cfg.Algorithm {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, t.cfg.Secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return claims, ErrInvalidToken
+		}
+	case AlgRS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(t.cfg.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return claims, ErrInvalidToken
+		}
+	default:
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return claims, ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return claims, ErrInvalidToken
+	}
+	if t.cfg.Issuer != "" && claims.Issuer != t.cfg.Issuer {
+		return claims, ErrInvalidToken
+	}
+	if t.cfg.Audience != "" && !claims.Audience.contains(t.cfg.Audience) {
+		return claims, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (a audience) contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var hmacSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signToken собирает токен с произвольными заголовком и полями, подписанный как issuer
+func signToken(t *testing.T, issuer *TokenIssuer, header map[string]string, claims any) string {
+	t.Helper()
+	headerJSON, _ := json.Marshal(header)
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := issuer.sign(signingInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims(now time.Time) Claims {
+	return Claims{
+		Subject:   "alice",
+		UserID:    1,
+		Issuer:    "library",
+		Audience:  audience{"api"},
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(time.Hour).Unix(),
+	}
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	key := testRSAKey(t)
+	configs := map[string]TokenConfig{
+		AlgHS256: {Algorithm: AlgHS256, Secret: hmacSecret, Issuer: "library", Audience: "api", TTL: time.Hour},
+		AlgRS256: {Algorithm: AlgRS256, PrivateKey: key, PublicKey: &key.PublicKey, Issuer: "library", Audience: "api", TTL: time.Hour},
+	}
+	for alg, cfg := range configs {
+		t.Run(alg, func(t *testing.T) {
+			issuer := NewTokenIssuer(cfg)
+			token, err := issuer.Issue(42, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := issuer.Verify(token)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.UserID != 42 || claims.Subject != "alice" || claims.Issuer != "library" || !claims.Audience.contains("api") {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	now := time.Now()
+	key := testRSAKey(t)
+	hs := NewTokenIssuer(TokenConfig{Algorithm: AlgHS256, Secret: hmacSecret, Issuer: "library", Audience: "api"})
+	rs := NewTokenIssuer(TokenConfig{Algorithm: AlgRS256, PrivateKey: key, PublicKey: &key.PublicKey, Issuer: "library", Audience: "api"})
+	hsHeader := map[string]string{"alg": AlgHS256, "typ": "JWT"}
+
+	// публичный ключ известен всем; им подписывают HS256-токен в расчёте, что сервер примет его как секрет HMAC
+	publicDER, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	withPublicKey := NewTokenIssuer(TokenConfig{Algorithm: AlgHS256, Secret: publicPEM})
+
+	with := func(change func(*Claims)) Claims {
+		claims := validClaims(now)
+		change(&claims)
+		return claims
+	}
+	valid := signToken(t, hs, hsHeader, validClaims(now))
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name     string
+		verifier *TokenIssuer
+		token    string
+		want     error
+	}{
+		{"alg none", hs, base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + ".", ErrInvalidToken},
+		{"alg none with RS256 verifier", rs, base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".", ErrInvalidToken},
+		{"HS256 signed with RSA public key", rs, signToken(t, withPublicKey, hsHeader, validClaims(now)), ErrInvalidToken},
+		{"RS256 token for HS256 verifier", hs, signToken(t, rs, map[string]string{"alg": AlgRS256}, validClaims(now)), ErrInvalidToken},
+		{"expired beyond skew", hs, signToken(t, hs, hsHeader, with(func(c *Claims) { c.ExpiresAt = now.Add(-clockSkew - time.Minute).Unix() })), ErrTokenExpired},
+		{"no exp", hs, signToken(t, hs, hsHeader, with(func(c *Claims) { c.ExpiresAt = 0 })), ErrTokenExpired},
+		{"nbf beyond skew", hs, signToken(t, hs, hsHeader, with(func(c *Claims) { c.NotBefore = now.Add(clockSkew + time.Minute).Unix() })), ErrInvalidToken},
+		{"wrong issuer", hs, signToken(t, hs, hsHeader, with(func(c *Claims) { c.Issuer = "other" })), ErrInvalidToken},
+		{"no issuer", hs, signToken(t, hs, hsHeader, with(func(c *Claims) { c.Issuer = "" })), ErrInvalidToken},
+		{"wrong audience", hs, signToken(t, hs, hsHeader, with(func(c *Claims) { c.Audience = audience{"other", "admin"} })), ErrInvalidToken},
+		{"no audience", hs, signToken(t, hs, hsHeader, with(func(c *Claims) { c.Audience = nil })), ErrInvalidToken},
+		{"tampered signature", hs, parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString([]byte("forged")), ErrInvalidToken},
+		{"tampered payload", hs, parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin","uid":1,"exp":9999999999}`)) + "." + parts[2], ErrInvalidToken},
+		{"wrong secret", hs, signToken(t, NewTokenIssuer(TokenConfig{Algorithm: AlgHS256, Secret: []byte("another-secret-another-secret-00")}), hsHeader, validClaims(now)), ErrInvalidToken},
+		{"empty", hs, "", ErrInvalidToken},
+		{"two segments", hs, parts[0] + "." + parts[1], ErrInvalidToken},
+		{"four segments", hs, valid + ".extra", ErrInvalidToken},
+		{"header not base64", hs, "!!." + parts[1] + "." + parts[2], ErrInvalidToken},
+		{"header not JSON", hs, base64.RawURLEncoding.EncodeToString([]byte("alg")) + "." + parts[1] + "." + parts[2], ErrInvalidToken},
+		{"signature not base64", hs, parts[0] + "." + parts[1] + ".!!", ErrInvalidToken},
+		{"payload not JSON", hs, signToken(t, hs, hsHeader, "claims"), ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.verifier.Verify(tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("Verify error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyClockSkew(t *testing.T) {
+	now := time.Now()
+	hs := NewTokenIssuer(TokenConfig{Algorithm: AlgHS256, Secret: hmacSecret, Issuer: "library", Audience: "api"})
+	header := map[string]string{"alg": AlgHS256}
+
+	tests := []struct {
+		name   string
+		change func(*Claims)
+	}{
+		{"expired within skew", func(c *Claims) { c.ExpiresAt = now.Add(-clockSkew / 2).Unix() }},
+		{"nbf within skew", func(c *Claims) { c.NotBefore = now.Add(clockSkew / 2).Unix() }},
+		{"no nbf", func(c *Claims) { c.NotBefore = 0 }},
+		{"audience list", func(c *Claims) { c.Audience = audience{"other", "api"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims(now)
+			tt.change(&claims)
+			if _, err := hs.Verify(signToken(t, hs, header, claims)); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestRS256VerifyWithoutPrivateKey(t *testing.T) {
+	key := testRSAKey(t)
+	token, err := NewTokenIssuer(TokenConfig{Algorithm: AlgRS256, PrivateKey: key, TTL: time.Hour}).Issue(1, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewTokenIssuer(TokenConfig{Algorithm: AlgRS256, PublicKey: &key.PublicKey})
+	if _, err := verifier.Verify(token); err != nil {
+		t.Errorf("Verify with public key only: %v", err)
+	}
+	if _, err := verifier.Issue(1, "alice"); err == nil {
+		t.Error("Issue without private key succeeded")
+	}
+}
+
+func TestTokenConfigFromEnv(t *testing.T) {
+	key := testRSAKey(t)
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600)
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"defaults", map[string]string{"JWT_SECRET": string(hmacSecret)}, false},
+		{"ttl", map[string]string{"JWT_SECRET": string(hmacSecret), "JWT_TTL": "15m"}, false},
+		{"zero ttl", map[string]string{"JWT_SECRET": string(hmacSecret), "JWT_TTL": "0s"}, true},
+		{"negative ttl", map[string]string{"JWT_SECRET": string(hmacSecret), "JWT_TTL": "-1h"}, true},
+		{"invalid ttl", map[string]string{"JWT_SECRET": string(hmacSecret), "JWT_TTL": "hour"}, true},
+		{"short secret", map[string]string{"JWT_SECRET": "short"}, true},
+		{"placeholder secret", map[string]string{"JWT_SECRET": "change-me"}, true},
+		{"rs256 private key", map[string]string{"JWT_ALGORITHM": AlgRS256, "JWT_PRIVATE_KEY_FILE": privatePath}, false},
+		{"rs256 without keys", map[string]string{"JWT_ALGORITHM": AlgRS256}, true},
+		{"unsupported algorithm", map[string]string{"JWT_ALGORITHM": "none"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"JWT_ALGORITHM", "JWT_SECRET", "JWT_PRIVATE_KEY_FILE", "JWT_PUBLIC_KEY_FILE", "JWT_ISSUER", "JWT_AUDIENCE", "JWT_TTL"} {
+				t.Setenv(name, tt.env[name])
+			}
+			cfg, err := TokenConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TokenConfigFromEnv error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.TTL <= 0 {
+				t.Errorf("TTL = %v", cfg.TTL)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	apperrors "api_library/internal/errors"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// Authenticator проверяет API-ключ и пользователя из JWT; для неизвестного ключа
+// или отключённого пользователя возвращает errors.ErrUnauthorized
+type Authenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (Identity, error)
+	AuthenticateUser(ctx context.Context, userID int) (Identity, error)
+}
+
+type Middleware struct {
+	tokens      *TokenIssuer
+	users       Authenticator
+	publicPaths map[string]bool
+}
+
+// publicPaths - пути, доступные без аутентификации (например, /auth/token)
+func NewMiddleware(tokens *TokenIssuer, users Authenticator, publicPaths ...string) *Middleware {
+	m := &Middleware{tokens: tokens, users: users, publicPaths: make(map[string]bool)}
+	for _, path := range publicPaths {
+		m.publicPaths[path] = true
+	}
+	return m
+}
+
+// Wrap принимает JWT в заголовке "Authorization: Bearer <token>" или API-ключ в X-API-Key
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, err := m.authenticate(r)
+		if err != nil {
+			httpErr := apperrors.MapErrorToHTTP(err)
+			if httpErr.Code == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api_library"`)
+			}
+			sendHTTPError(w, httpErr)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), identity)))
+	})
+}
+
+func (m *Middleware) authenticate(r *http.Request) (Identity, error) {
+	return m.Authenticate(r.Context(), r.Header.Get(APIKeyHeader), r.Header.Get("Authorization"))
+}
+
+// Authenticate проверяет API-ключ или значение заголовка Authorization; используется и вне HTTP (gRPC)
+func (m *Middleware) Authenticate(ctx context.Context, apiKey, authorization string) (Identity, error) {
+	if apiKey != "" {
+		return m.users.AuthenticateAPIKey(ctx, apiKey)
+	}
+
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Identity{}, apperrors.NewHTTPError(http.StatusUnauthorized, "missing credentials", "auth")
+	}
+
+	claims, err := m.tokens.Verify(strings.TrimSpace(token))
+	if err != nil {
+		return Identity{}, apperrors.NewHTTPError(http.StatusUnauthorized, err.Error(), "auth")
+	}
+	// токен остаётся действительным до истечения срока, поэтому отключение пользователя проверяется при каждом запросе
+	identity, err := m.users.AuthenticateUser(ctx, claims.UserID)
+	if err != nil {
+		return Identity{}, err
+	}
+	identity.Method = MethodJWT
+	return identity, nil
+}
+
+func sendHTTPError(w http.ResponseWriter, httpErr *apperrors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
@@ -0,0 +1,88 @@
+package auth_test
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/repository"
+	"api_library/internal/usecase"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// downConnector - база, к которой нельзя подключиться
+type downConnector struct{}
+
+func (downConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+}
+
+func (downConnector) Driver() driver.Driver { return nil }
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// TestDatabaseOutage проходит весь путь запроса - аутентификация, загрузка прав, обработчик - при
+// недоступной базе: клиент должен получить 503, а после размыкания предохранителя - 503 без обращения к базе
+func TestDatabaseOutage(t *testing.T) {
+	db := sql.OpenDB(downConnector{})
+	defer db.Close()
+	resilience := repository.NewResilience(repository.ResilienceConfig{RetryAttempts: 1, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+	authRepo := repository.NewResilientAuthRepository(repository.NewAuthRepository(db), resilience)
+	rbacRepo := repository.NewResilientRBACRepository(repository.NewRBACRepository(db), resilience)
+
+	tokens := auth.NewTokenIssuer(auth.TokenConfig{Algorithm: auth.AlgHS256, Secret: []byte(testSecret), TTL: time.Hour})
+	middleware := auth.NewMiddleware(tokens, usecase.NewAuthService(authRepo, tokens), "/auth/token")
+	authorizer := auth.NewAuthorizer(usecase.NewRBACService(rbacRepo, authRepo))
+
+	mux := http.NewServeMux()
+	books := authorizer.Require(auth.Permissions{http.MethodGet: auth.PermBooksRead}, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler reached while the database is down")
+	})
+	mux.HandleFunc("/books", books)
+	server := middleware.Wrap(mux)
+
+	token, err := tokens.Issue(1, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		handler http.Handler
+		header  map[string]string
+		ctx     func(context.Context) context.Context
+	}{
+		{"API key", server, map[string]string{auth.APIKeyHeader: "lib_test"}, nil},
+		{"JWT", server, map[string]string{"Authorization": "Bearer " + token}, nil},
+		// аутентификация уже пройдена, права загружаются из недоступной базы
+		{"permissions", books, nil, func(ctx context.Context) context.Context {
+			return auth.WithIdentity(ctx, auth.Identity{UserID: 1, Method: auth.MethodJWT})
+		}},
+		{"breaker open", server, map[string]string{auth.APIKeyHeader: "lib_test"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/books", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			if tt.ctx != nil {
+				r = r.WithContext(tt.ctx(r.Context()))
+			}
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("status = %d, want 503: %s", w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), "database is unavailable") {
+				t.Errorf("body = %s", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	apperrors "api_library/internal/errors"
+	"context"
+	"net/http"
+	"strings"
+)
+
+const (
+	RoleAdmin     = "admin"
+	RoleLibrarian = "librarian"
+	RolePatron    = "patron"
+	RoleReadOnly  = "read-only"
+)
+
+const (
+	PermAuthorsRead   = "authors:read"
+	PermAuthorsCreate = "authors:create"
+	PermAuthorsUpdate = "authors:update"
+	PermAuthorsDelete = "authors:delete"
+	PermBooksRead     = "books:read"
+	PermBooksCreate   = "books:create"
+	PermBooksUpdate   = "books:update"
+	PermBooksDelete   = "books:delete"
+	PermJobsRead      = "jobs:read"
+	PermJobsRun       = "jobs:run"
+	PermRolesRead     = "roles:read"
+	PermRolesManage   = "roles:manage"
+	PermUsersManage   = "users:manage"
+	PermAPIKeysManage = "api_keys:manage"
+	PermAuditRead     = "audit:read"
+	PermTrashRead     = "trash:read"
+)
+
+var KnownPermissions = []string{
+	PermAuthorsRead, PermAuthorsCreate, PermAuthorsUpdate, PermAuthorsDelete,
+	PermBooksRead, PermBooksCreate, PermBooksUpdate, PermBooksDelete,
+	PermJobsRead, PermJobsRun,
+	PermRolesRead, PermRolesManage,
+	PermUsersManage,
+	PermAPIKeysManage,
+	PermAuditRead,
+	PermTrashRead,
+}
+
+// ValidPermission допускает известные права, "*" и шаблоны вида "books:*"
+func ValidPermission(permission string) bool {
+	if permission == "*" {
+		return true
+	}
+	for _, known := range KnownPermissions {
+		if permission == known {
+			return true
+		}
+		if resource, _, _ := strings.Cut(known, ":"); permission == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission проверяет, покрывает ли набор прав требуемое право с учётом шаблонов
+func HasPermission(granted []string, required string) bool {
+	resource, _, _ := strings.Cut(required, ":")
+	for _, p := range granted {
+		if p == "*" || p == required || p == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (i Identity) Can(permission string) bool {
+	return HasPermission(i.Permissions, permission)
+}
+
+// AccessResolver загружает роли и права пользователя
+type AccessResolver interface {
+	ResolveAccess(ctx context.Context, userID int) (roles []string, permissions []string, err error)
+}
+
+// Permissions - требуемое право для каждого HTTP-метода маршрута
+type Permissions map[string]string
+
+type Authorizer struct {
+	resolver AccessResolver
+}
+
+func NewAuthorizer(resolver AccessResolver) *Authorizer {
+	return &Authorizer{resolver: resolver}
+}
+
+// Require пропускает запрос к next, только если у вызывающего есть право для метода запроса.
+// Методы, для которых право не указано, запрещены.
+func (a *Authorizer) Require(permissions Permissions, next http.HandlerFunc) http.HandlerFunc {
+	return a.RequireFunc(func(r *http.Request) string {
+		return permissions[r.Method]
+	}, next)
+}
+
+// RequireFunc - как Require, но право выбирается по всему запросу, например по подпути.
+// Пустая строка означает, что запрос не поддерживается.
+func (a *Authorizer) RequireFunc(permission func(r *http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return a.authorize(func(r *http.Request, identity Identity) *apperrors.HTTPError {
+		required := permission(r)
+		if required == "" {
+			return apperrors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "Authorizer")
+		}
+		if !identity.Can(required) {
+			return apperrors.NewHTTPError(http.StatusForbidden, "permission denied", required)
+		}
+		return nil
+	}, next)
+}
+
+// Resolve только загружает роли и права вызывающего в Identity; для обработчиков,
+// которые проверяют права сами, например по каждой операции пакета
+func (a *Authorizer) Resolve(next http.HandlerFunc) http.HandlerFunc {
+	return a.authorize(func(r *http.Request, identity Identity) *apperrors.HTTPError {
+		return nil
+	}, next)
+}
+
+func (a *Authorizer) authorize(check func(r *http.Request, identity Identity) *apperrors.HTTPError, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := FromContext(r.Context())
+		if !ok {
+			sendHTTPError(w, apperrors.MapErrorToHTTP(apperrors.ErrUnauthorized))
+			return
+		}
+
+		identity, err := a.Load(r.Context(), identity)
+		if err != nil {
+			sendHTTPError(w, apperrors.MapErrorToHTTP(err))
+			return
+		}
+		if httpErr := check(r, identity); httpErr != nil {
+			sendHTTPError(w, httpErr)
+			return
+		}
+		next(w, r.WithContext(WithIdentity(r.Context(), identity)))
+	}
+}
+
+// Load заполняет роли и права вызывающего
+func (a *Authorizer) Load(ctx context.Context, identity Identity) (Identity, error) {
+	roles, granted, err := a.resolver.ResolveAccess(ctx, identity.UserID)
+	if err != nil {
+		return identity, err
+	}
+	identity.Roles = roles
+	identity.Permissions = granted
+	return identity, nil
+}
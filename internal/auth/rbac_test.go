@@ -0,0 +1,63 @@
+package auth
+
+import "testing"
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"exact", []string{PermBooksRead}, PermBooksRead, true},
+		{"other action", []string{PermBooksRead}, PermBooksDelete, false},
+		{"other resource", []string{PermAuthorsDelete}, PermBooksDelete, false},
+		{"resource wildcard", []string{"books:*"}, PermBooksDelete, true},
+		{"wildcard of other resource", []string{"authors:*"}, PermBooksDelete, false},
+		{"global wildcard", []string{"*"}, PermRolesManage, true},
+		{"nothing granted", nil, PermBooksRead, false},
+		// шаблон не распространяется на ресурс с общим префиксом
+		{"prefix is not a resource", []string{"book:*"}, PermBooksRead, false},
+		{"wildcard action is not a grant", []string{"*:read"}, PermBooksRead, false},
+		{"one of several", []string{PermAuthorsRead, PermJobsRun}, PermJobsRun, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.granted, tt.required); got != tt.want {
+				t.Errorf("HasPermission(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentityCan(t *testing.T) {
+	identity := Identity{Permissions: []string{"books:*", PermAuditRead}}
+	if !identity.Can(PermBooksCreate) || !identity.Can(PermAuditRead) || identity.Can(PermAuthorsCreate) {
+		t.Errorf("Can returned wrong result for %v", identity.Permissions)
+	}
+}
+
+func TestValidPermission(t *testing.T) {
+	tests := []struct {
+		permission string
+		want       bool
+	}{
+		{"*", true},
+		{PermBooksRead, true},
+		{PermUsersManage, true},
+		{"books:*", true},
+		{"api_keys:*", true},
+		{"books:archive", false},
+		{"loans:read", false},
+		{"loans:*", false},
+		{"*:read", false},
+		{"books", false},
+		{"", false},
+		{"BOOKS:READ", false},
+	}
+	for _, tt := range tests {
+		if got := ValidPermission(tt.permission); got != tt.want {
+			t.Errorf("ValidPermission(%q) = %v, want %v", tt.permission, got, tt.want)
+		}
+	}
+}
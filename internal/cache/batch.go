@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/repository"
+	"context"
+)
+
+// cachedBatchRepository сбрасывает записи авторов и книг, которые меняет пакет: пакетные операции
+// пишут в базу мимо repository.Repository
+type cachedBatchRepository struct {
+	next  repository.BatchRepository
+	repo  repository.Repository
+	cache Cache
+}
+
+// repo нужен, чтобы найти книги удаляемых авторов
+func NewBatchRepository(next repository.BatchRepository, repo repository.Repository, cache Cache) repository.BatchRepository {
+	return &cachedBatchRepository{next: next, repo: repo, cache: cache}
+}
+
+func (c *cachedBatchRepository) ExecuteBatch(ctx context.Context, operations []entity.BatchOperation, atomic bool) ([]entity.BatchResult, error) {
+	var keys []string
+	var deletedAuthors []int
+	for _, op := range operations {
+		if op.Op == entity.BatchCreate || op.ID == 0 {
+			continue
+		}
+		switch op.Entity {
+		case entity.BatchEntityAuthor:
+			keys = append(keys, authorKey(op.ID))
+			if op.Op == entity.BatchDelete {
+				deletedAuthors = append(deletedAuthors, op.ID)
+			}
+		case entity.BatchEntityBook:
+			keys = append(keys, bookKey(op.ID))
+		}
+	}
+	if len(deletedAuthors) > 0 {
+		// книги удаляются вместе с автором
+		if books, err := c.repo.GetBooksByAuthors(repository.WithPrimary(ctx), deletedAuthors); err == nil {
+			for _, book := range books {
+				keys = append(keys, bookKey(book.ID))
+			}
+		}
+	}
+	if len(keys) > 0 {
+		defer invalidate(ctx, c.cache, keys...)
+	}
+	return c.next.ExecuteBatch(ctx, operations, atomic)
+}
@@ -0,0 +1,101 @@
+// Package cache - кэш чтений репозитория: LRU в памяти процесса или Redis, общий для всех экземпляров сервиса.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BackendNone   = "none"
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+
+	// префикс ключей в Redis, чтобы база Redis могла использоваться и другими сервисами
+	redisKeyPrefix = "api_library:"
+)
+
+// Cache хранит значения по ключу с ограниченным сроком жизни. Ошибки хранилища не должны ломать
+// чтение: вызывающий в этом случае идёт в базу.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+type Config struct {
+	// none - кэш выключен, memory - LRU в памяти процесса, redis - Redis
+	Backend string
+	// сколько запись живёт в кэше, если её не сбросило изменение
+	TTL time.Duration
+	// максимальное число записей в LRU
+	Size int
+	// адрес Redis, например redis://localhost:6379/0
+	RedisURL string
+	// max-age в Cache-Control ответов GET; 0 - клиент перепроверяет ответ при каждом запросе
+	MaxAge time.Duration
+}
+
+// ConfigFromEnv читает CACHE_BACKEND, CACHE_TTL, CACHE_SIZE, CACHE_REDIS_URL, CACHE_MAX_AGE
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Backend:  os.Getenv("CACHE_BACKEND"),
+		TTL:      5 * time.Minute,
+		Size:     10000,
+		RedisURL: os.Getenv("CACHE_REDIS_URL"),
+	}
+	if cfg.Backend == "" {
+		cfg.Backend = BackendMemory
+	}
+	switch cfg.Backend {
+	case BackendNone, BackendMemory:
+	case BackendRedis:
+		if cfg.RedisURL == "" {
+			return cfg, fmt.Errorf("CACHE_REDIS_URL is required for CACHE_BACKEND=redis")
+		}
+	default:
+		return cfg, fmt.Errorf("invalid CACHE_BACKEND %q: expected none, memory or redis", cfg.Backend)
+	}
+	if v := os.Getenv("CACHE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return cfg, fmt.Errorf("invalid CACHE_TTL %q: expected a duration such as 5m", v)
+		}
+		cfg.TTL = ttl
+	}
+	if v := os.Getenv("CACHE_SIZE"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			return cfg, fmt.Errorf("invalid CACHE_SIZE %q: expected a positive integer", v)
+		}
+		cfg.Size = size
+	}
+	if v := os.Getenv("CACHE_MAX_AGE"); v != "" {
+		maxAge, err := time.ParseDuration(v)
+		if err != nil || maxAge < 0 {
+			return cfg, fmt.Errorf("invalid CACHE_MAX_AGE %q: expected a duration such as 1m", v)
+		}
+		cfg.MaxAge = maxAge
+	}
+	return cfg, nil
+}
+
+// New создаёт кэш по конфигурации; для none возвращает nil
+func New(cfg Config) (Cache, error) {
+	switch cfg.Backend {
+	case BackendMemory:
+		return NewLRU(cfg.Size), nil
+	case BackendRedis:
+		options, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_REDIS_URL: %w", err)
+		}
+		return NewRedis(redis.NewClient(options), redisKeyPrefix), nil
+	}
+	return nil, nil
+}
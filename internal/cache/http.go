@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CacheControl добавляет Cache-Control к успешным ответам на GET. Ответы зависят от прав вызывающего,
+// поэтому они private: хранить их может клиент, но не общий прокси. При maxAge = 0 клиент
+// перепроверяет ответ каждый раз - по Last-Modified, если обработчик его указал.
+func CacheControl(maxAge time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	value := "private, no-cache"
+	if maxAge > 0 {
+		value = "private, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+		next(&cacheControlWriter{ResponseWriter: w, value: value}, r)
+	}
+}
+
+type cacheControlWriter struct {
+	http.ResponseWriter
+	value       string
+	wroteHeader bool
+}
+
+func (w *cacheControlWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if (status == http.StatusOK || status == http.StatusNotModified) && w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", w.value)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		method string
+		status int
+		want   string
+	}{
+		{"revalidate", 0, http.MethodGet, http.StatusOK, "private, no-cache"},
+		{"max-age", time.Minute, http.MethodGet, http.StatusOK, "private, max-age=60"},
+		{"not modified", time.Minute, http.MethodGet, http.StatusNotModified, "private, max-age=60"},
+		{"error", time.Minute, http.MethodGet, http.StatusNotFound, ""},
+		{"write", time.Minute, http.MethodPut, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CacheControl(tt.maxAge, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(tt.method, "/books/1", nil))
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyRead(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	// чтение делает a свежее b, поэтому при переполнении вытесняется b
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatal("a missing")
+	}
+	c.Set(ctx, "c", []byte("3"), time.Minute)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok, _ := c.Get(ctx, key); ok != want {
+			t.Errorf("Get(%s) present = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestLRUSetExistingKeyDoesNotEvict(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	c.Set(ctx, "a", []byte("updated"), time.Minute)
+
+	if value, ok, _ := c.Get(ctx, "a"); !ok || string(value) != "updated" {
+		t.Errorf("Get(a) = %q, %v", value, ok)
+	}
+	if _, ok, _ := c.Get(ctx, "b"); !ok {
+		t.Error("b evicted by overwrite of a")
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+	c.Set(ctx, "short", []byte("1"), time.Millisecond)
+	c.Set(ctx, "long", []byte("2"), time.Minute)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok, _ := c.Get(ctx, "short"); ok {
+		t.Error("expired entry returned")
+	}
+	if _, ok, _ := c.Get(ctx, "long"); !ok {
+		t.Error("live entry missing")
+	}
+	if len(c.entries) != 1 || c.order.Len() != 1 {
+		t.Errorf("expired entry not removed: %d entries", len(c.entries))
+	}
+}
+
+func TestLRUDelete(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	c.Delete(ctx, "a", "missing")
+
+	if _, ok, _ := c.Get(ctx, "a"); ok {
+		t.Error("a not deleted")
+	}
+	if _, ok, _ := c.Get(ctx, "b"); !ok {
+		t.Error("b deleted")
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedis(client, "test:"), server
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestRedis(t)
+
+	if _, ok, err := c.Get(ctx, "missing"); ok || err != nil {
+		t.Fatalf("Get(missing) = %v, %v", ok, err)
+	}
+	if err := c.Set(ctx, "book:1", []byte(`{"value":1}`), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if !server.Exists("test:book:1") {
+		t.Errorf("keys = %v, want prefixed key", server.Keys())
+	}
+	if value, ok, err := c.Get(ctx, "book:1"); !ok || err != nil || string(value) != `{"value":1}` {
+		t.Fatalf("Get = %q, %v, %v", value, ok, err)
+	}
+
+	if err := c.Delete(ctx, "book:1", "book:2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "book:1"); ok {
+		t.Error("deleted key returned")
+	}
+	if err := c.Delete(ctx); err != nil {
+		t.Errorf("Delete without keys: %v", err)
+	}
+}
+
+func TestRedisTTL(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestRedis(t)
+
+	c.Set(ctx, "author:1", []byte("1"), time.Minute)
+	if ttl := server.TTL("test:author:1"); ttl != time.Minute {
+		t.Errorf("TTL = %s, want 1m", ttl)
+	}
+	server.FastForward(time.Minute + time.Second)
+	if _, ok, _ := c.Get(ctx, "author:1"); ok {
+		t.Error("expired key returned")
+	}
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	c, server := newTestRedis(t)
+	server.Close()
+	if _, _, err := c.Get(context.Background(), "book:1"); err == nil {
+		t.Error("expected an error from a stopped server")
+	}
+}
+
+// изменение через один экземпляр сервиса сбрасывает запись для всех экземпляров с общим Redis
+func TestRedisSharedBetweenInstances(t *testing.T) {
+	ctx := context.Background()
+	shared, _ := newTestRedis(t)
+	db := newFakeRepository()
+	first := NewRepository(db, shared, time.Minute)
+	second := NewRepository(db, shared, time.Minute)
+
+	if _, err := second.GetBook(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.UpdateBook(ctx, 10, "Новое название", 1869, ""); err != nil {
+		t.Fatal(err)
+	}
+	book, err := second.GetBook(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Title != "Новое название" {
+		t.Errorf("second instance read stale title %q", book.Title)
+	}
+}
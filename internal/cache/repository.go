@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/repository"
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+)
+
+// cachedRepository кэширует GetAuthor и GetBook. Любое изменение сбрасывает записи затронутых авторов и книг;
+// удаление автора сбрасывает и его книги, потому что они удаляются вместе с ним. Остальные методы
+// передаются репозиторию без изменений.
+type cachedRepository struct {
+	next  repository.Repository
+	cache Cache
+	ttl   time.Duration
+}
+
+func NewRepository(next repository.Repository, cache Cache, ttl time.Duration) repository.Repository {
+	return &cachedRepository{next: next, cache: cache, ttl: ttl}
+}
+
+func authorKey(authorID int) string {
+	return "author:" + strconv.Itoa(authorID)
+}
+
+func bookKey(bookID int) string {
+	return "book:" + strconv.Itoa(bookID)
+}
+
+// entry - закэшированное значение; UpdatedAt хранится отдельно, потому что в JSON сущности его нет
+type entry[T any] struct {
+	Value     T         `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// cached возвращает значение из кэша или загружает его через load и сохраняет.
+// Ошибки загрузки (в том числе "не найдено") не кэшируются. load читает с основной базы: значение
+// с отстающей реплики, загруженное сразу после сброса, осталось бы в кэше до истечения TTL.
+func cached[T any](ctx context.Context, c *cachedRepository, key string, load func() (T, time.Time, error)) (T, time.Time, error) {
+	if data, ok, err := c.cache.Get(ctx, key); err != nil {
+		log.Printf("Ошибка чтения кэша %s: %v", key, err)
+	} else if ok {
+		var e entry[T]
+		if err := json.Unmarshal(data, &e); err == nil {
+			return e.Value, e.UpdatedAt, nil
+		}
+	}
+
+	value, updatedAt, err := load()
+	if err != nil {
+		return value, updatedAt, err
+	}
+	data, err := json.Marshal(entry[T]{Value: value, UpdatedAt: updatedAt})
+	if err == nil {
+		err = c.cache.Set(ctx, key, data, c.ttl)
+	}
+	if err != nil {
+		log.Printf("Ошибка записи в кэш %s: %v", key, err)
+	}
+	return value, updatedAt, nil
+}
+
+// invalidate сбрасывает записи после изменения, даже если оно завершилось ошибкой: часть изменений
+// могла примениться. Сбой кэша только логируется - запись устареет по TTL.
+func invalidate(ctx context.Context, cache Cache, keys ...string) {
+	// запрос клиента мог быть отменён, а сбросить кэш нужно всё равно
+	if err := cache.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+		log.Printf("Ошибка сброса кэша %v: %v", keys, err)
+	}
+}
+
+// authorBookKeys - ключи книг автора, которые удалятся вместе с ним
+func (c *cachedRepository) authorBookKeys(ctx context.Context, authorID int) []string {
+	books, err := c.next.GetBooksByAuthor(repository.WithPrimary(ctx), authorID)
+	if err != nil {
+		return nil
+	}
+	keys := make([]string, len(books))
+	for i, book := range books {
+		keys[i] = bookKey(book.ID)
+	}
+	return keys
+}
+
+func (c *cachedRepository) GetAllAuthors(ctx context.Context, filter entity.AuthorFilter) ([]entity.Author, error) {
+	return c.next.GetAllAuthors(ctx, filter)
+}
+
+func (c *cachedRepository) GetAuthor(ctx context.Context, authorID int) (entity.Author, error) {
+	author, updatedAt, err := cached(ctx, c, authorKey(authorID), func() (entity.Author, time.Time, error) {
+		author, err := c.next.GetAuthor(repository.WithPrimary(ctx), authorID)
+		return author, author.UpdatedAt, err
+	})
+	author.UpdatedAt = updatedAt
+	return author, err
+}
+
+func (c *cachedRepository) GetAuthorsByIDs(ctx context.Context, authorIDs []int) ([]entity.Author, error) {
+	return c.next.GetAuthorsByIDs(ctx, authorIDs)
+}
+
+func (c *cachedRepository) FindAuthorByName(ctx context.Context, firstName, lastName string) (entity.Author, error) {
+	return c.next.FindAuthorByName(ctx, firstName, lastName)
+}
+
+func (c *cachedRepository) CreateAuthor(ctx context.Context, firstName, lastName, biography string, birthDate time.Time) (int, error) {
+	return c.next.CreateAuthor(ctx, firstName, lastName, biography, birthDate)
+}
+
+func (c *cachedRepository) UpdateAuthor(ctx context.Context, authorID int, firstName, lastName, biography string, birthDate time.Time) error {
+	defer invalidate(ctx, c.cache, authorKey(authorID))
+	return c.next.UpdateAuthor(ctx, authorID, firstName, lastName, biography, birthDate)
+}
+
+func (c *cachedRepository) DeleteAuthor(ctx context.Context, authorID int) error {
+	keys := append(c.authorBookKeys(ctx, authorID), authorKey(authorID))
+	defer invalidate(ctx, c.cache, keys...)
+	return c.next.DeleteAuthor(ctx, authorID)
+}
+
+func (c *cachedRepository) GetAllBooks(ctx context.Context, filter entity.BookFilter) ([]entity.Book, error) {
+	return c.next.GetAllBooks(ctx, filter)
+}
+
+func (c *cachedRepository) GetBooksByAuthor(ctx context.Context, authorID int) ([]entity.Book, error) {
+	return c.next.GetBooksByAuthor(ctx, authorID)
+}
+
+func (c *cachedRepository) GetBooksByAuthors(ctx context.Context, authorIDs []int) ([]entity.Book, error) {
+	return c.next.GetBooksByAuthors(ctx, authorIDs)
+}
+
+func (c *cachedRepository) GetBook(ctx context.Context, bookID int) (entity.Book, error) {
+	book, updatedAt, err := cached(ctx, c, bookKey(bookID), func() (entity.Book, time.Time, error) {
+		book, err := c.next.GetBook(repository.WithPrimary(ctx), bookID)
+		return book, book.UpdatedAt, err
+	})
+	book.UpdatedAt = updatedAt
+	return book, err
+}
+
+func (c *cachedRepository) CreateBook(ctx context.Context, title string, year int, isbn string, authorID int) (int, error) {
+	return c.next.CreateBook(ctx, title, year, isbn, authorID)
+}
+
+func (c *cachedRepository) UpdateBook(ctx context.Context, bookID int, title string, year int, isbn string) error {
+	defer invalidate(ctx, c.cache, bookKey(bookID))
+	return c.next.UpdateBook(ctx, bookID, title, year, isbn)
+}
+
+func (c *cachedRepository) DeleteBook(ctx context.Context, bookID int) error {
+	defer invalidate(ctx, c.cache, bookKey(bookID))
+	return c.next.DeleteBook(ctx, bookID)
+}
+
+func (c *cachedRepository) UpdateBookAndAuthor(ctx context.Context, bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error {
+	defer invalidate(ctx, c.cache, bookKey(bookID), authorKey(authorID))
+	return c.next.UpdateBookAndAuthor(ctx, bookID, newTitle, newYear, newISBN, authorID, newFirstName, newLastName, newBiography, newBirthDate)
+}
+
+func (c *cachedRepository) RestoreAuthor(ctx context.Context, authorID int) error {
+	defer invalidate(ctx, c.cache, authorKey(authorID))
+	return c.next.RestoreAuthor(ctx, authorID)
+}
+
+func (c *cachedRepository) RestoreBook(ctx context.Context, bookID int) error {
+	defer invalidate(ctx, c.cache, bookKey(bookID))
+	return c.next.RestoreBook(ctx, bookID)
+}
+
+func (c *cachedRepository) GetTrash(ctx context.Context) (entity.Trash, error) {
+	return c.next.GetTrash(ctx)
+}
+
+func (c *cachedRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return c.next.PurgeDeleted(ctx, deletedBefore)
+}
+
+func (c *cachedRepository) GetAuthorVersions(ctx context.Context, authorID int) ([]entity.AuthorVersion, error) {
+	return c.next.GetAuthorVersions(ctx, authorID)
+}
+
+func (c *cachedRepository) GetAuthorAsOf(ctx context.Context, authorID int, asOf time.Time) (entity.Author, error) {
+	return c.next.GetAuthorAsOf(ctx, authorID, asOf)
+}
+
+func (c *cachedRepository) RevertAuthor(ctx context.Context, authorID, version int) error {
+	defer invalidate(ctx, c.cache, authorKey(authorID))
+	return c.next.RevertAuthor(ctx, authorID, version)
+}
+
+func (c *cachedRepository) GetBookVersions(ctx context.Context, bookID int) ([]entity.BookVersion, error) {
+	return c.next.GetBookVersions(ctx, bookID)
+}
+
+func (c *cachedRepository) GetBookAsOf(ctx context.Context, bookID int, asOf time.Time) (entity.Book, error) {
+	return c.next.GetBookAsOf(ctx, bookID, asOf)
+}
+
+func (c *cachedRepository) RevertBook(ctx context.Context, bookID, version int) error {
+	defer invalidate(ctx, c.cache, bookKey(bookID))
+	return c.next.RevertBook(ctx, bookID, version)
+}
+
+func (c *cachedRepository) ExportAuthors(ctx context.Context, filter entity.AuthorFilter, fn func(entity.Author) error) error {
+	return c.next.ExportAuthors(ctx, filter, fn)
+}
+
+func (c *cachedRepository) ExportBooks(ctx context.Context, filter entity.BookFilter, fn func(entity.BookRecord) error) error {
+	return c.next.ExportBooks(ctx, filter, fn)
+}
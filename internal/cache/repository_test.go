@@ -0,0 +1,277 @@
+package cache
+
+import (
+	"api_library/internal/entity"
+	apperrors "api_library/internal/errors"
+	"api_library/internal/repository"
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeRepository - каталог в памяти; методы, которые кэш не трогает, достаются от nil-интерфейса
+// и паникуют при вызове
+type fakeRepository struct {
+	repository.Repository
+
+	authors map[int]entity.Author
+	books   map[int]entity.Book
+	// сколько раз читались записи - по нему видно, попал ли запрос в кэш
+	loads int
+	// ошибка, которую возвращают изменения
+	err error
+}
+
+func newFakeRepository() *fakeRepository {
+	updatedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &fakeRepository{
+		authors: map[int]entity.Author{
+			1: {ID: 1, FirstName: "Лев", LastName: "Толстой", UpdatedAt: updatedAt},
+			2: {ID: 2, FirstName: "Станислав", LastName: "Лем", UpdatedAt: updatedAt},
+		},
+		books: map[int]entity.Book{
+			10: {ID: 10, Title: "Война и мир", AuthorID: 1, Year: 1869, UpdatedAt: updatedAt},
+			11: {ID: 11, Title: "Анна Каренина", AuthorID: 1, Year: 1878, UpdatedAt: updatedAt},
+			20: {ID: 20, Title: "Солярис", AuthorID: 2, Year: 1961, UpdatedAt: updatedAt},
+		},
+	}
+}
+
+func (r *fakeRepository) GetAuthor(ctx context.Context, authorID int) (entity.Author, error) {
+	r.loads++
+	author, ok := r.authors[authorID]
+	if !ok {
+		return author, apperrors.ErrNotFound
+	}
+	return author, nil
+}
+
+func (r *fakeRepository) GetBook(ctx context.Context, bookID int) (entity.Book, error) {
+	r.loads++
+	book, ok := r.books[bookID]
+	if !ok {
+		return book, apperrors.ErrNotFound
+	}
+	return book, nil
+}
+
+func (r *fakeRepository) GetBooksByAuthor(ctx context.Context, authorID int) ([]entity.Book, error) {
+	return r.GetBooksByAuthors(ctx, []int{authorID})
+}
+
+func (r *fakeRepository) GetBooksByAuthors(ctx context.Context, authorIDs []int) ([]entity.Book, error) {
+	var books []entity.Book
+	for _, book := range r.books {
+		for _, id := range authorIDs {
+			if book.AuthorID == id {
+				books = append(books, book)
+			}
+		}
+	}
+	return books, nil
+}
+
+func (r *fakeRepository) UpdateAuthor(ctx context.Context, authorID int, firstName, lastName, biography string, birthDate time.Time) error {
+	if r.err != nil {
+		return r.err
+	}
+	author := r.authors[authorID]
+	author.FirstName, author.LastName = firstName, lastName
+	r.authors[authorID] = author
+	return nil
+}
+
+func (r *fakeRepository) DeleteAuthor(ctx context.Context, authorID int) error {
+	return r.err
+}
+
+func (r *fakeRepository) UpdateBook(ctx context.Context, bookID int, title string, year int, isbn string) error {
+	if r.err != nil {
+		return r.err
+	}
+	book := r.books[bookID]
+	book.Title, book.Year, book.ISBN = title, year, isbn
+	r.books[bookID] = book
+	return nil
+}
+
+func (r *fakeRepository) DeleteBook(ctx context.Context, bookID int) error {
+	return r.err
+}
+
+func (r *fakeRepository) UpdateBookAndAuthor(ctx context.Context, bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error {
+	return r.err
+}
+
+func (r *fakeRepository) RestoreAuthor(ctx context.Context, authorID int) error {
+	return r.err
+}
+
+func (r *fakeRepository) RestoreBook(ctx context.Context, bookID int) error {
+	return r.err
+}
+
+func (r *fakeRepository) RevertAuthor(ctx context.Context, authorID, version int) error {
+	return r.err
+}
+
+func (r *fakeRepository) RevertBook(ctx context.Context, bookID, version int) error {
+	return r.err
+}
+
+// fakeBatchRepository пишет «в ту же базу», что и fakeRepository, и возвращает её ошибку
+type fakeBatchRepository struct {
+	db *fakeRepository
+}
+
+func (r fakeBatchRepository) ExecuteBatch(ctx context.Context, operations []entity.BatchOperation, atomic bool) ([]entity.BatchResult, error) {
+	return nil, r.db.err
+}
+
+func TestGetCachesValues(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeRepository()
+	repo := NewRepository(db, NewLRU(10), time.Minute)
+
+	for i := 0; i < 3; i++ {
+		book, err := repo.GetBook(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if book.Title != "Война и мир" || !book.UpdatedAt.Equal(db.books[10].UpdatedAt) {
+			t.Errorf("GetBook = %+v", book)
+		}
+		author, err := repo.GetAuthor(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// UpdatedAt не входит в JSON сущности, но нужен для Last-Modified
+		if author.LastName != "Толстой" || !author.UpdatedAt.Equal(db.authors[1].UpdatedAt) {
+			t.Errorf("GetAuthor = %+v", author)
+		}
+	}
+	if db.loads != 2 {
+		t.Errorf("loads = %d, want 2", db.loads)
+	}
+}
+
+func TestGetDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	db := newFakeRepository()
+	repo := NewRepository(db, NewLRU(10), time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := repo.GetBook(ctx, 99); !errors.Is(err, apperrors.ErrNotFound) {
+			t.Fatalf("error = %v, want not found", err)
+		}
+	}
+	if db.loads != 2 {
+		t.Errorf("loads = %d, want 2", db.loads)
+	}
+}
+
+func TestInvalidation(t *testing.T) {
+	birthDate := time.Date(1828, 9, 9, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		mutate      func(ctx context.Context, repo repository.Repository, batch repository.BatchRepository) error
+		invalidated []string
+	}{
+		{"UpdateAuthor", func(ctx context.Context, repo repository.Repository, _ repository.BatchRepository) error {
+			return repo.UpdateAuthor(ctx, 1, "Лев", "Толстой", "", birthDate)
+		}, []string{"author:1"}},
+		{"DeleteAuthor drops the author's books", func(ctx context.Context, repo repository.Repository, _ repository.BatchRepository) error {
+			return repo.DeleteAuthor(ctx, 1)
+		}, []string{"author:1", "book:10", "book:11"}},
+		{"RestoreAuthor", func(ctx context.Context, repo repository.Repository, _ repository.BatchRepository) error {
+			return repo.RestoreAuthor(ctx, 2)
+		}, []string{"author:2"}},
+		{"RevertAuthor", func(ctx context.Context, repo repository.Repository, _ repository.BatchRepository) error {
+			return repo.RevertAuthor(ctx, 1, 1)
+		}, []string{"author:1"}},
+		{"UpdateBook", func(ctx context.Context, repo repository.Repository, _ repository.BatchRepository) error {
+			return repo.UpdateBook(ctx, 10, "Война и мир", 1869, "")
+		}, []string{"book:10"}},
+		{"DeleteBook", func(ctx context.Context, repo repository.Repository, _ repository.BatchRepository) error {
+			return repo.DeleteBook(ctx, 20)
+		}, []string{"book:20"}},
+		{"RestoreBook", func(ctx context.Context, repo repository.Repository, _ repository.BatchRepository) error {
+			return repo.RestoreBook(ctx, 11)
+		}, []string{"book:11"}},
+		{"RevertBook", func(ctx context.Context, repo repository.Repository, _ repository.BatchRepository) error {
+			return repo.RevertBook(ctx, 10, 1)
+		}, []string{"book:10"}},
+		{"UpdateBookAndAuthor", func(ctx context.Context, repo repository.Repository, _ repository.BatchRepository) error {
+			return repo.UpdateBookAndAuthor(ctx, 20, "Солярис", 1961, "", 2, "Станислав", "Лем", "", birthDate)
+		}, []string{"author:2", "book:20"}},
+		{"batch", func(ctx context.Context, _ repository.Repository, batch repository.BatchRepository) error {
+			_, err := batch.ExecuteBatch(ctx, []entity.BatchOperation{
+				{Op: entity.BatchCreate, Entity: entity.BatchEntityAuthor},
+				{Op: entity.BatchUpdate, Entity: entity.BatchEntityBook, ID: 10},
+				{Op: entity.BatchDelete, Entity: entity.BatchEntityAuthor, ID: 2},
+			}, true)
+			return err
+		}, []string{"author:2", "book:10", "book:20"}},
+	}
+	for _, tt := range tests {
+		for _, failed := range []bool{false, true} {
+			name := tt.name
+			if failed {
+				// часть изменения могла примениться, поэтому кэш сбрасывается и при ошибке
+				name += " failed"
+			}
+			t.Run(name, func(t *testing.T) {
+				ctx := context.Background()
+				db := newFakeRepository()
+				lru := NewLRU(100)
+				repo := NewRepository(db, lru, time.Minute)
+				batch := NewBatchRepository(fakeBatchRepository{db: db}, db, lru)
+				for id := range db.authors {
+					repo.GetAuthor(ctx, id)
+				}
+				for id := range db.books {
+					repo.GetBook(ctx, id)
+				}
+				if failed {
+					db.err = errors.New("connection reset")
+				}
+
+				if err := tt.mutate(ctx, repo, batch); (err != nil) != failed {
+					t.Fatalf("mutate error = %v", err)
+				}
+
+				var invalidated []string
+				for _, key := range []string{"author:1", "author:2", "book:10", "book:11", "book:20"} {
+					if _, ok, _ := lru.Get(ctx, key); !ok {
+						invalidated = append(invalidated, key)
+					}
+				}
+				sort.Strings(invalidated)
+				if len(invalidated) != len(tt.invalidated) {
+					t.Fatalf("invalidated %v, want %v", invalidated, tt.invalidated)
+				}
+				for i := range invalidated {
+					if invalidated[i] != tt.invalidated[i] {
+						t.Fatalf("invalidated %v, want %v", invalidated, tt.invalidated)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestInvalidationIgnoresCanceledContext(t *testing.T) {
+	db := newFakeRepository()
+	lru := NewLRU(10)
+	repo := NewRepository(db, lru, time.Minute)
+	repo.GetBook(context.Background(), 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	repo.UpdateBook(ctx, 10, "Война и мир", 1869, "")
+	if _, ok, _ := lru.Get(context.Background(), "book:10"); ok {
+		t.Error("book:10 still cached after update with canceled context")
+	}
+}
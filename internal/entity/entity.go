@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -25,8 +27,36 @@ func (d *Date) UnmarshalJSON(b []byte) error {
 }
 
 // переопределяет поведение по умолчанию для сериализации
-func (d Date) MarshalJSON() (string, error) {
-	return string(d.Time.Format("2006-01-02")), nil
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.Time.Format("2006-01-02") + `"`), nil
+}
+
+// позволяет читать DATE из БД напрямую в Date
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		d.Time = time.Time{}
+	case time.Time:
+		d.Time = v
+	case []byte:
+		return d.Scan(string(v))
+	case string:
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return err
+		}
+		d.Time = t
+	default:
+		return fmt.Errorf("cannot scan %T into Date", value)
+	}
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.Time.IsZero() {
+		return nil, nil
+	}
+	return d.Time.Format("2006-01-02"), nil
 }
 
 type Author struct {
@@ -35,6 +65,10 @@ type Author struct {
 	LastName  string `json:"last_name"`
 	Biography string `json:"biography"`
 	BirthDate Date   `json:"birth_date"`
+	// заполняется только для удалённых записей (корзина)
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// время последнего изменения для Last-Modified; заполняется GetAuthor и в ответ не входит
+	UpdatedAt time.Time `json:"-"`
 }
 
 type Book struct {
@@ -43,9 +77,250 @@ type Book struct {
 	AuthorID int    `json:"author_id"`
 	Year     int    `json:"year"`
 	ISBN     string `json:"isbn"`
+	// заполняется только для удалённых записей (корзина)
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// время последнего изменения для Last-Modified; заполняется GetBook и в ответ не входит
+	UpdatedAt time.Time `json:"-"`
+}
+
+type AuthorVersion struct {
+	Version   int        `json:"version"`
+	ValidFrom time.Time  `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to,omitempty"`
+	Author    Author     `json:"author"`
+}
+
+type BookVersion struct {
+	Version   int        `json:"version"`
+	ValidFrom time.Time  `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to,omitempty"`
+	Book      Book       `json:"book"`
+}
+
+type Trash struct {
+	Authors []Author `json:"authors"`
+	Books   []Book   `json:"books"`
 }
 
 type BookAuthorPayload struct {
 	Book   Book   `json:"book"`
 	Author Author `json:"author"`
 }
+
+type JobRun struct {
+	ID          int        `json:"id"`
+	JobName     string     `json:"job_name"`
+	Trigger     string     `json:"trigger"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+type Notification struct {
+	ID            int        `json:"id"`
+	Kind          string     `json:"kind"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+type User struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Disabled     bool      `json:"disabled"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	UserID     int        `json:"user_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UserRoles struct {
+	UserID int      `json:"user_id"`
+	Roles  []string `json:"roles"`
+}
+
+type AuditEntry struct {
+	ID        int             `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	Changes   json.RawMessage `json:"changes,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditFilter struct {
+	Entity   string
+	EntityID int
+	Actor    string
+	Limit    int
+}
+
+const (
+	ImportAuthors = "authors"
+	ImportBooks   = "books"
+)
+
+// ImportOptions - параметры импорта; Mapping сопоставляет поле сущности с колонкой CSV
+type ImportOptions struct {
+	Entity    string
+	DryRun    bool
+	Mapping   map[string]string
+	Delimiter rune
+}
+
+type ImportRowError struct {
+	Line    int      `json:"line"`
+	Column  string   `json:"column,omitempty"`
+	Message string   `json:"message"`
+	Record  []string `json:"record"`
+}
+
+type ImportResult struct {
+	Entity   string `json:"entity"`
+	DryRun   bool   `json:"dry_run"`
+	Total    int    `json:"total"`
+	Imported int    `json:"imported"`
+	Rejected int    `json:"rejected"`
+	// авторы, созданные при импорте MARC (остальные найдены по имени)
+	AuthorsCreated int `json:"authors_created,omitempty"`
+	// первые отклонённые строки; остальные только считаются в ErrorsOmitted
+	Errors        []ImportRowError `json:"errors"`
+	ErrorsOmitted int              `json:"errors_omitted,omitempty"`
+	// заголовок исходного CSV, нужен для отчёта об ошибках
+	Header []string `json:"-"`
+}
+
+// AuthorFilter и BookFilter - фильтры списков и выгрузки; пустые поля не ограничивают выборку
+type AuthorFilter struct {
+	// подстрока имени или фамилии без учёта регистра
+	Name string
+}
+
+type BookFilter struct {
+	AuthorID int
+	Year     int
+	// подстрока названия без учёта регистра
+	Title string
+	ISBN  string
+}
+
+// BookRecord - книга вместе с автором для выгрузки каталога
+type BookRecord struct {
+	Book
+	Author Author `json:"author"`
+}
+
+// OAIRecord - книга для OAI-PMH; Datestamp - время последнего изменения книги, её автора или удаления
+type OAIRecord struct {
+	BookRecord
+	Datestamp time.Time
+	Deleted   bool
+}
+
+// OAIFilter - выборка записей OAI-PMH. Записи упорядочены по (Datestamp, ID);
+// AfterDatestamp/AfterID - последняя запись предыдущей страницы.
+type OAIFilter struct {
+	From           *time.Time
+	Until          *time.Time
+	AfterDatestamp *time.Time
+	AfterID        int
+	Limit          int
+}
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+
+	BatchEntityAuthor = "author"
+	BatchEntityBook   = "book"
+)
+
+// BatchRequest - пакет операций; Atomic - все операции в одной транзакции, иначе каждая сама по себе
+type BatchRequest struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+type BatchOperation struct {
+	Op     string          `json:"op"`
+	Entity string          `json:"entity"`
+	ID     int             `json:"id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	// разобранные данные операции и её позиция в пакете
+	Author *Author `json:"-"`
+	Book   *Book   `json:"-"`
+	Index  int     `json:"-"`
+}
+
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Status int    `json:"status"`
+	ID     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Atomic    bool          `json:"atomic"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// IdempotencyRecord - сохранённый результат запроса с Idempotency-Key; Status == 0 - запрос ещё выполняется
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Status      int
+	Headers     map[string][]string
+	Body        []byte
+	ExpiresAt   time.Time
+	// пока запрос выполняется, ключ занят до этого момента; после него ключ может занять повтор
+	LockedUntil time.Time
+}
+
+// CatalogStats - число записей каталога без учёта корзины
+type CatalogStats struct {
+	Authors int
+	Books   int
+}
@@ -9,12 +9,15 @@ var (
 	ErrNotFound     = errors.New("not found")
 	ErrInvalidInput = errors.New("invalid input")
 	ErrDB           = errors.New("database error")
+	ErrUnauthorized = errors.New("unauthorized")
 )
 
 type HTTPError struct {
 	Code    int
 	Message string
 	Source  string
+	// исходная ошибка, по которой можно понять, временный ли сбой
+	cause error
 }
 
 func NewHTTPError(code int, message string, source string) *HTTPError {
@@ -25,15 +28,51 @@ func (e *HTTPError) Error() string {
 	return e.Message + " " + e.Source
 }
 
+func (e *HTTPError) Unwrap() error {
+	return e.cause
+}
+
+// dbError - ErrDB с сохранённой ошибкой драйвера
+type dbError struct {
+	cause error
+}
+
+// WrapDB возвращает ошибку, равную ErrDB для errors.Is, но сохраняющую исходную ошибку драйвера
+func WrapDB(err error) error {
+	return &dbError{cause: err}
+}
+
+func (e *dbError) Error() string {
+	return ErrDB.Error()
+}
+
+func (e *dbError) Is(target error) bool {
+	return target == ErrDB
+}
+
+func (e *dbError) Unwrap() error {
+	return e.cause
+}
+
+// WrapUnavailable - ответ 503 для недоступной базы; исходная ошибка сохраняется для errors.Is и логов
+func WrapUnavailable(err error) *HTTPError {
+	return &HTTPError{Code: http.StatusServiceUnavailable, Message: "database is unavailable", cause: err}
+}
+
 func MapErrorToHTTP(err error) *HTTPError {
+	if httpErr, ok := err.(*HTTPError); ok {
+		return httpErr
+	}
 	switch err {
 	case ErrNotFound:
 		return NewHTTPError(http.StatusNotFound, err.Error(), "")
 	case ErrInvalidInput:
 		return NewHTTPError(http.StatusBadRequest, err.Error(), "")
+	case ErrUnauthorized:
+		return NewHTTPError(http.StatusUnauthorized, err.Error(), "")
 	case ErrDB:
 		return NewHTTPError(http.StatusInternalServerError, err.Error(), "")
 	default:
-		return NewHTTPError(http.StatusInternalServerError, err.Error(), "")
+		return &HTTPError{Code: http.StatusInternalServerError, Message: err.Error(), cause: err}
 	}
 }
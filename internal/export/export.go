@@ -0,0 +1,166 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format - формат выгрузки
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+}
+
+var (
+	CSV    = Format{Name: "csv", ContentType: "text/csv; charset=utf-8", Extension: "csv"}
+	NDJSON = Format{Name: "ndjson", ContentType: "application/x-ndjson", Extension: "ndjson"}
+	XLSX   = Format{Name: "xlsx", ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Extension: "xlsx"}
+)
+
+// mediaTypes сопоставляет типы из заголовка Accept с форматами; первым идёт формат по умолчанию
+var mediaTypes = []struct {
+	mediaType string
+	format    Format
+}{
+	{"text/csv", CSV},
+	{"application/x-ndjson", NDJSON},
+	{"application/jsonl", NDJSON},
+	{"application/json-lines", NDJSON},
+	{XLSX.ContentType, XLSX},
+}
+
+// ByName возвращает формат по имени из параметра ?format=
+func ByName(name string) (Format, bool) {
+	for _, f := range []Format{CSV, NDJSON, XLSX} {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Negotiate выбирает формат по заголовку Accept с учётом q-значений.
+// Пустой Accept и */* дают CSV; false - ни один из форматов не подходит.
+func Negotiate(accept string) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return CSV, true
+	}
+
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{mediaType, q})
+		}
+	}
+	// при равных q порядок из заголовка сохраняется
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if c.mediaType == "*/*" || c.mediaType == "text/*" {
+			return CSV, true
+		}
+		for _, m := range mediaTypes {
+			if c.mediaType == m.mediaType {
+				return m.format, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// Row - одна запись выгрузки: Object сериализуется в NDJSON, Cells - ячейки строки для CSV и XLSX
+type Row struct {
+	Object interface{}
+	Cells  []interface{}
+}
+
+// Encoder пишет записи в выбранном формате по мере поступления
+type Encoder interface {
+	Encode(row Row) error
+	// Close дописывает служебные части формата; без него файл может быть неполным
+	Close() error
+}
+
+// NewEncoder создаёт кодировщик; columns - заголовки колонок для табличных форматов
+func NewEncoder(format Format, w io.Writer, columns []string) (Encoder, error) {
+	switch format.Name {
+	case CSV.Name:
+		return newCSVEncoder(w, columns)
+	case NDJSON.Name:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	case XLSX.Name:
+		return newXLSXEncoder(w, columns)
+	}
+	return nil, fmt.Errorf("unknown export format %q", format.Name)
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer, columns []string) (*csvEncoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w)}
+	if err := e.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *csvEncoder) Encode(row Row) error {
+	record := make([]string, len(row.Cells))
+	for i, cell := range row.Cells {
+		if s, ok := cell.(string); ok {
+			record[i] = EscapeFormula(s)
+			continue
+		}
+		record[i] = fmt.Sprint(cell)
+	}
+	return e.w.Write(record)
+}
+
+// EscapeFormula не даёт табличным редакторам выполнить значение как формулу:
+// строки, начинающиеся с =, +, -, @, табуляции или перевода строки, получают префикс '
+func EscapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r\n", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(row Row) error {
+	return e.enc.Encode(row.Object)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestCSVEncoderEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		cell interface{}
+		want string
+	}{
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\r=1+1", "'\r=1+1"},
+		{"\n=1+1", "'\n=1+1"},
+		{"Война и мир", "Война и мир"},
+		{"", ""},
+		{-5, "-5"},
+		{1869, "1869"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		enc, err := NewEncoder(CSV, &buf, []string{"value", "id"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Encode(Row{Cells: []interface{}{tt.cell, 1}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := records[1][0]; got != tt.want {
+			t.Errorf("Encode(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
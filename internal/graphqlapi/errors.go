@@ -0,0 +1,22 @@
+package graphqlapi
+
+import (
+	"api_library/internal/errors"
+)
+
+// fieldError - ошибка резолвера; HTTP-код ошибки сервиса передаётся клиенту в extensions.status
+type fieldError struct {
+	httpErr *errors.HTTPError
+}
+
+func resolverError(err error) error {
+	return &fieldError{httpErr: errors.MapErrorToHTTP(err)}
+}
+
+func (e *fieldError) Error() string {
+	return e.httpErr.Message
+}
+
+func (e *fieldError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"status": e.httpErr.Code}
+}
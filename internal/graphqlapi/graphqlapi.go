@@ -0,0 +1,87 @@
+// Package graphqlapi - GraphQL-схема каталога (авторы, книги и связи между ними)
+// поверх того же usecase.Service, что и REST API.
+package graphqlapi
+
+import (
+	"api_library/internal/errors"
+	"api_library/internal/usecase"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request - тело запроса GraphQL
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type Executor struct {
+	schema  graphql.Schema
+	service usecase.Service
+	limits  Limits
+}
+
+func NewExecutor(service usecase.Service, limits Limits) (*Executor, error) {
+	schema, err := newSchema(service)
+	if err != nil {
+		return nil, err
+	}
+	return &Executor{schema: schema, service: service, limits: limits}, nil
+}
+
+// Execute проверяет глубину и сложность запроса и выполняет его. Ошибка возвращается, только если
+// запрос отклонён целиком; ошибки отдельных полей находятся в Result.Errors.
+// allowMutations == false запрещает мутации (запросы GET).
+func (e *Executor) Execute(ctx context.Context, request Request, allowMutations bool) (*graphql.Result, error) {
+	if request.Query == "" {
+		return nil, errors.NewHTTPError(http.StatusBadRequest, "query is required", "GraphQL")
+	}
+
+	// синтаксические ошибки сообщит graphql.Do вместе с позицией в запросе
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(request.Query)})})
+	if err == nil {
+		if err := e.check(document, request.OperationName, allowMutations); err != nil {
+			return nil, err
+		}
+	}
+
+	return graphql.Do(graphql.Params{
+		Schema:         e.schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        withLoaders(ctx, newLoaders(e.service)),
+	}), nil
+}
+
+// check проверяет операции, которые могут быть выполнены: выбранную по имени или все
+func (e *Executor) check(document *ast.Document, operationName string, allowMutations bool) error {
+	cost := newQueryCost(e.schema, document)
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName != "" && (operation.Name == nil || operation.Name.Value != operationName) {
+			continue
+		}
+		if operation.Operation == ast.OperationTypeMutation && !allowMutations {
+			return errors.NewHTTPError(http.StatusMethodNotAllowed, "mutations require POST", "GraphQL")
+		}
+		depth, complexity := cost.operation(operation)
+		if e.limits.MaxDepth > 0 && depth > e.limits.MaxDepth {
+			return errors.NewHTTPError(http.StatusBadRequest, "query depth "+strconv.Itoa(depth)+" exceeds limit "+strconv.Itoa(e.limits.MaxDepth), "GraphQL")
+		}
+		if e.limits.MaxComplexity > 0 && complexity > e.limits.MaxComplexity {
+			return errors.NewHTTPError(http.StatusBadRequest, "query complexity "+strconv.Itoa(complexity)+" exceeds limit "+strconv.Itoa(e.limits.MaxComplexity), "GraphQL")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package graphqlapi
+
+import (
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// assumedListSize - сколько элементов предполагается в списке при оценке сложности:
+// у списков нет пагинации, поэтому вложенные списки умножают стоимость
+const assumedListSize = 10
+
+// Limits ограничивает запросы до выполнения; 0 - без ограничения
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// queryCost - глубина и сложность операции: каждое поле стоит 1, поля внутри списка
+// считаются assumedListSize раз. Служебные поля интроспекции (__schema, __typename) не учитываются.
+type queryCost struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	// фрагменты на текущем пути обхода, защищает от циклов до валидации запроса
+	visiting map[string]bool
+}
+
+func newQueryCost(schema graphql.Schema, document *ast.Document) *queryCost {
+	c := &queryCost{schema: schema, fragments: map[string]*ast.FragmentDefinition{}, visiting: map[string]bool{}}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			c.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	return c
+}
+
+func (c *queryCost) operation(operation *ast.OperationDefinition) (depth, complexity int) {
+	root := c.schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = c.schema.MutationType()
+	}
+	return c.selectionSet(operation.SelectionSet, root)
+}
+
+func (c *queryCost) selectionSet(set *ast.SelectionSet, parent *graphql.Object) (depth, complexity int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		var d, n int
+		switch s := selection.(type) {
+		case *ast.Field:
+			d, n = c.field(s, parent)
+		case *ast.InlineFragment:
+			d, n = c.selectionSet(s.SelectionSet, c.typeCondition(s.TypeCondition, parent))
+		case *ast.FragmentSpread:
+			fragment, ok := c.fragments[s.Name.Value]
+			if !ok || c.visiting[s.Name.Value] {
+				continue
+			}
+			c.visiting[s.Name.Value] = true
+			d, n = c.selectionSet(fragment.SelectionSet, c.typeCondition(fragment.TypeCondition, parent))
+			delete(c.visiting, s.Name.Value)
+		}
+		depth = max(depth, d)
+		complexity += n
+	}
+	return depth, complexity
+}
+
+func (c *queryCost) field(field *ast.Field, parent *graphql.Object) (depth, complexity int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+	definition, ok := parent.Fields()[field.Name.Value]
+	if !ok {
+		// неизвестное поле отклонит валидация
+		return 1, 1
+	}
+
+	fieldType := definition.Type
+	list := false
+	for {
+		if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+			fieldType = nonNull.OfType
+			continue
+		}
+		if l, ok := fieldType.(*graphql.List); ok {
+			list = true
+			fieldType = l.OfType
+			continue
+		}
+		break
+	}
+	object, _ := fieldType.(*graphql.Object)
+	childDepth, childComplexity := c.selectionSet(field.SelectionSet, object)
+	if list {
+		childComplexity *= assumedListSize
+	}
+	return 1 + childDepth, 1 + childComplexity
+}
+
+func (c *queryCost) typeCondition(condition *ast.Named, parent *graphql.Object) *graphql.Object {
+	if condition == nil || condition.Name == nil {
+		return parent
+	}
+	object, _ := c.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
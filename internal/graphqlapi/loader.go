@@ -0,0 +1,131 @@
+package graphqlapi
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/usecase"
+	"context"
+	"sync"
+)
+
+type loadersKey struct{}
+
+// loader собирает ключи, которые понадобятся резолверам, и загружает их одним запросом
+// при первом обращении к любому из них. Живёт в пределах одного запроса.
+type loader[V any] struct {
+	fetch func(ctx context.Context, keys []int) (map[int]V, error)
+	// вызывается после каждой загрузки, чтобы подготовить связанные загрузчики
+	loaded func(values map[int]V)
+
+	mu      sync.Mutex
+	pending []int
+	values  map[int]V
+	done    map[int]bool
+}
+
+func newLoader[V any](fetch func(ctx context.Context, keys []int) (map[int]V, error)) *loader[V] {
+	return &loader[V]{fetch: fetch, values: map[int]V{}, done: map[int]bool{}}
+}
+
+// prime добавляет ключи в следующую пакетную загрузку
+func (l *loader[V]) prime(keys ...int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if !l.done[key] {
+			l.pending = append(l.pending, key)
+		}
+	}
+}
+
+// set сохраняет уже известное значение, чтобы не загружать его повторно
+func (l *loader[V]) set(key int, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.values[key] = value
+	l.done[key] = true
+}
+
+// load возвращает значение по ключу; ok == false, если такой записи нет
+func (l *loader[V]) load(ctx context.Context, key int) (value V, ok bool, err error) {
+	l.mu.Lock()
+	if l.done[key] {
+		value, ok = l.values[key]
+		l.mu.Unlock()
+		return value, ok, nil
+	}
+
+	keys := []int{key}
+	seen := map[int]bool{key: true}
+	for _, k := range l.pending {
+		if !seen[k] && !l.done[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	l.pending = nil
+	fetched, err := l.fetch(ctx, keys)
+	if err != nil {
+		l.mu.Unlock()
+		return value, false, err
+	}
+	for _, k := range keys {
+		if v, found := fetched[k]; found {
+			l.values[k] = v
+		}
+		l.done[k] = true
+	}
+	value, ok = l.values[key]
+	l.mu.Unlock()
+
+	if l.loaded != nil {
+		l.loaded(fetched)
+	}
+	return value, ok, nil
+}
+
+// loaders - загрузчики запроса: книги по ID автора и авторы по ID
+type loaders struct {
+	books   *loader[[]entity.Book]
+	authors *loader[entity.Author]
+}
+
+func newLoaders(service usecase.Service) *loaders {
+	l := &loaders{
+		books:   newLoader(service.GetBooksByAuthors),
+		authors: newLoader(service.GetAuthorsByIDs),
+	}
+	l.books.loaded = func(values map[int][]entity.Book) {
+		for _, books := range values {
+			l.booksSeen(books)
+		}
+	}
+	l.authors.loaded = func(values map[int]entity.Author) {
+		for _, author := range values {
+			l.books.prime(author.ID)
+		}
+	}
+	return l
+}
+
+// authorsSeen запоминает полученных авторов и готовит пакетную загрузку их книг
+func (l *loaders) authorsSeen(authors []entity.Author) {
+	for _, author := range authors {
+		l.authors.set(author.ID, author)
+		l.books.prime(author.ID)
+	}
+}
+
+// booksSeen готовит пакетную загрузку авторов полученных книг
+func (l *loaders) booksSeen(books []entity.Book) {
+	for _, book := range books {
+		l.authors.prime(book.AuthorID)
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
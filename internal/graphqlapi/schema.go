@@ -0,0 +1,374 @@
+package graphqlapi
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/usecase"
+	"net/http"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+const dateLayout = "2006-01-02"
+
+// resolvers - резолверы схемы поверх usecase.Service
+type resolvers struct {
+	service usecase.Service
+}
+
+func newSchema(service usecase.Service) (graphql.Schema, error) {
+	r := &resolvers{service: service}
+
+	authorType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: authorField(func(a entity.Author) interface{} { return a.ID })},
+			"firstName": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: authorField(func(a entity.Author) interface{} { return a.FirstName })},
+			"lastName":  &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: authorField(func(a entity.Author) interface{} { return a.LastName })},
+			"biography": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: authorField(func(a entity.Author) interface{} { return a.Biography })},
+			"birthDate": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Дата рождения в формате YYYY-MM-DD",
+				Resolve: authorField(func(a entity.Author) interface{} {
+					if a.BirthDate.IsZero() {
+						return nil
+					}
+					return a.BirthDate.Format(dateLayout)
+				}),
+			},
+		},
+	})
+	bookType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Book",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: bookField(func(b entity.Book) interface{} { return b.ID })},
+			"title":    &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: bookField(func(b entity.Book) interface{} { return b.Title })},
+			"year":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: bookField(func(b entity.Book) interface{} { return b.Year })},
+			"isbn":     &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: bookField(func(b entity.Book) interface{} { return b.ISBN })},
+			"authorId": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: bookField(func(b entity.Book) interface{} { return b.AuthorID })},
+			"author":   &graphql.Field{Type: authorType, Resolve: r.bookAuthor},
+		},
+	})
+	// связь в обратную сторону добавляется после создания обоих типов
+	authorType.AddFieldConfig("books", &graphql.Field{
+		Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookType))),
+		Resolve: r.authorBooks,
+	})
+
+	authorInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "AuthorInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"firstName": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"lastName":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"biography": &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: ""},
+			"birthDate": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "YYYY-MM-DD"},
+		},
+	})
+	bookInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"authorId": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"year":     &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 0},
+			"isbn":     &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: ""},
+		},
+	})
+	// автора книги меняет только UpdateBookWithAuthor, поэтому authorId при изменении не передаётся
+	bookUpdateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "BookUpdateInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"year":  &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 0},
+			"isbn":  &graphql.InputObjectFieldConfig{Type: graphql.String, DefaultValue: ""},
+		},
+	})
+
+	idArg := graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}}
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"authors": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(authorType))),
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.String, Description: "Подстрока имени или фамилии"},
+				},
+				Resolve: r.authors,
+			},
+			"author": &graphql.Field{Type: authorType, Args: idArg, Resolve: r.author},
+			"books": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bookType))),
+				Args: graphql.FieldConfigArgument{
+					"authorId": &graphql.ArgumentConfig{Type: graphql.Int},
+					"year":     &graphql.ArgumentConfig{Type: graphql.Int},
+					"title":    &graphql.ArgumentConfig{Type: graphql.String, Description: "Подстрока названия"},
+					"isbn":     &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: r.books,
+			},
+			"book": &graphql.Field{Type: bookType, Args: idArg, Resolve: r.book},
+		},
+	})
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createAuthor": &graphql.Field{
+				Type:    graphql.NewNonNull(authorType),
+				Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(authorInput)}},
+				Resolve: r.createAuthor,
+			},
+			"updateAuthor": &graphql.Field{
+				Type: graphql.NewNonNull(authorType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(authorInput)},
+				},
+				Resolve: r.updateAuthor,
+			},
+			"deleteAuthor": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Args: idArg, Resolve: r.deleteAuthor},
+			"createBook": &graphql.Field{
+				Type:    graphql.NewNonNull(bookType),
+				Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookInput)}},
+				Resolve: r.createBook,
+			},
+			"updateBook": &graphql.Field{
+				Type: graphql.NewNonNull(bookType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(bookUpdateInput)},
+				},
+				Resolve: r.updateBook,
+			},
+			"deleteBook": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Args: idArg, Resolve: r.deleteBook},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func authorField(get func(entity.Author) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(entity.Author)), nil
+	}
+}
+
+func bookField(get func(entity.Book) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(entity.Book)), nil
+	}
+}
+
+func (r *resolvers) authors(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermAuthorsRead); err != nil {
+		return nil, err
+	}
+	name, _ := p.Args["name"].(string)
+	authors, err := r.service.GetAllAuthors(p.Context, entity.AuthorFilter{Name: name})
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	if authors == nil {
+		authors = []entity.Author{}
+	}
+	loadersFrom(p.Context).authorsSeen(authors)
+	return authors, nil
+}
+
+func (r *resolvers) author(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermAuthorsRead); err != nil {
+		return nil, err
+	}
+	author, err := r.service.GetAuthor(p.Context, p.Args["id"].(int))
+	if err == errors.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	loadersFrom(p.Context).authorsSeen([]entity.Author{author})
+	return author, nil
+}
+
+func (r *resolvers) books(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermBooksRead); err != nil {
+		return nil, err
+	}
+	filter := entity.BookFilter{}
+	filter.AuthorID, _ = p.Args["authorId"].(int)
+	filter.Year, _ = p.Args["year"].(int)
+	filter.Title, _ = p.Args["title"].(string)
+	filter.ISBN, _ = p.Args["isbn"].(string)
+	books, err := r.service.GetAllBooks(p.Context, filter)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	if books == nil {
+		books = []entity.Book{}
+	}
+	loadersFrom(p.Context).booksSeen(books)
+	return books, nil
+}
+
+func (r *resolvers) book(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermBooksRead); err != nil {
+		return nil, err
+	}
+	book, err := r.service.GetBook(p.Context, p.Args["id"].(int))
+	if err == errors.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	loadersFrom(p.Context).booksSeen([]entity.Book{book})
+	return book, nil
+}
+
+// authorBooks берёт книги из пакетной загрузки по всем авторам, полученным в запросе
+func (r *resolvers) authorBooks(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermBooksRead); err != nil {
+		return nil, err
+	}
+	books, _, err := loadersFrom(p.Context).books.load(p.Context, p.Source.(entity.Author).ID)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	if books == nil {
+		books = []entity.Book{}
+	}
+	return books, nil
+}
+
+// bookAuthor берёт автора из пакетной загрузки по всем книгам, полученным в запросе
+func (r *resolvers) bookAuthor(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermAuthorsRead); err != nil {
+		return nil, err
+	}
+	author, ok, err := loadersFrom(p.Context).authors.load(p.Context, p.Source.(entity.Book).AuthorID)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return author, nil
+}
+
+func (r *resolvers) createAuthor(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermAuthorsCreate); err != nil {
+		return nil, err
+	}
+	author, err := authorFromInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+	id, err := r.service.CreateAuthor(p.Context, author.FirstName, author.LastName, author.Biography, author.BirthDate.Time)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	author.ID = id
+	loadersFrom(p.Context).authorsSeen([]entity.Author{author})
+	return author, nil
+}
+
+func (r *resolvers) updateAuthor(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermAuthorsUpdate); err != nil {
+		return nil, err
+	}
+	author, err := authorFromInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+	author.ID = p.Args["id"].(int)
+	if err := r.service.UpdateAuthor(p.Context, author.ID, author.FirstName, author.LastName, author.Biography, author.BirthDate.Time); err != nil {
+		return nil, resolverError(err)
+	}
+	loadersFrom(p.Context).authorsSeen([]entity.Author{author})
+	return author, nil
+}
+
+func (r *resolvers) deleteAuthor(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermAuthorsDelete); err != nil {
+		return nil, err
+	}
+	if err := r.service.DeleteAuthor(p.Context, p.Args["id"].(int)); err != nil {
+		return nil, resolverError(err)
+	}
+	return true, nil
+}
+
+func (r *resolvers) createBook(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermBooksCreate); err != nil {
+		return nil, err
+	}
+	input := p.Args["input"].(map[string]interface{})
+	book := entity.Book{
+		Title:    input["title"].(string),
+		AuthorID: input["authorId"].(int),
+		Year:     input["year"].(int),
+		ISBN:     input["isbn"].(string),
+	}
+	id, err := r.service.CreateBook(p.Context, book.Title, book.Year, book.ISBN, book.AuthorID)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	book.ID = id
+	loadersFrom(p.Context).booksSeen([]entity.Book{book})
+	return book, nil
+}
+
+func (r *resolvers) updateBook(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermBooksUpdate); err != nil {
+		return nil, err
+	}
+	id := p.Args["id"].(int)
+	input := p.Args["input"].(map[string]interface{})
+	title, year, isbn := input["title"].(string), input["year"].(int), input["isbn"].(string)
+	if err := r.service.UpdateBook(p.Context, id, title, year, isbn, 0); err != nil {
+		return nil, resolverError(err)
+	}
+	// автор книги не меняется, поэтому возвращается сохранённое состояние
+	book, err := r.service.GetBook(p.Context, id)
+	if err != nil {
+		return nil, resolverError(err)
+	}
+	loadersFrom(p.Context).booksSeen([]entity.Book{book})
+	return book, nil
+}
+
+func (r *resolvers) deleteBook(p graphql.ResolveParams) (interface{}, error) {
+	if err := require(p, auth.PermBooksDelete); err != nil {
+		return nil, err
+	}
+	if err := r.service.DeleteBook(p.Context, p.Args["id"].(int)); err != nil {
+		return nil, resolverError(err)
+	}
+	return true, nil
+}
+
+func authorFromInput(value interface{}) (entity.Author, error) {
+	input := value.(map[string]interface{})
+	author := entity.Author{
+		FirstName: input["firstName"].(string),
+		LastName:  input["lastName"].(string),
+		Biography: input["biography"].(string),
+	}
+	if birthDate, ok := input["birthDate"].(string); ok && birthDate != "" {
+		t, err := time.Parse(dateLayout, birthDate)
+		if err != nil {
+			return author, resolverError(errors.NewHTTPError(http.StatusBadRequest, "birthDate must be YYYY-MM-DD", "AuthorInput"))
+		}
+		author.BirthDate.Time = t
+	}
+	return author, nil
+}
+
+// require проверяет право вызывающего на поле: маршрут только загружает права,
+// а проверяются они для каждого запрошенного поля
+func require(p graphql.ResolveParams, permission string) error {
+	if identity, ok := auth.FromContext(p.Context); !ok || !identity.Can(permission) {
+		return resolverError(errors.NewHTTPError(http.StatusForbidden, "permission denied: "+permission, p.Info.FieldName))
+	}
+	return nil
+}
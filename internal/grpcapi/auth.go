@@ -0,0 +1,126 @@
+package grpcapi
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/requestid"
+	"api_library/pkg/librarypb"
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator проверяет учётные данные из метаданных; реализуется auth.Middleware
+type Authenticator interface {
+	Authenticate(ctx context.Context, apiKey, authorization string) (auth.Identity, error)
+}
+
+// Authorizer загружает роли и права; реализуется auth.Authorizer
+type Authorizer interface {
+	Load(ctx context.Context, identity auth.Identity) (auth.Identity, error)
+}
+
+// права, требуемые для каждого метода; методы без записи запрещены
+var methodPermissions = map[string][]string{
+	librarypb.LibraryService_ListAuthors_FullMethodName:        {auth.PermAuthorsRead},
+	librarypb.LibraryService_GetAuthor_FullMethodName:          {auth.PermAuthorsRead},
+	librarypb.LibraryService_CreateAuthor_FullMethodName:       {auth.PermAuthorsCreate},
+	librarypb.LibraryService_UpdateAuthor_FullMethodName:       {auth.PermAuthorsUpdate},
+	librarypb.LibraryService_DeleteAuthor_FullMethodName:       {auth.PermAuthorsDelete},
+	librarypb.LibraryService_RestoreAuthor_FullMethodName:      {auth.PermAuthorsDelete},
+	librarypb.LibraryService_ListAuthorVersions_FullMethodName: {auth.PermAuthorsRead},
+	librarypb.LibraryService_RevertAuthor_FullMethodName:       {auth.PermAuthorsUpdate},
+
+	// ListBooks, как и /export/books, отдаёт книги вместе с авторами по праву books:read
+	librarypb.LibraryService_ListBooks_FullMethodName:            {auth.PermBooksRead},
+	librarypb.LibraryService_GetBook_FullMethodName:              {auth.PermBooksRead},
+	librarypb.LibraryService_CreateBook_FullMethodName:           {auth.PermBooksCreate},
+	librarypb.LibraryService_UpdateBook_FullMethodName:           {auth.PermBooksUpdate},
+	librarypb.LibraryService_UpdateBookWithAuthor_FullMethodName: {auth.PermBooksUpdate, auth.PermAuthorsUpdate},
+	librarypb.LibraryService_DeleteBook_FullMethodName:           {auth.PermBooksDelete},
+	librarypb.LibraryService_RestoreBook_FullMethodName:          {auth.PermBooksDelete},
+	librarypb.LibraryService_ListBookVersions_FullMethodName:     {auth.PermBooksRead},
+	librarypb.LibraryService_RevertBook_FullMethodName:           {auth.PermBooksUpdate},
+
+	librarypb.LibraryService_GetTrash_FullMethodName: {auth.PermTrashRead},
+}
+
+// метаданные запроса: те же имена, что у HTTP-заголовков
+const (
+	metadataAuthorization = "authorization"
+	metadataAPIKey        = "x-api-key"
+	metadataRequestID     = "x-request-id"
+)
+
+type authInterceptor struct {
+	authenticator Authenticator
+	authorizer    Authorizer
+}
+
+func (a *authInterceptor) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authInterceptor) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// authorize аутентифицирует вызывающего, проверяет права на метод и добавляет в контекст
+// Identity и ID запроса (для журнала аудита); ID возвращается в заголовке x-request-id
+func (a *authInterceptor) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	required, ok := methodPermissions[fullMethod]
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "method not supported")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := firstValue(md, metadataRequestID)
+	if requestID == "" || len(requestID) > 128 {
+		requestID = requestid.New()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, requestID))
+	ctx = requestid.WithRequestID(ctx, requestID)
+
+	identity, err := a.authenticator.Authenticate(ctx, firstValue(md, metadataAPIKey), firstValue(md, metadataAuthorization))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	identity, err = a.authorizer.Load(ctx, identity)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	for _, permission := range required {
+		if !identity.Can(permission) {
+			return nil, status.Error(codes.PermissionDenied, "permission denied: "+permission)
+		}
+	}
+	return auth.WithIdentity(ctx, identity), nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// serverStream подменяет контекст потока контекстом с Identity
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,100 @@
+package grpcapi
+
+import (
+	"api_library/internal/entity"
+	"api_library/pkg/librarypb"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const dateLayout = "2006-01-02"
+
+func timestampOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func authorToProto(author entity.Author) *librarypb.Author {
+	pb := &librarypb.Author{
+		Id:        int64(author.ID),
+		FirstName: author.FirstName,
+		LastName:  author.LastName,
+		Biography: author.Biography,
+		DeletedAt: timestampOrNil(author.DeletedAt),
+	}
+	if !author.BirthDate.IsZero() {
+		pb.BirthDate = author.BirthDate.Format(dateLayout)
+	}
+	return pb
+}
+
+func authorFromProto(pb *librarypb.Author) (entity.Author, error) {
+	if pb == nil {
+		return entity.Author{}, status.Error(codes.InvalidArgument, "author is required")
+	}
+	author := entity.Author{
+		FirstName: pb.GetFirstName(),
+		LastName:  pb.GetLastName(),
+		Biography: pb.GetBiography(),
+	}
+	if pb.GetBirthDate() != "" {
+		birthDate, err := time.Parse(dateLayout, pb.GetBirthDate())
+		if err != nil {
+			return author, status.Error(codes.InvalidArgument, "invalid birth_date, expected YYYY-MM-DD")
+		}
+		author.BirthDate = entity.Date{Time: birthDate}
+	}
+	return author, nil
+}
+
+func bookToProto(book entity.Book) *librarypb.Book {
+	return &librarypb.Book{
+		Id:        int64(book.ID),
+		Title:     book.Title,
+		AuthorId:  int64(book.AuthorID),
+		Year:      int32(book.Year),
+		Isbn:      book.ISBN,
+		DeletedAt: timestampOrNil(book.DeletedAt),
+	}
+}
+
+func bookRecordToProto(record entity.BookRecord) *librarypb.Book {
+	pb := bookToProto(record.Book)
+	if record.Author.ID != 0 {
+		pb.Author = authorToProto(record.Author)
+	}
+	return pb
+}
+
+func bookFromProto(pb *librarypb.Book) (entity.Book, error) {
+	if pb == nil {
+		return entity.Book{}, status.Error(codes.InvalidArgument, "book is required")
+	}
+	return entity.Book{
+		Title:    pb.GetTitle(),
+		AuthorID: int(pb.GetAuthorId()),
+		Year:     int(pb.GetYear()),
+		ISBN:     pb.GetIsbn(),
+	}, nil
+}
+
+func authorsToProto(authors []entity.Author) []*librarypb.Author {
+	pbs := make([]*librarypb.Author, len(authors))
+	for i, author := range authors {
+		pbs[i] = authorToProto(author)
+	}
+	return pbs
+}
+
+func booksToProto(books []entity.Book) []*librarypb.Book {
+	pbs := make([]*librarypb.Book, len(books))
+	for i, book := range books {
+		pbs[i] = bookToProto(book)
+	}
+	return pbs
+}
@@ -0,0 +1,70 @@
+package grpcapi
+
+import (
+	apperrors "api_library/internal/errors"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus переводит ошибки сервиса в статусы gRPC: сначала сигнальные ошибки пакета errors,
+// затем HTTPError по его HTTP-коду
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	// недоступность базы проверяется раньше ErrDB: клиенту важно, что запрос можно повторить
+	var unavailable *apperrors.HTTPError
+	if errors.As(err, &unavailable) && unavailable.Code == http.StatusServiceUnavailable {
+		return status.Error(codes.Unavailable, unavailable.Message)
+	}
+
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, apperrors.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, apperrors.ErrUnauthorized):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, apperrors.ErrDB):
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	var httpErr *apperrors.HTTPError
+	if !errors.As(err, &httpErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return status.Error(httpCode(httpErr.Code), httpErr.Message)
+}
+
+func httpCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
@@ -0,0 +1,206 @@
+// Package grpcapi - gRPC-интерфейс каталога (proto/library/v1/library.proto)
+// поверх того же usecase.Service, что и REST API.
+package grpcapi
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/usecase"
+	"api_library/pkg/librarypb"
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type libraryServer struct {
+	librarypb.UnimplementedLibraryServiceServer
+	service usecase.Service
+}
+
+// NewServer создаёт gRPC-сервер с LibraryService; аутентификация и права проверяются так же, как в REST
+func NewServer(service usecase.Service, authenticator Authenticator, authorizer Authorizer) *grpc.Server {
+	a := &authInterceptor{authenticator: authenticator, authorizer: authorizer}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(a.unary),
+		grpc.StreamInterceptor(a.stream),
+	)
+	librarypb.RegisterLibraryServiceServer(server, &libraryServer{service: service})
+	return server
+}
+
+func (s *libraryServer) ListAuthors(ctx context.Context, req *librarypb.ListAuthorsRequest) (*librarypb.ListAuthorsResponse, error) {
+	authors, err := s.service.GetAllAuthors(ctx, entity.AuthorFilter{Name: req.GetName()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &librarypb.ListAuthorsResponse{Authors: authorsToProto(authors)}, nil
+}
+
+func (s *libraryServer) GetAuthor(ctx context.Context, req *librarypb.GetAuthorRequest) (*librarypb.Author, error) {
+	var author entity.Author
+	var err error
+	if req.GetAsOf() != nil {
+		author, err = s.service.GetAuthorAsOf(ctx, int(req.GetId()), req.GetAsOf().AsTime())
+	} else {
+		author, err = s.service.GetAuthor(ctx, int(req.GetId()))
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return authorToProto(author), nil
+}
+
+func (s *libraryServer) CreateAuthor(ctx context.Context, req *librarypb.CreateAuthorRequest) (*librarypb.CreateAuthorResponse, error) {
+	author, err := authorFromProto(req.GetAuthor())
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.service.CreateAuthor(ctx, author.FirstName, author.LastName, author.Biography, author.BirthDate.Time)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &librarypb.CreateAuthorResponse{Id: int64(id)}, nil
+}
+
+func (s *libraryServer) UpdateAuthor(ctx context.Context, req *librarypb.UpdateAuthorRequest) (*emptypb.Empty, error) {
+	author, err := authorFromProto(req.GetAuthor())
+	if err != nil {
+		return nil, err
+	}
+	err = s.service.UpdateAuthor(ctx, int(req.GetId()), author.FirstName, author.LastName, author.Biography, author.BirthDate.Time)
+	return empty(err)
+}
+
+func (s *libraryServer) DeleteAuthor(ctx context.Context, req *librarypb.AuthorIDRequest) (*emptypb.Empty, error) {
+	return empty(s.service.DeleteAuthor(ctx, int(req.GetId())))
+}
+
+func (s *libraryServer) RestoreAuthor(ctx context.Context, req *librarypb.AuthorIDRequest) (*emptypb.Empty, error) {
+	return empty(s.service.RestoreAuthor(ctx, int(req.GetId())))
+}
+
+func (s *libraryServer) ListAuthorVersions(ctx context.Context, req *librarypb.AuthorIDRequest) (*librarypb.ListAuthorVersionsResponse, error) {
+	versions, err := s.service.GetAuthorVersions(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &librarypb.ListAuthorVersionsResponse{Versions: make([]*librarypb.AuthorVersion, len(versions))}
+	for i, version := range versions {
+		resp.Versions[i] = &librarypb.AuthorVersion{
+			Version:   int32(version.Version),
+			ValidFrom: timestampOrNil(&version.ValidFrom),
+			ValidTo:   timestampOrNil(version.ValidTo),
+			Author:    authorToProto(version.Author),
+		}
+	}
+	return resp, nil
+}
+
+func (s *libraryServer) RevertAuthor(ctx context.Context, req *librarypb.RevertAuthorRequest) (*emptypb.Empty, error) {
+	return empty(s.service.RevertAuthor(ctx, int(req.GetId()), int(req.GetVersion())))
+}
+
+// ListBooks отправляет книги по мере чтения из базы, не собирая весь список в памяти
+func (s *libraryServer) ListBooks(req *librarypb.ListBooksRequest, stream librarypb.LibraryService_ListBooksServer) error {
+	filter := entity.BookFilter{
+		AuthorID: int(req.GetAuthorId()),
+		Year:     int(req.GetYear()),
+		Title:    req.GetTitle(),
+		ISBN:     req.GetIsbn(),
+	}
+	err := s.service.ExportBooks(stream.Context(), filter, func(record entity.BookRecord) error {
+		return stream.Send(bookRecordToProto(record))
+	})
+	return toStatus(err)
+}
+
+func (s *libraryServer) GetBook(ctx context.Context, req *librarypb.GetBookRequest) (*librarypb.Book, error) {
+	var book entity.Book
+	var err error
+	if req.GetAsOf() != nil {
+		book, err = s.service.GetBookAsOf(ctx, int(req.GetId()), req.GetAsOf().AsTime())
+	} else {
+		book, err = s.service.GetBook(ctx, int(req.GetId()))
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return bookToProto(book), nil
+}
+
+func (s *libraryServer) CreateBook(ctx context.Context, req *librarypb.CreateBookRequest) (*librarypb.CreateBookResponse, error) {
+	book, err := bookFromProto(req.GetBook())
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.service.CreateBook(ctx, book.Title, book.Year, book.ISBN, book.AuthorID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &librarypb.CreateBookResponse{Id: int64(id)}, nil
+}
+
+func (s *libraryServer) UpdateBook(ctx context.Context, req *librarypb.UpdateBookRequest) (*emptypb.Empty, error) {
+	book, err := bookFromProto(req.GetBook())
+	if err != nil {
+		return nil, err
+	}
+	return empty(s.service.UpdateBook(ctx, int(req.GetId()), book.Title, book.Year, book.ISBN, book.AuthorID))
+}
+
+func (s *libraryServer) UpdateBookWithAuthor(ctx context.Context, req *librarypb.UpdateBookWithAuthorRequest) (*emptypb.Empty, error) {
+	book, err := bookFromProto(req.GetBook())
+	if err != nil {
+		return nil, err
+	}
+	author, err := authorFromProto(req.GetAuthor())
+	if err != nil {
+		return nil, err
+	}
+	return empty(s.service.UpdateBookWithAuthor(ctx, int(req.GetBookId()), book.Title, book.Year, book.ISBN, book.AuthorID,
+		author.FirstName, author.LastName, author.Biography, author.BirthDate.Time))
+}
+
+func (s *libraryServer) DeleteBook(ctx context.Context, req *librarypb.BookIDRequest) (*emptypb.Empty, error) {
+	return empty(s.service.DeleteBook(ctx, int(req.GetId())))
+}
+
+func (s *libraryServer) RestoreBook(ctx context.Context, req *librarypb.BookIDRequest) (*emptypb.Empty, error) {
+	return empty(s.service.RestoreBook(ctx, int(req.GetId())))
+}
+
+func (s *libraryServer) ListBookVersions(ctx context.Context, req *librarypb.BookIDRequest) (*librarypb.ListBookVersionsResponse, error) {
+	versions, err := s.service.GetBookVersions(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &librarypb.ListBookVersionsResponse{Versions: make([]*librarypb.BookVersion, len(versions))}
+	for i, version := range versions {
+		resp.Versions[i] = &librarypb.BookVersion{
+			Version:   int32(version.Version),
+			ValidFrom: timestampOrNil(&version.ValidFrom),
+			ValidTo:   timestampOrNil(version.ValidTo),
+			Book:      bookToProto(version.Book),
+		}
+	}
+	return resp, nil
+}
+
+func (s *libraryServer) RevertBook(ctx context.Context, req *librarypb.RevertBookRequest) (*emptypb.Empty, error) {
+	return empty(s.service.RevertBook(ctx, int(req.GetId()), int(req.GetVersion())))
+}
+
+func (s *libraryServer) GetTrash(ctx context.Context, _ *emptypb.Empty) (*librarypb.Trash, error) {
+	trash, err := s.service.GetTrash(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &librarypb.Trash{Authors: authorsToProto(trash.Authors), Books: booksToProto(trash.Books)}, nil
+}
+
+func empty(err error) (*emptypb.Empty, error) {
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/usecase"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type AuditHandler struct {
+	auditService usecase.AuditService
+}
+
+func NewAuditHandler(auditService usecase.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// HandleAudit - история изменений: /audit?entity=book&id=1&actor=admin&limit=50
+func (h *AuditHandler) HandleAudit(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getAuditLog(w, r)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleAudit"))
+	}
+}
+
+func (h *AuditHandler) getAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := entity.AuditFilter{
+		Entity: query.Get("entity"),
+		Actor:  query.Get("actor"),
+	}
+	if v := query.Get("id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid id", "getAuditLog"))
+			return
+		}
+		filter.EntityID = id
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid limit", "getAuditLog"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.auditService.GetAuditLog(r.Context(), filter)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (h *AuditHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/usecase"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AuthHandler struct {
+	authService usecase.AuthService
+}
+
+func NewAuthHandler(authService usecase.AuthService) *AuthHandler {
+	return &AuthHandler{authService: authService}
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type createAPIKeyResponse struct {
+	APIKey entity.APIKey `json:"api_key"`
+	Key    string        `json:"key"`
+}
+
+// HandleToken выдаёт JWT по логину и паролю сотрудника
+func (h *AuthHandler) HandleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleToken"))
+		return
+	}
+
+	var credentials entity.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, err.Error(), "HandleToken"))
+		return
+	}
+
+	token, err := h.authService.Login(r.Context(), credentials.Username, credentials.Password)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(token)
+}
+
+// HandleUsers создаёт пользователя; роли ему назначаются через /admin/users/{id}/roles
+func (h *AuthHandler) HandleUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleUsers"))
+		return
+	}
+
+	var credentials entity.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, err.Error(), "HandleUsers"))
+		return
+	}
+
+	user, err := h.authService.CreateUser(r.Context(), credentials.Username, credentials.Password)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// HandleAPIKeys - ключи текущего пользователя
+func (h *AuthHandler) HandleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(errors.ErrUnauthorized))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getAPIKeys(w, r, identity)
+	case http.MethodPost:
+		h.createAPIKey(w, r, identity)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleAPIKeys"))
+	}
+}
+
+func (h *AuthHandler) HandleAPIKey(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(errors.ErrUnauthorized))
+		return
+	}
+
+	urlPathSegments := strings.Split(r.URL.Path, "api-keys/")
+	keyID, err := strconv.Atoi(urlPathSegments[len(urlPathSegments)-1])
+	if err != nil {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid api key ID", "HandleAPIKey"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		h.revokeAPIKey(w, r, identity, keyID)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleAPIKey"))
+	}
+}
+
+func (h *AuthHandler) getAPIKeys(w http.ResponseWriter, r *http.Request, identity auth.Identity) {
+	keys, err := h.authService.GetAPIKeys(r.Context(), identity.UserID)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(keys)
+}
+
+func (h *AuthHandler) createAPIKey(w http.ResponseWriter, r *http.Request, identity auth.Identity) {
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, err.Error(), "createAPIKey"))
+		return
+	}
+
+	apiKey, key, err := h.authService.CreateAPIKey(r.Context(), identity.UserID, req.Name, req.ExpiresAt)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createAPIKeyResponse{APIKey: apiKey, Key: key})
+}
+
+func (h *AuthHandler) revokeAPIKey(w http.ResponseWriter, r *http.Request, identity auth.Identity, keyID int) {
+	if err := h.authService.RevokeAPIKey(r.Context(), identity.UserID, keyID); err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "API key revoked with ID: %d", keyID)
+}
+
+func (h *AuthHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
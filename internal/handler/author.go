@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type AuthorHandler struct {
@@ -31,13 +32,18 @@ func (h *AuthorHandler) HandleAuthors(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AuthorHandler) HandleAuthor(w http.ResponseWriter, r *http.Request) {
-	urlPathSegments := strings.Split(r.URL.Path, "authors/")
-	authorID, err := strconv.Atoi(urlPathSegments[len(urlPathSegments)-1])
+	urlPathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/authors/"), "/")
+	authorID, err := strconv.Atoi(urlPathSegments[0])
 	if err != nil {
 		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid author ID", "HandleAuthor"))
 		return
 	}
 
+	if len(urlPathSegments) > 1 {
+		h.handleAuthorSubresource(w, r, authorID, urlPathSegments[1:])
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getAuthorByID(w, r, authorID)
@@ -50,8 +56,39 @@ func (h *AuthorHandler) HandleAuthor(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// подресурсы автора: /restore, /versions, /versions/{n}/revert
+func (h *AuthorHandler) handleAuthorSubresource(w http.ResponseWriter, r *http.Request, authorID int, segments []string) {
+	switch {
+	case len(segments) == 1 && segments[0] == "restore":
+		if r.Method != http.MethodPost {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleAuthor"))
+			return
+		}
+		h.restoreAuthor(w, r, authorID)
+	case len(segments) == 1 && segments[0] == "versions":
+		if r.Method != http.MethodGet {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleAuthor"))
+			return
+		}
+		h.getAuthorVersions(w, r, authorID)
+	case len(segments) == 3 && segments[0] == "versions" && segments[2] == "revert":
+		version, err := strconv.Atoi(segments[1])
+		if err != nil {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid version", "HandleAuthor"))
+			return
+		}
+		if r.Method != http.MethodPost {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleAuthor"))
+			return
+		}
+		h.revertAuthor(w, r, authorID, version)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusNotFound, "not found", "HandleAuthor"))
+	}
+}
+
 func (h *AuthorHandler) getAuthors(w http.ResponseWriter, r *http.Request) {
-	authors, err := h.service.GetAllAuthors()
+	authors, err := h.service.GetAllAuthors(r.Context(), entity.AuthorFilter{})
 	if err != nil {
 		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
 		return
@@ -61,12 +98,27 @@ func (h *AuthorHandler) getAuthors(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// getAuthorByID с параметром ?as_of=2025-01-01T00:00:00Z возвращает состояние автора на указанный момент
 func (h *AuthorHandler) getAuthorByID(w http.ResponseWriter, r *http.Request, authorID int) {
-	author, err := h.service.GetAuthor(authorID)
+	var author entity.Author
+	var err error
+	if v := r.URL.Query().Get("as_of"); v != "" {
+		asOf, parseErr := time.Parse(time.RFC3339, v)
+		if parseErr != nil {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid as_of, expected RFC 3339 timestamp", "getAuthorByID"))
+			return
+		}
+		author, err = h.service.GetAuthorAsOf(r.Context(), authorID, asOf)
+	} else {
+		author, err = h.service.GetAuthor(r.Context(), authorID)
+	}
 	if err != nil {
 		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
 		return
 	}
+	if notModified(w, r, author.UpdatedAt) {
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(author)
 	w.WriteHeader(http.StatusOK)
@@ -79,7 +131,7 @@ func (h *AuthorHandler) createAuthor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authorID, err := h.service.CreateAuthor(author.FirstName, author.LastName, author.Biography, author.BirthDate.Time)
+	authorID, err := h.service.CreateAuthor(r.Context(), author.FirstName, author.LastName, author.Biography, author.BirthDate.Time)
 	if err != nil {
 		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
 		return
@@ -96,7 +148,7 @@ func (h *AuthorHandler) updateAuthor(w http.ResponseWriter, r *http.Request, aut
 		return
 	}
 
-	err := h.service.UpdateAuthor(authorID, author.FirstName, author.LastName, author.Biography, author.BirthDate.Time)
+	err := h.service.UpdateAuthor(r.Context(), authorID, author.FirstName, author.LastName, author.Biography, author.BirthDate.Time)
 	if err != nil {
 		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
 		return
@@ -107,7 +159,7 @@ func (h *AuthorHandler) updateAuthor(w http.ResponseWriter, r *http.Request, aut
 }
 
 func (h *AuthorHandler) deleteAuthor(w http.ResponseWriter, r *http.Request, authorID int) {
-	err := h.service.DeleteAuthor(authorID)
+	err := h.service.DeleteAuthor(r.Context(), authorID)
 	if err != nil {
 		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
 		return
@@ -117,6 +169,39 @@ func (h *AuthorHandler) deleteAuthor(w http.ResponseWriter, r *http.Request, aut
 	fmt.Fprintf(w, "Author deleted with ID: %d", authorID)
 }
 
+func (h *AuthorHandler) restoreAuthor(w http.ResponseWriter, r *http.Request, authorID int) {
+	err := h.service.RestoreAuthor(r.Context(), authorID)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Author restored with ID: %d", authorID)
+}
+
+func (h *AuthorHandler) getAuthorVersions(w http.ResponseWriter, r *http.Request, authorID int) {
+	versions, err := h.service.GetAuthorVersions(r.Context(), authorID)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(versions)
+}
+
+func (h *AuthorHandler) revertAuthor(w http.ResponseWriter, r *http.Request, authorID, version int) {
+	err := h.service.RevertAuthor(r.Context(), authorID, version)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Author with ID %d reverted to version %d", authorID, version)
+}
+
 func (h *AuthorHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpErr.Code)
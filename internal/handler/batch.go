@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/usecase"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+)
+
+// maxBatchOperationSize - допустимый размер одной операции пакета в теле запроса, с запасом на длинную биографию
+const maxBatchOperationSize = 16 << 10
+
+type BatchHandler struct {
+	batchService usecase.BatchService
+	// размер тела, больше которого пакет из maxSize операций быть не может
+	maxBodySize int64
+}
+
+// maxSize - максимальное число операций в пакете, из него выводится допустимый размер тела запроса
+func NewBatchHandler(batchService usecase.BatchService, maxSize int) *BatchHandler {
+	return &BatchHandler{batchService: batchService, maxBodySize: int64(maxSize) * maxBatchOperationSize}
+}
+
+// HandleBatch - пакет операций над авторами и книгами.
+// Ответ: 200 - все операции выполнены, 207 - часть операций не выполнена (независимый режим),
+// 422 - атомарный пакет откачен. Результат каждой операции - в results.
+func (h *BatchHandler) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.executeBatch(w, r)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleBatch"))
+	}
+}
+
+func (h *BatchHandler) executeBatch(w http.ResponseWriter, r *http.Request) {
+	var request entity.BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBodySize)).Decode(&request); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusRequestEntityTooLarge, "request body is larger than "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes", "executeBatch"))
+			return
+		}
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid data format", "executeBatch"))
+		return
+	}
+
+	response, err := h.batchService.Execute(r.Context(), request)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+
+	status := http.StatusOK
+	switch {
+	case response.Failed > 0 && response.Atomic:
+		status = http.StatusUnprocessableEntity
+	case response.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BatchHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
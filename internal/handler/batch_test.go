@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/usecase"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeBatchService struct {
+	usecase.BatchService
+	calls int
+}
+
+func (f *fakeBatchService) Execute(ctx context.Context, request entity.BatchRequest) (entity.BatchResponse, error) {
+	f.calls++
+	return entity.BatchResponse{Atomic: request.Atomic}, nil
+}
+
+func TestBatchBodyLimit(t *testing.T) {
+	operation := `{"op":"create","entity":"author","data":{"first_name":"Лев","last_name":"Толстой"}},`
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"within limit", `{"operations":[` + strings.TrimSuffix(strings.Repeat(operation, 2), ",") + `]}`, http.StatusOK},
+		{"oversized body", `{"operations":[{"op":"create","entity":"author","data":{"biography":"` + strings.Repeat("x", 2*maxBatchOperationSize) + `"}}]}`, http.StatusRequestEntityTooLarge},
+		{"malformed", `{"operations":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeBatchService{}
+			h := NewBatchHandler(service, 2)
+			w := httptest.NewRecorder()
+			h.HandleBatch(w, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(tt.body)))
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if executed := service.calls > 0; executed != (tt.want == http.StatusOK) {
+				t.Errorf("service executed = %v", executed)
+			}
+		})
+	}
+}
@@ -2,12 +2,15 @@ package handler
 
 import (
 	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/marc"
 	"api_library/internal/usecase"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type BookHandler struct {
@@ -30,13 +33,33 @@ func (h *BookHandler) HandleBooks(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *BookHandler) HandleBook(w http.ResponseWriter, r *http.Request) {
-	urlPathSegments := strings.Split(r.URL.Path, "books/")
-	bookID, err := strconv.Atoi(urlPathSegments[len(urlPathSegments)-1])
+	urlPathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/books/"), "/")
+	// /books/{id}.mrc и /books/{id}.xml - запись книги в MARC 21
+	id, marcFormat, _ := strings.Cut(urlPathSegments[0], ".")
+	bookID, err := strconv.Atoi(id)
 	if err != nil {
 		h.sendResponse(w, http.StatusBadRequest, "Invalid book ID")
 		return
 	}
 
+	if marcFormat != "" {
+		if len(urlPathSegments) > 1 || (marcFormat != "mrc" && marcFormat != "xml") {
+			h.sendResponse(w, http.StatusNotFound, "Not found")
+			return
+		}
+		if r.Method != http.MethodGet {
+			h.sendResponse(w, http.StatusMethodNotAllowed, "Method not supported")
+			return
+		}
+		h.getBookMARC(w, r, bookID, marcFormat)
+		return
+	}
+
+	if len(urlPathSegments) > 1 {
+		h.handleBookSubresource(w, r, bookID, urlPathSegments[1:])
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.getBookByID(w, r, bookID)
@@ -49,26 +72,106 @@ func (h *BookHandler) HandleBook(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// подресурсы книги: /restore, /versions, /versions/{n}/revert
+func (h *BookHandler) handleBookSubresource(w http.ResponseWriter, r *http.Request, bookID int, segments []string) {
+	switch {
+	case len(segments) == 1 && segments[0] == "restore":
+		if r.Method != http.MethodPost {
+			h.sendResponse(w, http.StatusMethodNotAllowed, "Method not supported")
+			return
+		}
+		h.restoreBook(w, r, bookID)
+	case len(segments) == 1 && segments[0] == "versions":
+		if r.Method != http.MethodGet {
+			h.sendResponse(w, http.StatusMethodNotAllowed, "Method not supported")
+			return
+		}
+		h.getBookVersions(w, r, bookID)
+	case len(segments) == 3 && segments[0] == "versions" && segments[2] == "revert":
+		version, err := strconv.Atoi(segments[1])
+		if err != nil {
+			h.sendResponse(w, http.StatusBadRequest, "Invalid version")
+			return
+		}
+		if r.Method != http.MethodPost {
+			h.sendResponse(w, http.StatusMethodNotAllowed, "Method not supported")
+			return
+		}
+		h.revertBook(w, r, bookID, version)
+	default:
+		h.sendResponse(w, http.StatusNotFound, "Not found")
+	}
+}
+
 func (h *BookHandler) getBooks(w http.ResponseWriter, r *http.Request) {
-	books, err := h.bookService.GetAllBooks()
+	books, err := h.bookService.GetAllBooks(r.Context(), entity.BookFilter{})
 	if err != nil {
-		h.sendResponse(w, http.StatusInternalServerError, fmt.Sprintf("Error retrieving books: %v", err))
+		httpErr := errors.MapErrorToHTTP(err)
+		h.sendResponse(w, httpErr.Code, fmt.Sprintf("Error retrieving books: %v", httpErr.Message))
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(books)
 }
 
+// getBookByID с параметром ?as_of=2025-01-01T00:00:00Z возвращает состояние книги на указанный момент
 func (h *BookHandler) getBookByID(w http.ResponseWriter, r *http.Request, bookID int) {
-	book, err := h.bookService.GetBook(bookID)
+	var book entity.Book
+	var err error
+	if v := r.URL.Query().Get("as_of"); v != "" {
+		asOf, parseErr := time.Parse(time.RFC3339, v)
+		if parseErr != nil {
+			h.sendResponse(w, http.StatusBadRequest, "Invalid as_of, expected RFC 3339 timestamp")
+			return
+		}
+		book, err = h.bookService.GetBookAsOf(r.Context(), bookID, asOf)
+	} else {
+		book, err = h.bookService.GetBook(r.Context(), bookID)
+	}
 	if err != nil {
-		h.sendResponse(w, http.StatusNotFound, fmt.Sprintf("Book with ID %d not found", bookID))
+		h.sendBookError(w, err, bookID)
+		return
+	}
+	if notModified(w, r, book.UpdatedAt) {
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(book)
 }
 
+func (h *BookHandler) getBookMARC(w http.ResponseWriter, r *http.Request, bookID int, format string) {
+	book, err := h.bookService.GetBook(r.Context(), bookID)
+	if err != nil {
+		h.sendBookError(w, err, bookID)
+		return
+	}
+	// автор мог быть удалён - запись выгружается без поля 100
+	author, err := h.bookService.GetAuthor(r.Context(), book.AuthorID)
+	if err != nil && err != errors.ErrNotFound {
+		httpErr := errors.MapErrorToHTTP(err)
+		h.sendResponse(w, httpErr.Code, fmt.Sprintf("Error retrieving author: %v", httpErr.Message))
+		return
+	}
+	record := marc.FromBook(book, author)
+
+	if format == "xml" {
+		w.Header().Set("Content-Type", "application/marcxml+xml")
+		xmlWriter := marc.NewXMLWriter(w)
+		if err := xmlWriter.Write(record); err == nil {
+			xmlWriter.Close()
+		}
+		return
+	}
+	data, err := marc.Marshal(record)
+	if err != nil {
+		h.sendResponse(w, http.StatusInternalServerError, fmt.Sprintf("Error encoding MARC record: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/marc")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="book-%d.mrc"`, bookID))
+	w.Write(data)
+}
+
 func (h *BookHandler) createBook(w http.ResponseWriter, r *http.Request) {
 	var book entity.Book
 	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
@@ -76,9 +179,10 @@ func (h *BookHandler) createBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bookID, err := h.bookService.CreateBook(book.Title, book.Year, book.ISBN, book.AuthorID)
+	bookID, err := h.bookService.CreateBook(r.Context(), book.Title, book.Year, book.ISBN, book.AuthorID)
 	if err != nil {
-		h.sendResponse(w, http.StatusInternalServerError, fmt.Sprintf("Error creating book: %v", err))
+		httpErr := errors.MapErrorToHTTP(err)
+		h.sendResponse(w, httpErr.Code, fmt.Sprintf("Error creating book: %v", httpErr.Message))
 		return
 	}
 
@@ -93,25 +197,72 @@ func (h *BookHandler) updateBook(w http.ResponseWriter, r *http.Request, bookID
 		return
 	}
 
-	err := h.bookService.UpdateBook(bookID, book.Title, book.Year, book.ISBN, book.AuthorID)
+	err := h.bookService.UpdateBook(r.Context(), bookID, book.Title, book.Year, book.ISBN, book.AuthorID)
 	if err != nil {
-		h.sendResponse(w, http.StatusInternalServerError, fmt.Sprintf("Error updating book: %v", err))
+		httpErr := errors.MapErrorToHTTP(err)
+		h.sendResponse(w, httpErr.Code, fmt.Sprintf("Error updating book: %v", httpErr.Message))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "Book with ID %d updated")
+	fmt.Fprintf(w, "Book with ID %d updated", bookID)
 }
 
 func (h *BookHandler) deleteBook(w http.ResponseWriter, r *http.Request, bookID int) {
-	err := h.bookService.DeleteBook(bookID)
+	err := h.bookService.DeleteBook(r.Context(), bookID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		h.sendResponse(w, httpErr.Code, fmt.Sprintf("Error deleting book: %v", httpErr.Message))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Book with ID %d deleted", bookID)
+}
+
+func (h *BookHandler) restoreBook(w http.ResponseWriter, r *http.Request, bookID int) {
+	err := h.bookService.RestoreBook(r.Context(), bookID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		h.sendResponse(w, httpErr.Code, fmt.Sprintf("Error restoring book: %v", httpErr.Message))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Book with ID %d restored", bookID)
+}
+
+func (h *BookHandler) getBookVersions(w http.ResponseWriter, r *http.Request, bookID int) {
+	versions, err := h.bookService.GetBookVersions(r.Context(), bookID)
 	if err != nil {
-		h.sendResponse(w, http.StatusInternalServerError, fmt.Sprintf("Error deleting book: %v", err))
+		h.sendBookError(w, err, bookID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+func (h *BookHandler) revertBook(w http.ResponseWriter, r *http.Request, bookID, version int) {
+	err := h.bookService.RevertBook(r.Context(), bookID, version)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		h.sendResponse(w, httpErr.Code, fmt.Sprintf("Error reverting book: %v", httpErr.Message))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "Book with ID %d deleted")
+	fmt.Fprintf(w, "Book with ID %d reverted to version %d", bookID, version)
+}
+
+// sendBookError отвечает 404 с ID книги, если её нет, и кодом из ошибки в остальных случаях
+// (например, 503, пока база недоступна)
+func (h *BookHandler) sendBookError(w http.ResponseWriter, err error, bookID int) {
+	httpErr := errors.MapErrorToHTTP(err)
+	if httpErr.Code == http.StatusNotFound {
+		h.sendResponse(w, http.StatusNotFound, fmt.Sprintf("Book with ID %d not found", bookID))
+		return
+	}
+	h.sendResponse(w, httpErr.Code, fmt.Sprintf("Error retrieving book: %v", httpErr.Message))
 }
 
 func (h *BookHandler) sendResponse(w http.ResponseWriter, statusCode int, message string) {
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+// notModified указывает Last-Modified и отвечает 304, если версия клиента из If-Modified-Since актуальна.
+// Last-Modified передаётся с точностью до секунды, поэтому и сравнение идёт по секундам.
+func notModified(w http.ResponseWriter, r *http.Request, updatedAt time.Time) bool {
+	if updatedAt.IsZero() {
+		return false
+	}
+	updatedAt = updatedAt.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", updatedAt.Format(http.TimeFormat))
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !updatedAt.After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
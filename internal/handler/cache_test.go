@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	updatedAt := time.Date(2025, 3, 1, 12, 0, 0, 500_000_000, time.FixedZone("MSK", 3*60*60))
+	lastModified := "Sat, 01 Mar 2025 09:00:00 GMT"
+
+	tests := []struct {
+		name            string
+		updatedAt       time.Time
+		ifModifiedSince string
+		want            bool
+	}{
+		{"no header", updatedAt, "", false},
+		{"same second", updatedAt, lastModified, true},
+		{"client is newer", updatedAt, "Sat, 01 Mar 2025 10:00:00 GMT", true},
+		{"changed since", updatedAt, "Sat, 01 Mar 2025 08:59:59 GMT", false},
+		{"malformed header", updatedAt, "yesterday", false},
+		{"unknown update time", time.Time{}, lastModified, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+			if tt.ifModifiedSince != "" {
+				r.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+			w := httptest.NewRecorder()
+
+			if got := notModified(w, r, tt.updatedAt); got != tt.want {
+				t.Fatalf("notModified = %v, want %v", got, tt.want)
+			}
+			if tt.want && w.Code != http.StatusNotModified {
+				t.Errorf("status = %d, want 304", w.Code)
+			}
+			wantHeader := lastModified
+			if tt.updatedAt.IsZero() {
+				wantHeader = ""
+			}
+			if got := w.Header().Get("Last-Modified"); got != wantHeader {
+				t.Errorf("Last-Modified = %q, want %q", got, wantHeader)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/export"
+	"api_library/internal/usecase"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+var (
+	authorExportColumns = []string{"id", "first_name", "last_name", "biography", "birth_date"}
+	bookExportColumns   = []string{"id", "title", "year", "isbn", "author_id", "author_first_name", "author_last_name"}
+)
+
+type ExportHandler struct {
+	service usecase.Service
+}
+
+func NewExportHandler(service usecase.Service) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// HandleExportAuthors - выгрузка авторов: /export/authors?name=толст&format=xlsx
+func (h *ExportHandler) HandleExportAuthors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleExportAuthors"))
+		return
+	}
+	format, ok := h.negotiate(w, r)
+	if !ok {
+		return
+	}
+	filter := authorFilterFromQuery(r.URL.Query())
+
+	h.stream(w, r, format, "authors", authorExportColumns, func(enc export.Encoder) error {
+		return h.service.ExportAuthors(r.Context(), filter, func(author entity.Author) error {
+			return enc.Encode(export.Row{
+				Object: author,
+				Cells:  []interface{}{author.ID, author.FirstName, author.LastName, author.Biography, formatExportDate(author.BirthDate)},
+			})
+		})
+	})
+}
+
+// HandleExportBooks - выгрузка книг вместе с авторами; фильтры те же, что у GET /books
+func (h *ExportHandler) HandleExportBooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleExportBooks"))
+		return
+	}
+	format, ok := h.negotiate(w, r)
+	if !ok {
+		return
+	}
+	filter, err := bookFilterFromQuery(r.URL.Query())
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+
+	h.stream(w, r, format, "books", bookExportColumns, func(enc export.Encoder) error {
+		return h.service.ExportBooks(r.Context(), filter, func(book entity.BookRecord) error {
+			return enc.Encode(export.Row{
+				Object: book,
+				Cells:  []interface{}{book.ID, book.Title, book.Year, book.ISBN, book.AuthorID, book.Author.FirstName, book.Author.LastName},
+			})
+		})
+	})
+}
+
+// negotiate выбирает формат: параметр ?format= важнее заголовка Accept
+func (h *ExportHandler) negotiate(w http.ResponseWriter, r *http.Request) (export.Format, bool) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		format, ok := export.ByName(name)
+		if !ok {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "unknown format, expected csv, ndjson or xlsx", "negotiate"))
+		}
+		return format, ok
+	}
+	format, ok := export.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusNotAcceptable, "supported formats: text/csv, application/x-ndjson, "+export.XLSX.ContentType, "negotiate"))
+	}
+	return format, ok
+}
+
+// stream пишет выгрузку прямо в ответ. После начала передачи статус уже не изменить,
+// поэтому ошибка в середине только логируется, а ответ обрывается незавершённым.
+func (h *ExportHandler) stream(w http.ResponseWriter, r *http.Request, format export.Format, name string, columns []string, write func(enc export.Encoder) error) {
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.`+format.Extension+`"`)
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+
+	enc, err := export.NewEncoder(format, w, columns)
+	if err != nil {
+		log.Printf("Ошибка выгрузки %s: %v", name, err)
+		return
+	}
+	if err := write(enc); err != nil {
+		log.Printf("Ошибка выгрузки %s: %v", name, err)
+		return
+	}
+	if err := enc.Close(); err != nil {
+		log.Printf("Ошибка выгрузки %s: %v", name, err)
+	}
+}
+
+// formatExportDate - пустая строка для неизвестной даты вместо 0001-01-01
+func formatExportDate(d entity.Date) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format("2006-01-02")
+}
+
+func (h *ExportHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
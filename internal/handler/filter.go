@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// authorFilterFromQuery разбирает фильтры выгрузки авторов: ?name=толст
+func authorFilterFromQuery(query url.Values) entity.AuthorFilter {
+	return entity.AuthorFilter{Name: strings.TrimSpace(query.Get("name"))}
+}
+
+// bookFilterFromQuery разбирает фильтры выгрузки книг: ?author_id=1&year=1869&title=война&isbn=9785171183665
+func bookFilterFromQuery(query url.Values) (entity.BookFilter, error) {
+	filter := entity.BookFilter{
+		Title: strings.TrimSpace(query.Get("title")),
+		ISBN:  strings.TrimSpace(query.Get("isbn")),
+	}
+	if v := query.Get("author_id"); v != "" {
+		authorID, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.NewHTTPError(http.StatusBadRequest, "invalid author_id", "bookFilterFromQuery")
+		}
+		filter.AuthorID = authorID
+	}
+	if v := query.Get("year"); v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.NewHTTPError(http.StatusBadRequest, "invalid year", "bookFilterFromQuery")
+		}
+		filter.Year = year
+	}
+	return filter, nil
+}
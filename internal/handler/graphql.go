@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"api_library/internal/errors"
+	"api_library/internal/graphqlapi"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// максимальный размер тела запроса GraphQL
+const maxGraphQLBody = 1 << 20
+
+type GraphQLHandler struct {
+	executor *graphqlapi.Executor
+}
+
+func NewGraphQLHandler(executor *graphqlapi.Executor) *GraphQLHandler {
+	return &GraphQLHandler{executor: executor}
+}
+
+// HandleGraphQL - запросы GraphQL: POST с JSON {"query", "operationName", "variables"}
+// или с телом application/graphql; GET с теми же параметрами в строке запроса, только без мутаций.
+// Ошибки отдельных полей возвращаются в errors со статусом 200.
+func (h *GraphQLHandler) HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var request graphqlapi.Request
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		request.Query = query.Get("query")
+		request.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid variables", "HandleGraphQL"))
+				return
+			}
+		}
+	case http.MethodPost:
+		body := http.MaxBytesReader(w, r.Body, maxGraphQLBody)
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/graphql" {
+			query, err := io.ReadAll(body)
+			if err != nil {
+				h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid request body", "HandleGraphQL"))
+				return
+			}
+			request.Query = string(query)
+		} else if err := json.NewDecoder(body).Decode(&request); err != nil {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid data format", "HandleGraphQL"))
+			return
+		}
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleGraphQL"))
+		return
+	}
+
+	result, err := h.executor.Execute(r.Context(), request, r.Method == http.MethodPost)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// sendHTTPError отдаёт ошибку в формате ответа GraphQL, чтобы клиенты разбирали её так же, как ошибки полей
+func (h *GraphQLHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{"message": httpErr.Message}},
+	})
+}
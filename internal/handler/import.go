@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/export"
+	"api_library/internal/marc"
+	"api_library/internal/usecase"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// префикс параметров сопоставления колонок: ?map.title=Название
+const importMappingPrefix = "map."
+
+type ImportHandler struct {
+	importService usecase.ImportService
+	marcService   usecase.MARCService
+}
+
+func NewImportHandler(importService usecase.ImportService, marcService usecase.MARCService) *ImportHandler {
+	return &ImportHandler{importService: importService, marcService: marcService}
+}
+
+// HandleImport - массовый импорт из CSV: /import?type=books&dry_run=true&report=csv&map.title=Название
+func (h *ImportHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.importCSV(w, r)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleImport"))
+	}
+}
+
+func (h *ImportHandler) importCSV(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := entity.ImportOptions{
+		Entity:  query.Get("type"),
+		Mapping: make(map[string]string),
+	}
+	if v := query.Get("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid dry_run", "importCSV"))
+			return
+		}
+		opts.DryRun = dryRun
+	}
+	if v := query.Get("delimiter"); v != "" {
+		delimiter, size := utf8.DecodeRuneInString(v)
+		if size != len(v) || delimiter == '"' || delimiter == '\r' || delimiter == '\n' {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid delimiter", "importCSV"))
+			return
+		}
+		opts.Delimiter = delimiter
+	}
+	for key, values := range query {
+		if field, ok := strings.CutPrefix(key, importMappingPrefix); ok {
+			opts.Mapping[field] = values[0]
+		}
+	}
+
+	input, err := importInput(r)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+
+	result, err := h.importService.Import(r.Context(), input, opts)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+
+	if query.Get("report") == "csv" {
+		h.sendErrorReport(w, result)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// HandleImportMARC - импорт книг из MARC 21: ISO 2709 или MARCXML, формат определяется по содержимому.
+// /import/marc?dry_run=true
+func (h *ImportHandler) HandleImportMARC(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.importMARC(w, r)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleImportMARC"))
+	}
+}
+
+func (h *ImportHandler) importMARC(w http.ResponseWriter, r *http.Request) {
+	var dryRun bool
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid dry_run", "importMARC"))
+			return
+		}
+	}
+
+	input, err := importInput(r)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+
+	// MARCXML начинается с '<', запись ISO 2709 - с цифр длины записи
+	buffered := bufio.NewReader(input)
+	var records marc.RecordReader = marc.NewReader(buffered)
+	for {
+		b, err := buffered.Peek(1)
+		if err != nil {
+			break
+		}
+		if unicode.IsSpace(rune(b[0])) {
+			buffered.ReadByte()
+			continue
+		}
+		if b[0] == '<' || b[0] == 0xEF {
+			records = marc.NewXMLReader(buffered)
+		}
+		break
+	}
+
+	result, err := h.marcService.Import(r.Context(), records, dryRun)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// importInput возвращает импортируемые данные: тело запроса целиком или файл из multipart-формы (поле file).
+// Файл читается из multipart напрямую, без сохранения на диск.
+func importInput(r *http.Request) (io.Reader, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.NewHTTPError(http.StatusBadRequest, "invalid multipart body", "importCSV")
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, errors.NewHTTPError(http.StatusBadRequest, "file field is required", "importCSV")
+		}
+		if err != nil {
+			return nil, errors.NewHTTPError(http.StatusBadRequest, "invalid multipart body", "importCSV")
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+// sendErrorReport отдаёт отклонённые строки как CSV-файл: номер строки, колонка, причина и исходные значения.
+// Итоги импорта передаются в заголовках X-Import-*. Ячейки экранируются как в выгрузке: отчёт открывают
+// в табличном редакторе, а исходные значения пришли от загрузившего файл.
+func (h *ImportHandler) sendErrorReport(w http.ResponseWriter, result entity.ImportResult) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="import-`+result.Entity+`-errors.csv"`)
+	w.Header().Set("X-Import-Total", strconv.Itoa(result.Total))
+	w.Header().Set("X-Import-Imported", strconv.Itoa(result.Imported))
+	w.Header().Set("X-Import-Rejected", strconv.Itoa(result.Rejected))
+	w.Header().Set("X-Import-Dry-Run", strconv.FormatBool(result.DryRun))
+	w.Header().Set("X-Import-Errors-Omitted", strconv.Itoa(result.ErrorsOmitted))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(escapeCells(append([]string{"line", "column", "error"}, result.Header...)))
+	for _, rowErr := range result.Errors {
+		writer.Write(escapeCells(append([]string{strconv.Itoa(rowErr.Line), rowErr.Column, rowErr.Message}, rowErr.Record...)))
+	}
+	writer.Flush()
+}
+
+func escapeCells(cells []string) []string {
+	for i, cell := range cells {
+		cells[i] = export.EscapeFormula(cell)
+	}
+	return cells
+}
+
+func (h *ImportHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
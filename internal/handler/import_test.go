@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/usecase"
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeImportService struct {
+	usecase.ImportService
+	result entity.ImportResult
+}
+
+func (f *fakeImportService) Import(ctx context.Context, input io.Reader, opts entity.ImportOptions) (entity.ImportResult, error) {
+	return f.result, nil
+}
+
+// значения из загруженного файла возвращаются в отчёте и не должны выполняться как формулы
+func TestErrorReportEscaped(t *testing.T) {
+	service := &fakeImportService{result: entity.ImportResult{
+		Entity:        entity.ImportBooks,
+		Rejected:      3,
+		ErrorsOmitted: 2,
+		Header:        []string{"title", "=author"},
+		Errors: []entity.ImportRowError{
+			{Line: 2, Column: "=author", Message: "expected integer", Record: []string{"=HYPERLINK(\"http://x\")", "-1"}},
+		},
+	}}
+	h := NewImportHandler(service, nil)
+	w := httptest.NewRecorder()
+	h.HandleImport(w, httptest.NewRequest(http.MethodPost, "/import?type=books&report=csv", strings.NewReader("")))
+
+	if w.Code != http.StatusOK || w.Header().Get("X-Import-Errors-Omitted") != "2" {
+		t.Fatalf("status %d, X-Import-Errors-Omitted %q", w.Code, w.Header().Get("X-Import-Errors-Omitted"))
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"line", "column", "error", "title", "'=author"},
+		{"2", "'=author", "expected integer", "'=HYPERLINK(\"http://x\")", "'-1"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("report = %q, want %q", records, want)
+	}
+}
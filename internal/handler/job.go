@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"api_library/internal/errors"
+	"api_library/internal/scheduler"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const defaultJobHistoryLimit = 20
+
+type JobHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewJobHandler(scheduler *scheduler.Scheduler) *JobHandler {
+	return &JobHandler{scheduler: scheduler}
+}
+
+func (h *JobHandler) HandleJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getJobs(w, r)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleJobs"))
+	}
+}
+
+// HandleJob обслуживает /admin/jobs/{name} (история запусков) и /admin/jobs/{name}/run (ручной запуск)
+func (h *JobHandler) HandleJob(w http.ResponseWriter, r *http.Request) {
+	urlPathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/jobs/"), "/")
+	jobName := urlPathSegments[0]
+	if jobName == "" || len(urlPathSegments) > 2 {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusNotFound, "not found", "HandleJob"))
+		return
+	}
+
+	if len(urlPathSegments) == 2 {
+		if urlPathSegments[1] != "run" {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusNotFound, "not found", "HandleJob"))
+			return
+		}
+		if r.Method != http.MethodPost {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleJob"))
+			return
+		}
+		h.triggerJob(w, r, jobName)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getJobHistory(w, r, jobName)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleJob"))
+	}
+}
+
+func (h *JobHandler) getJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.scheduler.Jobs(r.Context())
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(jobs)
+}
+
+func (h *JobHandler) getJobHistory(w http.ResponseWriter, r *http.Request, jobName string) {
+	limit := defaultJobHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid limit", "getJobHistory"))
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.scheduler.History(r.Context(), jobName, limit)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(runs)
+}
+
+func (h *JobHandler) triggerJob(w http.ResponseWriter, r *http.Request, jobName string) {
+	run, err := h.scheduler.Trigger(r.Context(), jobName)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	// задача выполняется в фоне, итог появится в истории запусков
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/admin/jobs/"+url.PathEscape(jobName))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(run)
+}
+
+func (h *JobHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
@@ -0,0 +1,557 @@
+package handler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/usecase"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	oaiNamespace      = "http://www.openarchives.org/OAI/2.0/"
+	oaiSchemaLocation = "http://www.openarchives.org/OAI/2.0/ http://www.openarchives.org/OAI/2.0/OAI-PMH.xsd"
+	oaiDCNamespace    = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	oaiDCSchema       = "http://www.openarchives.org/OAI/2.0/oai_dc.xsd"
+	dcNamespace       = "http://purl.org/dc/elements/1.1/"
+	xsiNamespace      = "http://www.w3.org/2001/XMLSchema-instance"
+
+	oaiMetadataPrefixDC = "oai_dc"
+	oaiDatestampFormat  = "2006-01-02T15:04:05Z"
+	oaiDayFormat        = "2006-01-02"
+	// записей на одной странице ListIdentifiers / ListRecords
+	oaiPageSize = 100
+)
+
+// OAIConfig - сведения о репозитории для ответа Identify
+type OAIConfig struct {
+	RepositoryName string
+	// часть идентификаторов записей: oai:<RepositoryIdentifier>:book/<id>
+	RepositoryIdentifier string
+	AdminEmail           string
+	// внешний адрес /oai; если не задан, определяется по запросу
+	BaseURL string
+}
+
+// OAIConfigFromEnv читает OAI_REPOSITORY_NAME, OAI_REPOSITORY_IDENTIFIER, OAI_ADMIN_EMAIL и OAI_BASE_URL
+func OAIConfigFromEnv() OAIConfig {
+	config := OAIConfig{
+		RepositoryName:       os.Getenv("OAI_REPOSITORY_NAME"),
+		RepositoryIdentifier: os.Getenv("OAI_REPOSITORY_IDENTIFIER"),
+		AdminEmail:           os.Getenv("OAI_ADMIN_EMAIL"),
+		BaseURL:              os.Getenv("OAI_BASE_URL"),
+	}
+	if config.RepositoryName == "" {
+		config.RepositoryName = "api_library"
+	}
+	if config.RepositoryIdentifier == "" {
+		config.RepositoryIdentifier = "api-library.local"
+	}
+	if config.AdminEmail == "" {
+		config.AdminEmail = "admin@" + config.RepositoryIdentifier
+	}
+	return config
+}
+
+// допустимые аргументы каждого глагола; true - обязательный
+var oaiVerbArguments = map[string]map[string]bool{
+	"Identify":            {},
+	"ListMetadataFormats": {"identifier": false},
+	"ListSets":            {"resumptionToken": false},
+	"GetRecord":           {"identifier": true, "metadataPrefix": true},
+	"ListIdentifiers":     {"metadataPrefix": true, "from": false, "until": false, "set": false, "resumptionToken": false},
+	"ListRecords":         {"metadataPrefix": true, "from": false, "until": false, "set": false, "resumptionToken": false},
+}
+
+type oaiResponse struct {
+	XMLName             xml.Name                `xml:"OAI-PMH"`
+	Xmlns               string                  `xml:"xmlns,attr"`
+	XmlnsXsi            string                  `xml:"xmlns:xsi,attr"`
+	SchemaLocation      string                  `xml:"xsi:schemaLocation,attr"`
+	ResponseDate        string                  `xml:"responseDate"`
+	Request             oaiRequest              `xml:"request"`
+	Errors              []oaiError              `xml:"error,omitempty"`
+	Identify            *oaiIdentify            `xml:"Identify,omitempty"`
+	ListMetadataFormats *oaiListMetadataFormats `xml:"ListMetadataFormats,omitempty"`
+	GetRecord           *oaiGetRecord           `xml:"GetRecord,omitempty"`
+	ListIdentifiers     *oaiListIdentifiers     `xml:"ListIdentifiers,omitempty"`
+	ListRecords         *oaiListRecords         `xml:"ListRecords,omitempty"`
+}
+
+type oaiRequest struct {
+	Verb            string `xml:"verb,attr,omitempty"`
+	Identifier      string `xml:"identifier,attr,omitempty"`
+	MetadataPrefix  string `xml:"metadataPrefix,attr,omitempty"`
+	From            string `xml:"from,attr,omitempty"`
+	Until           string `xml:"until,attr,omitempty"`
+	Set             string `xml:"set,attr,omitempty"`
+	ResumptionToken string `xml:"resumptionToken,attr,omitempty"`
+	BaseURL         string `xml:",chardata"`
+}
+
+type oaiError struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+type oaiIdentify struct {
+	RepositoryName    string `xml:"repositoryName"`
+	BaseURL           string `xml:"baseURL"`
+	ProtocolVersion   string `xml:"protocolVersion"`
+	AdminEmail        string `xml:"adminEmail"`
+	EarliestDatestamp string `xml:"earliestDatestamp"`
+	DeletedRecord     string `xml:"deletedRecord"`
+	Granularity       string `xml:"granularity"`
+}
+
+type oaiListMetadataFormats struct {
+	Formats []oaiMetadataFormat `xml:"metadataFormat"`
+}
+
+type oaiMetadataFormat struct {
+	MetadataPrefix    string `xml:"metadataPrefix"`
+	Schema            string `xml:"schema"`
+	MetadataNamespace string `xml:"metadataNamespace"`
+}
+
+type oaiHeader struct {
+	Status     string `xml:"status,attr,omitempty"`
+	Identifier string `xml:"identifier"`
+	Datestamp  string `xml:"datestamp"`
+}
+
+type oaiRecord struct {
+	Header   oaiHeader    `xml:"header"`
+	Metadata *oaiMetadata `xml:"metadata,omitempty"`
+}
+
+type oaiMetadata struct {
+	DC oaiDC `xml:"oai_dc:dc"`
+}
+
+type oaiDC struct {
+	XmlnsOAIDC     string   `xml:"xmlns:oai_dc,attr"`
+	XmlnsDC        string   `xml:"xmlns:dc,attr"`
+	XmlnsXsi       string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	Title          []string `xml:"dc:title"`
+	Creator        []string `xml:"dc:creator"`
+	Date           []string `xml:"dc:date"`
+	Type           []string `xml:"dc:type"`
+	Identifier     []string `xml:"dc:identifier"`
+}
+
+type oaiGetRecord struct {
+	Record oaiRecord `xml:"record"`
+}
+
+type oaiListIdentifiers struct {
+	Headers         []oaiHeader         `xml:"header"`
+	ResumptionToken *oaiResumptionToken `xml:"resumptionToken,omitempty"`
+}
+
+type oaiListRecords struct {
+	Records         []oaiRecord         `xml:"record"`
+	ResumptionToken *oaiResumptionToken `xml:"resumptionToken,omitempty"`
+}
+
+// пустой токен в последней странице сообщает сборщику, что список закончен
+type oaiResumptionToken struct {
+	Value string `xml:",chardata"`
+}
+
+// oaiToken - состояние постраничной выдачи: исходные аргументы и последняя отданная запись
+type oaiToken struct {
+	MetadataPrefix string `json:"p"`
+	From           string `json:"f,omitempty"`
+	Until          string `json:"u,omitempty"`
+	Datestamp      int64  `json:"t"`
+	ID             int    `json:"i"`
+}
+
+type OAIHandler struct {
+	oaiService usecase.OAIService
+	config     OAIConfig
+}
+
+func NewOAIHandler(oaiService usecase.OAIService, config OAIConfig) *OAIHandler {
+	return &OAIHandler{oaiService: oaiService, config: config}
+}
+
+// HandleOAI - провайдер OAI-PMH 2.0; ошибки протокола возвращаются в теле ответа со статусом 200
+func (h *OAIHandler) HandleOAI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleOAI"))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid request", "HandleOAI"))
+		return
+	}
+
+	response := &oaiResponse{
+		Xmlns:          oaiNamespace,
+		XmlnsXsi:       xsiNamespace,
+		SchemaLocation: oaiSchemaLocation,
+		ResponseDate:   time.Now().UTC().Format(oaiDatestampFormat),
+		Request:        oaiRequest{BaseURL: h.baseURL(r)},
+	}
+
+	args, oaiErr := h.parseArguments(r)
+	if oaiErr != nil {
+		// при badVerb и badArgument атрибуты request не указываются
+		response.Errors = []oaiError{*oaiErr}
+		h.sendXML(w, response)
+		return
+	}
+	response.Request.Verb = args["verb"]
+	response.Request.Identifier = args["identifier"]
+	response.Request.MetadataPrefix = args["metadataPrefix"]
+	response.Request.From = args["from"]
+	response.Request.Until = args["until"]
+	response.Request.Set = args["set"]
+	response.Request.ResumptionToken = args["resumptionToken"]
+
+	var err error
+	switch args["verb"] {
+	case "Identify":
+		err = h.identify(r, response)
+	case "ListMetadataFormats":
+		err = h.listMetadataFormats(r, args, response)
+	case "ListSets":
+		response.Errors = []oaiError{{"noSetHierarchy", "This repository does not support sets"}}
+	case "GetRecord":
+		err = h.getRecord(r, args, response)
+	case "ListIdentifiers", "ListRecords":
+		err = h.listRecords(r, args, response)
+	}
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	for _, e := range response.Errors {
+		if e.Code == "badArgument" {
+			response.Request = oaiRequest{BaseURL: response.Request.BaseURL}
+		}
+	}
+	h.sendXML(w, response)
+}
+
+// parseArguments проверяет глагол и набор аргументов: неизвестные, повторённые и недостающие аргументы - badArgument
+func (h *OAIHandler) parseArguments(r *http.Request) (map[string]string, *oaiError) {
+	verbs := r.Form["verb"]
+	if len(verbs) != 1 {
+		return nil, &oaiError{"badVerb", "Exactly one verb argument is required"}
+	}
+	allowed, ok := oaiVerbArguments[verbs[0]]
+	if !ok {
+		return nil, &oaiError{"badVerb", "Illegal verb"}
+	}
+
+	args := map[string]string{"verb": verbs[0]}
+	for name, values := range r.Form {
+		if name == "verb" {
+			continue
+		}
+		if _, ok := allowed[name]; !ok {
+			return nil, &oaiError{"badArgument", "Illegal argument: " + name}
+		}
+		if len(values) != 1 {
+			return nil, &oaiError{"badArgument", "Repeated argument: " + name}
+		}
+		args[name] = values[0]
+	}
+
+	// resumptionToken - исключительный аргумент
+	if _, ok := args["resumptionToken"]; ok {
+		if len(args) != 2 {
+			return nil, &oaiError{"badArgument", "resumptionToken is an exclusive argument"}
+		}
+		return args, nil
+	}
+	for name, required := range allowed {
+		if _, ok := args[name]; required && !ok {
+			return nil, &oaiError{"badArgument", "Missing required argument: " + name}
+		}
+	}
+	return args, nil
+}
+
+func (h *OAIHandler) identify(r *http.Request, response *oaiResponse) error {
+	earliest, err := h.oaiService.GetEarliestDatestamp(r.Context())
+	if err != nil {
+		return err
+	}
+	response.Identify = &oaiIdentify{
+		RepositoryName:    h.config.RepositoryName,
+		BaseURL:           response.Request.BaseURL,
+		ProtocolVersion:   "2.0",
+		AdminEmail:        h.config.AdminEmail,
+		EarliestDatestamp: earliest.UTC().Format(oaiDatestampFormat),
+		// удалённые книги видны, пока лежат в корзине
+		DeletedRecord: "transient",
+		Granularity:   "YYYY-MM-DDThh:mm:ssZ",
+	}
+	return nil
+}
+
+func (h *OAIHandler) listMetadataFormats(r *http.Request, args map[string]string, response *oaiResponse) error {
+	if identifier, ok := args["identifier"]; ok {
+		bookID, ok := h.parseIdentifier(identifier)
+		if !ok {
+			response.Errors = []oaiError{{"idDoesNotExist", "Unknown identifier"}}
+			return nil
+		}
+		if _, err := h.oaiService.GetRecord(r.Context(), bookID); err == errors.ErrNotFound {
+			response.Errors = []oaiError{{"idDoesNotExist", "Unknown identifier"}}
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	response.ListMetadataFormats = &oaiListMetadataFormats{Formats: []oaiMetadataFormat{{
+		MetadataPrefix:    oaiMetadataPrefixDC,
+		Schema:            oaiDCSchema,
+		MetadataNamespace: oaiDCNamespace,
+	}}}
+	return nil
+}
+
+func (h *OAIHandler) getRecord(r *http.Request, args map[string]string, response *oaiResponse) error {
+	if args["metadataPrefix"] != oaiMetadataPrefixDC {
+		response.Errors = []oaiError{{"cannotDisseminateFormat", "Only oai_dc is supported"}}
+		return nil
+	}
+	bookID, ok := h.parseIdentifier(args["identifier"])
+	if !ok {
+		response.Errors = []oaiError{{"idDoesNotExist", "Unknown identifier"}}
+		return nil
+	}
+	record, err := h.oaiService.GetRecord(r.Context(), bookID)
+	if err == errors.ErrNotFound {
+		response.Errors = []oaiError{{"idDoesNotExist", "Unknown identifier"}}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	response.GetRecord = &oaiGetRecord{Record: h.record(record)}
+	return nil
+}
+
+// listRecords обслуживает ListIdentifiers и ListRecords: отличаются только наличием metadata
+func (h *OAIHandler) listRecords(r *http.Request, args map[string]string, response *oaiResponse) error {
+	var token oaiToken
+	filter := entity.OAIFilter{Limit: oaiPageSize + 1}
+	resumed := false
+
+	if raw, ok := args["resumptionToken"]; ok {
+		decoded, ok := decodeOAIToken(raw)
+		if !ok {
+			response.Errors = []oaiError{{"badResumptionToken", "Invalid resumption token"}}
+			return nil
+		}
+		token, resumed = decoded, true
+		after := time.Unix(0, token.Datestamp)
+		filter.AfterDatestamp, filter.AfterID = &after, token.ID
+	} else {
+		token = oaiToken{MetadataPrefix: args["metadataPrefix"], From: args["from"], Until: args["until"]}
+		if _, ok := args["set"]; ok {
+			response.Errors = []oaiError{{"noSetHierarchy", "This repository does not support sets"}}
+			return nil
+		}
+	}
+
+	if token.MetadataPrefix != oaiMetadataPrefixDC {
+		response.Errors = []oaiError{{"cannotDisseminateFormat", "Only oai_dc is supported"}}
+		return nil
+	}
+	from, until, oaiErr := parseOAIRange(token.From, token.Until)
+	if oaiErr != nil {
+		response.Errors = []oaiError{*oaiErr}
+		return nil
+	}
+	filter.From, filter.Until = from, until
+
+	records, err := h.oaiService.GetRecords(r.Context(), filter)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 && !resumed {
+		response.Errors = []oaiError{{"noRecordsMatch", "No records match the request"}}
+		return nil
+	}
+
+	var resumption *oaiResumptionToken
+	if len(records) > oaiPageSize {
+		records = records[:oaiPageSize]
+		last := records[len(records)-1]
+		token.Datestamp, token.ID = last.Datestamp.UnixNano(), last.ID
+		resumption = &oaiResumptionToken{Value: encodeOAIToken(token)}
+	} else if resumed {
+		resumption = &oaiResumptionToken{}
+	}
+
+	if args["verb"] == "ListIdentifiers" {
+		list := &oaiListIdentifiers{ResumptionToken: resumption}
+		for _, record := range records {
+			list.Headers = append(list.Headers, h.header(record))
+		}
+		response.ListIdentifiers = list
+		return nil
+	}
+	list := &oaiListRecords{ResumptionToken: resumption}
+	for _, record := range records {
+		list.Records = append(list.Records, h.record(record))
+	}
+	response.ListRecords = list
+	return nil
+}
+
+func (h *OAIHandler) header(record entity.OAIRecord) oaiHeader {
+	header := oaiHeader{
+		Identifier: h.identifier(record.ID),
+		Datestamp:  record.Datestamp.UTC().Format(oaiDatestampFormat),
+	}
+	if record.Deleted {
+		header.Status = "deleted"
+	}
+	return header
+}
+
+// record - заголовок и Dublin Core; у удалённых записей метаданных нет
+func (h *OAIHandler) record(record entity.OAIRecord) oaiRecord {
+	result := oaiRecord{Header: h.header(record)}
+	if record.Deleted {
+		return result
+	}
+
+	dc := oaiDC{
+		XmlnsOAIDC:     oaiDCNamespace,
+		XmlnsDC:        dcNamespace,
+		XmlnsXsi:       xsiNamespace,
+		SchemaLocation: oaiDCNamespace + " " + oaiDCSchema,
+		Title:          []string{record.Title},
+		Type:           []string{"Text"},
+	}
+	switch {
+	case record.Author.LastName != "" && record.Author.FirstName != "":
+		dc.Creator = []string{record.Author.LastName + ", " + record.Author.FirstName}
+	case record.Author.LastName != "" || record.Author.FirstName != "":
+		dc.Creator = []string{record.Author.LastName + record.Author.FirstName}
+	}
+	if record.Year != 0 {
+		dc.Date = []string{strconv.Itoa(record.Year)}
+	}
+	if record.ISBN != "" {
+		dc.Identifier = []string{"urn:isbn:" + record.ISBN}
+	}
+	result.Metadata = &oaiMetadata{DC: dc}
+	return result
+}
+
+func (h *OAIHandler) identifier(bookID int) string {
+	return "oai:" + h.config.RepositoryIdentifier + ":book/" + strconv.Itoa(bookID)
+}
+
+func (h *OAIHandler) parseIdentifier(identifier string) (int, bool) {
+	id, ok := strings.CutPrefix(identifier, "oai:"+h.config.RepositoryIdentifier+":book/")
+	if !ok {
+		return 0, false
+	}
+	bookID, err := strconv.Atoi(id)
+	return bookID, err == nil && bookID > 0
+}
+
+func (h *OAIHandler) baseURL(r *http.Request) string {
+	if h.config.BaseURL != "" {
+		return h.config.BaseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+// parseOAIRange разбирает from/until с точностью до дня или до секунды; у обоих точность должна совпадать.
+// until с точностью до дня включает весь день.
+func parseOAIRange(fromArg, untilArg string) (*time.Time, *time.Time, *oaiError) {
+	parse := func(value string) (time.Time, bool, bool) {
+		if t, err := time.Parse(oaiDatestampFormat, value); err == nil {
+			return t, false, true
+		}
+		if t, err := time.Parse(oaiDayFormat, value); err == nil {
+			return t, true, true
+		}
+		return time.Time{}, false, false
+	}
+
+	var from, until *time.Time
+	var fromDay, untilDay bool
+	if fromArg != "" {
+		t, day, ok := parse(fromArg)
+		if !ok {
+			return nil, nil, &oaiError{"badArgument", "Invalid from date"}
+		}
+		from, fromDay = &t, day
+	}
+	if untilArg != "" {
+		t, day, ok := parse(untilArg)
+		if !ok {
+			return nil, nil, &oaiError{"badArgument", "Invalid until date"}
+		}
+		if day {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		until, untilDay = &t, day
+	}
+	if from != nil && until != nil {
+		if fromDay != untilDay {
+			return nil, nil, &oaiError{"badArgument", "from and until must have the same granularity"}
+		}
+		if from.After(*until) {
+			return nil, nil, &oaiError{"badArgument", "from must not be later than until"}
+		}
+	}
+	return from, until, nil
+}
+
+func encodeOAIToken(token oaiToken) string {
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeOAIToken(raw string) (oaiToken, bool) {
+	var token oaiToken
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return token, false
+	}
+	if err := json.Unmarshal(data, &token); err != nil || token.Datestamp == 0 || token.ID == 0 {
+		return token, false
+	}
+	return token, true
+}
+
+func (h *OAIHandler) sendXML(w http.ResponseWriter, response *oaiResponse) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(response)
+}
+
+func (h *OAIHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
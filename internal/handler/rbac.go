@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/usecase"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type RBACHandler struct {
+	rbacService usecase.RBACService
+}
+
+func NewRBACHandler(rbacService usecase.RBACService) *RBACHandler {
+	return &RBACHandler{rbacService: rbacService}
+}
+
+func (h *RBACHandler) HandleRoles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getRoles(w, r)
+	case http.MethodPost:
+		h.createRole(w, r)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleRoles"))
+	}
+}
+
+func (h *RBACHandler) HandleRole(w http.ResponseWriter, r *http.Request) {
+	roleName := strings.TrimPrefix(r.URL.Path, "/admin/roles/")
+	if roleName == "" || strings.Contains(roleName, "/") {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid role name", "HandleRole"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getRole(w, r, roleName)
+	case http.MethodPut:
+		h.updateRole(w, r, roleName)
+	case http.MethodDelete:
+		h.deleteRole(w, r, roleName)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleRole"))
+	}
+}
+
+// HandleUserRoles обслуживает /admin/users/{id}/roles
+func (h *RBACHandler) HandleUserRoles(w http.ResponseWriter, r *http.Request) {
+	urlPathSegments := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/users/"), "/")
+	if len(urlPathSegments) != 2 || urlPathSegments[1] != "roles" {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusNotFound, "not found", "HandleUserRoles"))
+		return
+	}
+	userID, err := strconv.Atoi(urlPathSegments[0])
+	if err != nil {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "invalid user ID", "HandleUserRoles"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.getUserRoles(w, r, userID)
+	case http.MethodPut:
+		h.setUserRoles(w, r, userID)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleUserRoles"))
+	}
+}
+
+func (h *RBACHandler) getRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.rbacService.GetRoles(r.Context())
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(roles)
+}
+
+func (h *RBACHandler) getRole(w http.ResponseWriter, r *http.Request, roleName string) {
+	role, err := h.rbacService.GetRole(r.Context(), roleName)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(role)
+}
+
+func (h *RBACHandler) createRole(w http.ResponseWriter, r *http.Request) {
+	var role entity.Role
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, err.Error(), "createRole"))
+		return
+	}
+
+	if err := h.rbacService.CreateRole(r.Context(), role); err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, "Role created: %s", role.Name)
+}
+
+func (h *RBACHandler) updateRole(w http.ResponseWriter, r *http.Request, roleName string) {
+	var role entity.Role
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, err.Error(), "updateRole"))
+		return
+	}
+	role.Name = roleName
+
+	if err := h.rbacService.UpdateRole(r.Context(), role); err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Role updated: %s", roleName)
+}
+
+func (h *RBACHandler) deleteRole(w http.ResponseWriter, r *http.Request, roleName string) {
+	if err := h.rbacService.DeleteRole(r.Context(), roleName); err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Role deleted: %s", roleName)
+}
+
+func (h *RBACHandler) getUserRoles(w http.ResponseWriter, r *http.Request, userID int) {
+	userRoles, err := h.rbacService.GetUserRoles(r.Context(), userID)
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(userRoles)
+}
+
+func (h *RBACHandler) setUserRoles(w http.ResponseWriter, r *http.Request, userID int) {
+	var userRoles entity.UserRoles
+	if err := json.NewDecoder(r.Body).Decode(&userRoles); err != nil {
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, err.Error(), "setUserRoles"))
+		return
+	}
+
+	if err := h.rbacService.SetUserRoles(r.Context(), userID, userRoles.Roles); err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Roles updated for user with ID: %d", userID)
+}
+
+func (h *RBACHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"api_library/internal/errors"
+	"api_library/internal/usecase"
+	"encoding/json"
+	"net/http"
+)
+
+type TrashHandler struct {
+	service usecase.Service
+}
+
+func NewTrashHandler(service usecase.Service) *TrashHandler {
+	return &TrashHandler{service: service}
+}
+
+// HandleTrash - удалённые авторы и книги, которые ещё можно восстановить
+func (h *TrashHandler) HandleTrash(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.getTrash(w, r)
+	default:
+		h.sendHTTPError(w, errors.NewHTTPError(http.StatusMethodNotAllowed, "method not supported", "HandleTrash"))
+	}
+}
+
+func (h *TrashHandler) getTrash(w http.ResponseWriter, r *http.Request) {
+	trash, err := h.service.GetTrash(r.Context())
+	if err != nil {
+		h.sendHTTPError(w, errors.MapErrorToHTTP(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trash)
+}
+
+func (h *TrashHandler) sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
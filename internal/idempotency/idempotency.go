@@ -0,0 +1,223 @@
+// Package idempotency позволяет клиентам безопасно повторять POST-запросы:
+// ответ на запрос с заголовком Idempotency-Key сохраняется и возвращается на повторы.
+package idempotency
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/repository"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	Header = "Idempotency-Key"
+	// заголовок, которым помечается повторно отданный сохранённый ответ
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	// максимальный размер тела запроса, который сохраняется для сравнения
+	maxBodySize = 10 << 20
+	// на сколько выполняющийся запрос занимает ключ; пока запрос идёт, занятость продлевается каждую треть срока.
+	// Если процесс упал посреди запроса, повтор сможет выполнить его не раньше чем через этот срок.
+	defaultLockLease = 30 * time.Second
+)
+
+// заголовки ответа, которые сохраняются вместе с телом
+var storedHeaders = []string{"Content-Type", "Location"}
+
+type Middleware struct {
+	repo  repository.IdempotencyRepository
+	ttl   time.Duration
+	lease time.Duration
+}
+
+// ttl - сколько хранится ответ; после истечения ключ можно использовать заново
+func NewMiddleware(repo repository.IdempotencyRepository, ttl time.Duration) *Middleware {
+	return &Middleware{repo: repo, ttl: ttl, lease: defaultLockLease}
+}
+
+// Wrap обрабатывает POST-запросы с Idempotency-Key. Ключ действует в пределах пользователя.
+// Повтор с тем же телом получает сохранённый ответ, с другим телом - 422,
+// повтор до завершения первого запроса - 409. Ответы 5xx не сохраняются, такой запрос можно повторить.
+// Если запрос оборвался вместе с процессом, ключ освобождается после истечения занятости (lease).
+func (m *Middleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if r.Method != http.MethodPost || key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, Header+" is longer than "+strconv.Itoa(maxKeyLength)+" characters", "idempotency"))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			if _, ok := err.(*http.MaxBytesError); ok {
+				sendHTTPError(w, errors.NewHTTPError(http.StatusRequestEntityTooLarge, "request body is too large", "idempotency"))
+				return
+			}
+			sendHTTPError(w, errors.NewHTTPError(http.StatusBadRequest, "failed to read request body", "idempotency"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := entity.IdempotencyRecord{
+			Scope:       scope(r.Context()),
+			Key:         key,
+			Fingerprint: fingerprint(r, body),
+			ExpiresAt:   time.Now().Add(m.ttl),
+			LockedUntil: time.Now().Add(m.lease),
+		}
+		existing, reserved, err := m.repo.ReserveIdempotencyKey(r.Context(), record)
+		if err != nil {
+			sendHTTPError(w, errors.MapErrorToHTTP(err))
+			return
+		}
+		if !reserved {
+			m.replay(w, existing, record.Fingerprint)
+			return
+		}
+
+		// результат сохраняется и после обрыва соединения клиентом - иначе повтор выполнит запрос второй раз
+		ctx := context.WithoutCancel(r.Context())
+		recorder := &responseRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				if err := m.repo.ReleaseIdempotencyKey(ctx, record.Scope, record.Key); err != nil {
+					log.Printf("Не удалось освободить ключ идемпотентности %q: %v", record.Key, err)
+				}
+			}
+		}()
+		defer m.keepLocked(ctx, record)()
+
+		next(recorder, r)
+
+		if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+			return
+		}
+		record.Status = recorder.status
+		record.Headers = make(map[string][]string)
+		for _, name := range storedHeaders {
+			if values := w.Header().Values(name); len(values) > 0 {
+				record.Headers[name] = values
+			}
+		}
+		record.Body = recorder.body.Bytes()
+		if err := m.repo.CompleteIdempotencyKey(ctx, record); err != nil {
+			log.Printf("Не удалось сохранить ответ для ключа идемпотентности %q: %v", record.Key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+func (m *Middleware) replay(w http.ResponseWriter, existing entity.IdempotencyRecord, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		sendHTTPError(w, errors.NewHTTPError(http.StatusUnprocessableEntity, Header+" was already used with a different request", "idempotency"))
+		return
+	}
+	if existing.Status == 0 {
+		w.Header().Set("Retry-After", "1")
+		sendHTTPError(w, errors.NewHTTPError(http.StatusConflict, "request with this "+Header+" is still in progress", "idempotency"))
+		return
+	}
+	for name, values := range existing.Headers {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(existing.Status)
+	w.Write(existing.Body)
+}
+
+// keepLocked продлевает занятость ключа, пока выполняется запрос; возвращает функцию остановки
+func (m *Middleware) keepLocked(ctx context.Context, record entity.IdempotencyRecord) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(m.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := m.repo.ExtendIdempotencyKey(ctx, record.Scope, record.Key, time.Now().Add(m.lease)); err != nil {
+					log.Printf("Не удалось продлить ключ идемпотентности %q: %v", record.Key, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// Cleanup удаляет истёкшие ключи; предназначен для запуска из планировщика
+func (m *Middleware) Cleanup(ctx context.Context) error {
+	deleted, err := m.repo.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Удалено истёкших ключей идемпотентности: %d", deleted)
+	return nil
+}
+
+func scope(ctx context.Context) string {
+	if identity, ok := auth.FromContext(ctx); ok {
+		return "user:" + strconv.Itoa(identity.UserID)
+	}
+	return "anonymous"
+}
+
+// fingerprint - отпечаток запроса: метод, путь с параметрами и тело
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder передаёт ответ клиенту и одновременно запоминает его
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
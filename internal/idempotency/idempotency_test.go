@@ -0,0 +1,237 @@
+package idempotency
+
+import (
+	"api_library/internal/entity"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryRepository повторяет условия занятия ключа из SQL-реализации
+type memoryRepository struct {
+	mu      sync.Mutex
+	records map[string]*entity.IdempotencyRecord
+	extends int
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{records: make(map[string]*entity.IdempotencyRecord)}
+}
+
+func (m *memoryRepository) ReserveIdempotencyKey(ctx context.Context, record entity.IdempotencyRecord) (entity.IdempotencyRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	existing, ok := m.records[record.Scope+" "+record.Key]
+	if ok && existing.ExpiresAt.After(now) &&
+		!(existing.Status == 0 && existing.LockedUntil.Before(now) && existing.Fingerprint == record.Fingerprint) {
+		return *existing, false, nil
+	}
+	m.records[record.Scope+" "+record.Key] = &record
+	return record, true, nil
+}
+
+func (m *memoryRepository) ExtendIdempotencyKey(ctx context.Context, scope, key string, lockedUntil time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.extends++
+	if record, ok := m.records[scope+" "+key]; ok && record.Status == 0 {
+		record.LockedUntil = lockedUntil
+	}
+	return nil
+}
+
+func (m *memoryRepository) CompleteIdempotencyKey(ctx context.Context, record entity.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record.LockedUntil = time.Time{}
+	m.records[record.Scope+" "+record.Key] = &record
+	return nil
+}
+
+func (m *memoryRepository) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, scope+" "+key)
+	return nil
+}
+
+func (m *memoryRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// createHandler отвечает 201 с новым ID на каждый вызов
+func createHandler(calls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", fmt.Sprintf("/books/%d", id))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%d}`, id)
+	}
+}
+
+func post(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(Header, key)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestReplay(t *testing.T) {
+	var calls atomic.Int32
+	handler := NewMiddleware(newMemoryRepository(), time.Hour).Wrap(createHandler(&calls))
+
+	first := post(handler, "k1", `{"title":"A"}`)
+	second := post(handler, "k1", `{"title":"A"}`)
+	if calls.Load() != 1 {
+		t.Fatalf("handler called %d times, want 1", calls.Load())
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %q, want %d %q", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Location") != "/books/1" || second.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("replay headers = %v", second.Header())
+	}
+	if first.Header().Get(ReplayedHeader) != "" {
+		t.Error("first response marked as replayed")
+	}
+
+	// без ключа и с другим ключом запрос выполняется заново
+	post(handler, "", `{"title":"A"}`)
+	post(handler, "k2", `{"title":"A"}`)
+	if calls.Load() != 3 {
+		t.Errorf("handler called %d times, want 3", calls.Load())
+	}
+}
+
+func TestFingerprintMismatch(t *testing.T) {
+	var calls atomic.Int32
+	handler := NewMiddleware(newMemoryRepository(), time.Hour).Wrap(createHandler(&calls))
+
+	post(handler, "k", `{"title":"A"}`)
+	w := post(handler, "k", `{"title":"B"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status %d, want 422", w.Code)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/authors", strings.NewReader(`{"title":"A"}`))
+	r.Header.Set(Header, "k")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("other path: status %d, want 422", w.Code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler called %d times, want 1", calls.Load())
+	}
+}
+
+func TestConcurrentInProgress(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	handler := NewMiddleware(newMemoryRepository(), time.Hour).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		createHandler(&calls)(w, r)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(handler, "k", `{}`) }()
+	<-started
+
+	w := post(handler, "k", `{}`)
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Errorf("in-progress repeat: status %d, Retry-After %q; want 409 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	close(release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("first request: status %d", first.Code)
+	}
+	if w := post(handler, "k", `{}`); w.Code != http.StatusCreated || w.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("repeat after completion: status %d, replayed %q", w.Code, w.Header().Get(ReplayedHeader))
+	}
+}
+
+func TestServerErrorNotStored(t *testing.T) {
+	var calls atomic.Int32
+	handler := NewMiddleware(newMemoryRepository(), time.Hour).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	if w := post(handler, "k", `{}`); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first: status %d", w.Code)
+	}
+	w := post(handler, "k", `{}`)
+	if w.Code != http.StatusCreated || w.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("retry after 5xx: status %d, replayed %q; want a new execution", w.Code, w.Header().Get(ReplayedHeader))
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler called %d times, want 2", calls.Load())
+	}
+}
+
+// запрос, оборвавшийся вместе с процессом, оставляет ключ без ответа; после истечения занятости
+// его берёт повтор с тем же телом, а не ждёт истечения ключа
+func TestStaleLockTakenOver(t *testing.T) {
+	repo := newMemoryRepository()
+	repo.records["anonymous k"] = &entity.IdempotencyRecord{
+		Scope:       "anonymous",
+		Key:         "k",
+		Fingerprint: fingerprint(httptest.NewRequest(http.MethodPost, "/books", nil), []byte(`{}`)),
+		ExpiresAt:   time.Now().Add(time.Hour),
+		LockedUntil: time.Now().Add(-time.Second),
+	}
+	var calls atomic.Int32
+	handler := NewMiddleware(repo, time.Hour).Wrap(createHandler(&calls))
+
+	if w := post(handler, "k", `{"other":true}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body on stale key: status %d, want 422", w.Code)
+	}
+	if w := post(handler, "k", `{}`); w.Code != http.StatusCreated || calls.Load() != 1 {
+		t.Errorf("stale key: status %d, calls %d; want the request executed", w.Code, calls.Load())
+	}
+}
+
+func TestLockExtendedWhileRunning(t *testing.T) {
+	repo := newMemoryRepository()
+	m := NewMiddleware(repo, time.Hour)
+	m.lease = 30 * time.Millisecond
+
+	var calls atomic.Int32
+	handler := m.Wrap(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// второй запрос приходит, когда исходная занятость уже истекла бы без продления
+			if w := post(m.Wrap(createHandler(&calls)), "k", `{}`); w.Code != http.StatusConflict {
+				t.Errorf("repeat during a long request: status %d, want 409", w.Code)
+			}
+			time.Sleep(3 * m.lease)
+			if w := post(m.Wrap(createHandler(&calls)), "k", `{}`); w.Code != http.StatusConflict {
+				t.Errorf("repeat after the first lease: status %d, want 409", w.Code)
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	post(handler, "k", `{}`)
+	if calls.Load() != 1 {
+		t.Errorf("handler called %d times, want 1", calls.Load())
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.extends == 0 {
+		t.Error("lock not extended")
+	}
+}
@@ -0,0 +1,120 @@
+package marc
+
+import (
+	"api_library/internal/entity"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Соответствие полей MARC 21 и каталога:
+// 001 - ID книги, 020$a - ISBN, 100$a - основной автор («Фамилия, Имя»),
+// 245$a/$b - заглавие, 264$c (или устаревшее 260$c) - год издания.
+
+// FromBook строит запись MARC для книги; author может быть нулевым, если автор неизвестен
+func FromBook(book entity.Book, author entity.Author) Record {
+	record := Record{
+		Leader:        defaultLeader,
+		ControlFields: []ControlField{{Tag: "001", Value: strconv.Itoa(book.ID)}},
+	}
+	if book.ISBN != "" {
+		record.DataFields = append(record.DataFields, DataField{Tag: "020", Ind1: ' ', Ind2: ' ', Subfields: []Subfield{{'a', book.ISBN}}})
+	}
+
+	titleInd1 := byte('0')
+	if name := authorHeading(author); name != "" {
+		titleInd1 = '1'
+		record.DataFields = append(record.DataFields, DataField{Tag: "100", Ind1: '1', Ind2: ' ', Subfields: []Subfield{{'a', name}}})
+	}
+	record.DataFields = append(record.DataFields, DataField{Tag: "245", Ind1: titleInd1, Ind2: '0', Subfields: []Subfield{{'a', book.Title}}})
+
+	if book.Year != 0 {
+		record.DataFields = append(record.DataFields, DataField{Tag: "264", Ind1: ' ', Ind2: '1', Subfields: []Subfield{{'c', strconv.Itoa(book.Year)}}})
+	}
+	return record
+}
+
+// authorHeading - форма имени для 100$a: «Фамилия, Имя» или одно из них
+func authorHeading(author entity.Author) string {
+	switch {
+	case author.LastName != "" && author.FirstName != "":
+		return author.LastName + ", " + author.FirstName
+	case author.LastName != "":
+		return author.LastName
+	default:
+		return author.FirstName
+	}
+}
+
+// Book извлекает книгу и основного автора из записи. Поля не проверяются:
+// ISBN, год и автор могут оказаться пустыми, решение о пригодности записи принимает вызывающий.
+func (r Record) Book() (entity.Book, entity.Author) {
+	var book entity.Book
+	var author entity.Author
+
+	// «978-5-17-118366-5 (hardcover)» - берём только сам номер
+	if isbn := strings.Fields(r.Subfield("020", 'a')); len(isbn) > 0 {
+		book.ISBN = strings.ReplaceAll(isbn[0], "-", "")
+	}
+
+	title := trimPunctuation(r.Subfield("245", 'a'))
+	if subtitle := trimPunctuation(r.Subfield("245", 'b')); subtitle != "" {
+		title += ": " + subtitle
+	}
+	book.Title = title
+
+	year := publicationYear(r.Subfield("264", 'c'))
+	if year == 0 {
+		year = publicationYear(r.Subfield("260", 'c'))
+	}
+	if year == 0 {
+		// 008/07-10 - год издания в фиксированных полях
+		if f := r.ControlField("008"); len(f) >= 11 {
+			year = publicationYear(f[7:11])
+		}
+	}
+	book.Year = year
+
+	for _, f := range r.DataFields {
+		if f.Tag != "100" {
+			continue
+		}
+		name, _ := f.Subfield('a')
+		name = trimPunctuation(name)
+		// первый индикатор 1 - «Фамилия, Имя», 0 - только личное имя
+		if f.Ind1 == '0' {
+			author.FirstName = name
+		} else if last, first, ok := strings.Cut(name, ","); ok {
+			author.LastName = strings.TrimSpace(last)
+			author.FirstName = trimPunctuation(first)
+		} else {
+			author.LastName = name
+		}
+		break
+	}
+	return book, author
+}
+
+// trimPunctuation убирает конечную пунктуацию ISBD: «Война и мир /» -> «Война и мир»
+func trimPunctuation(s string) string {
+	return strings.TrimRightFunc(strings.TrimSpace(s), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("/:;,.=", r)
+	})
+}
+
+// publicationYear находит первый четырёхзначный год: «©2019.», «[1869]», «1869-1870»
+func publicationYear(s string) int {
+	run := 0
+	for i, r := range s {
+		if r >= '0' && r <= '9' {
+			run++
+			if run == 4 && (i+1 == len(s) || s[i+1] < '0' || s[i+1] > '9') {
+				year, _ := strconv.Atoi(s[i-3 : i+1])
+				return year
+			}
+			continue
+		}
+		run = 0
+	}
+	return 0
+}
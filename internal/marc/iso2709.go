@@ -0,0 +1,210 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+
+	leaderLength         = 24
+	directoryEntryLength = 12
+	// максимальная длина записи ISO 2709 - пять десятичных цифр
+	maxRecordLength = 99999
+)
+
+// Reader читает записи ISO 2709 из потока по одной
+type Reader struct {
+	r     *bufio.Reader
+	count int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read возвращает следующую запись или io.EOF. Ошибка *ParseError относится к одной записи,
+// после неё чтение можно продолжить; другие ошибки означают, что поток дальше не разобрать.
+func (rd *Reader) Read() (Record, error) {
+	// между записями встречаются переводы строк, их пропускаем
+	for {
+		b, err := rd.r.Peek(1)
+		if err != nil {
+			return Record{}, err
+		}
+		if b[0] != '\n' && b[0] != '\r' {
+			break
+		}
+		rd.r.ReadByte()
+	}
+
+	head := make([]byte, 5)
+	if _, err := io.ReadFull(rd.r, head); err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+	length, ok := decimal(head)
+	if !ok || length < leaderLength+1 {
+		return Record{}, fmt.Errorf("invalid record length %q", head)
+	}
+	data := make([]byte, length)
+	copy(data, head)
+	if _, err := io.ReadFull(rd.r, data[5:]); err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+
+	rd.count++
+	record, err := Unmarshal(data)
+	if err != nil {
+		return Record{}, &ParseError{Record: rd.count, Err: err}
+	}
+	return record, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Unmarshal разбирает одну запись ISO 2709. Содержимое полей должно быть в UTF-8.
+func Unmarshal(data []byte) (Record, error) {
+	if len(data) < leaderLength+1 || data[len(data)-1] != recordTerminator {
+		return Record{}, errors.New("missing record terminator")
+	}
+	leader := data[:leaderLength]
+	baseAddress, ok := decimal(leader[12:17])
+	if !ok || baseAddress <= leaderLength || baseAddress > len(data) {
+		return Record{}, fmt.Errorf("invalid base address %q", leader[12:17])
+	}
+	if data[baseAddress-1] != fieldTerminator {
+		return Record{}, errors.New("directory is not terminated")
+	}
+
+	record := Record{Leader: string(leader)}
+	directory := data[leaderLength : baseAddress-1]
+	if len(directory)%directoryEntryLength != 0 {
+		return Record{}, errors.New("invalid directory length")
+	}
+	fields := data[baseAddress:]
+	for i := 0; i < len(directory); i += directoryEntryLength {
+		entry := directory[i : i+directoryEntryLength]
+		tag := string(entry[:3])
+		length, ok1 := decimal(entry[3:7])
+		start, ok2 := decimal(entry[7:12])
+		if !ok1 || !ok2 || length < 1 || start+length > len(fields) {
+			return Record{}, fmt.Errorf("invalid directory entry for tag %s", tag)
+		}
+		value := fields[start : start+length]
+		if value[len(value)-1] != fieldTerminator {
+			return Record{}, fmt.Errorf("field %s is not terminated", tag)
+		}
+		value = value[:len(value)-1]
+		if !utf8.Valid(value) {
+			return Record{}, fmt.Errorf("field %s is not valid UTF-8", tag)
+		}
+
+		if isControlTag(tag) {
+			record.ControlFields = append(record.ControlFields, ControlField{Tag: tag, Value: string(value)})
+			continue
+		}
+		if len(value) < 2 {
+			return Record{}, fmt.Errorf("field %s has no indicators", tag)
+		}
+		field := DataField{Tag: tag, Ind1: value[0], Ind2: value[1]}
+		for _, part := range bytes.Split(value[2:], []byte{subfieldDelimiter}) {
+			if len(part) == 0 {
+				continue
+			}
+			field.Subfields = append(field.Subfields, Subfield{Code: part[0], Value: string(part[1:])})
+		}
+		record.DataFields = append(record.DataFields, field)
+	}
+	return record, nil
+}
+
+// decimal разбирает число из одних цифр ASCII. strconv.Atoi не подходит: он принимает знак,
+// и отрицательное смещение из чужого файла вывело бы срез за границы.
+func decimal(b []byte) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, true
+}
+
+// Marshal кодирует запись в ISO 2709, пересчитывая длину записи и базовый адрес в лидере
+func Marshal(record Record) ([]byte, error) {
+	var directory, fields bytes.Buffer
+	addField := func(tag string, value []byte) error {
+		if len(tag) != 3 {
+			return fmt.Errorf("invalid tag %q", tag)
+		}
+		length := len(value) + 1
+		if length > 9999 {
+			return fmt.Errorf("field %s is too long", tag)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", tag, length, fields.Len())
+		fields.Write(value)
+		fields.WriteByte(fieldTerminator)
+		return nil
+	}
+
+	for _, f := range record.ControlFields {
+		if err := addField(f.Tag, []byte(f.Value)); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range record.DataFields {
+		value := []byte{indicator(f.Ind1), indicator(f.Ind2)}
+		for _, s := range f.Subfields {
+			value = append(value, subfieldDelimiter, s.Code)
+			value = append(value, s.Value...)
+		}
+		if err := addField(f.Tag, value); err != nil {
+			return nil, err
+		}
+	}
+	directory.WriteByte(fieldTerminator)
+
+	leader := record.Leader
+	if len(leader) != leaderLength {
+		leader = defaultLeader
+	}
+	baseAddress := leaderLength + directory.Len()
+	length := baseAddress + fields.Len() + 1
+	if length > maxRecordLength {
+		return nil, errors.New("record is too long")
+	}
+
+	out := make([]byte, 0, length)
+	out = append(out, fmt.Sprintf("%05d", length)...)
+	out = append(out, leader[5:12]...)
+	out = append(out, fmt.Sprintf("%05d", baseAddress)...)
+	out = append(out, leader[17:]...)
+	out = append(out, directory.Bytes()...)
+	out = append(out, fields.Bytes()...)
+	out = append(out, recordTerminator)
+	return out, nil
+}
+
+// indicator заменяет отсутствующий индикатор пробелом
+func indicator(b byte) byte {
+	if b == 0 {
+		return ' '
+	}
+	return b
+}
@@ -0,0 +1,83 @@
+package marc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func sampleRecord(t testing.TB) []byte {
+	t.Helper()
+	data, err := Marshal(Record{
+		ControlFields: []ControlField{{Tag: "001", Value: "42"}},
+		DataFields: []DataField{{
+			Tag: "245", Ind1: '1', Ind2: '0',
+			Subfields: []Subfield{{Code: 'a', Value: "Война и мир"}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	record, err := Unmarshal(sampleRecord(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := record.ControlField("001"); got != "42" {
+		t.Errorf("001 = %q, want 42", got)
+	}
+	if got := record.Subfield("245", 'a'); got != "Война и мир" {
+		t.Errorf("245$a = %q", got)
+	}
+}
+
+func TestUnmarshalMalformedDirectory(t *testing.T) {
+	// первая запись каталога: тег 001 с 24-го байта, длина в 27:31, начало в 31:36
+	const entry = leaderLength
+	tests := []struct {
+		name   string
+		offset int
+		value  string
+	}{
+		{"negative start", entry + 7, "-0001"},
+		{"signed start", entry + 7, "+0000"},
+		{"negative length", entry + 3, "-003"},
+		{"zero length", entry + 3, "0000"},
+		{"start past fields", entry + 7, "99999"},
+		{"length past fields", entry + 3, "9999"},
+		{"non-digit length", entry + 3, "00a3"},
+		{"spaces in start", entry + 7, "    0"},
+		{"signed base address", 12, "-0001"},
+		{"base address past record", 12, "99999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := sampleRecord(t)
+			copy(data[tt.offset:], tt.value)
+			if _, err := Unmarshal(data); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestReaderRejectsSignedRecordLength(t *testing.T) {
+	data := sampleRecord(t)
+	copy(data, "-0001")
+	_, err := NewReader(bytes.NewReader(data)).Read()
+	if err == nil || err == io.EOF {
+		t.Fatalf("Read() error = %v, want invalid record length", err)
+	}
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Add(sampleRecord(f))
+	f.Add([]byte("00026     2200025   4500\x1e\x1d"))
+	// испорченная запись должна давать ошибку, а не панику
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Unmarshal(data)
+	})
+}
@@ -0,0 +1,93 @@
+// Package marc читает и пишет библиографические записи MARC 21
+// в двоичном формате ISO 2709 и в MARCXML.
+package marc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Record - запись MARC 21. Поля хранятся в порядке появления в записи.
+type Record struct {
+	Leader        string
+	ControlFields []ControlField
+	DataFields    []DataField
+}
+
+// ControlField - управляющее поле 001-009 без индикаторов и подполей
+type ControlField struct {
+	Tag   string
+	Value string
+}
+
+type DataField struct {
+	Tag       string
+	Ind1      byte
+	Ind2      byte
+	Subfields []Subfield
+}
+
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// RecordReader - общий интерфейс Reader (ISO 2709) и XMLReader
+type RecordReader interface {
+	Read() (Record, error)
+}
+
+// ParseError - ошибка разбора отдельной записи; чтение можно продолжить со следующей
+type ParseError struct {
+	// порядковый номер записи, начиная с 1
+	Record int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Record, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ControlField возвращает значение управляющего поля tag или пустую строку
+func (r Record) ControlField(tag string) string {
+	for _, f := range r.ControlFields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Subfield возвращает первое подполе code первого поля tag, в котором оно есть
+func (r Record) Subfield(tag string, code byte) string {
+	for _, f := range r.DataFields {
+		if f.Tag != tag {
+			continue
+		}
+		if v, ok := f.Subfield(code); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func (f DataField) Subfield(code byte) (string, bool) {
+	for _, s := range f.Subfields {
+		if s.Code == code {
+			return s.Value, true
+		}
+	}
+	return "", false
+}
+
+func isControlTag(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
+
+// leader для новых записей: новая (n), текст (a), монография (m), кодировка UTF-8 (a).
+// Длина записи и базовый адрес проставляются при записи.
+const defaultLeader = "00000nam a2200000 i 4500"
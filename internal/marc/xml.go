@@ -0,0 +1,161 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Namespace - пространство имён MARCXML
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// XMLReader читает записи MARCXML по одной: как из <collection>, так и одиночную <record>
+type XMLReader struct {
+	dec   *xml.Decoder
+	count int
+}
+
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{dec: xml.NewDecoder(r)}
+}
+
+// Read возвращает следующую запись или io.EOF; семантика ошибок та же, что у Reader.Read
+func (rd *XMLReader) Read() (Record, error) {
+	for {
+		token, err := rd.dec.Token()
+		if err != nil {
+			return Record{}, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		var x xmlRecord
+		if err := rd.dec.DecodeElement(&x, &start); err != nil {
+			return Record{}, err
+		}
+		rd.count++
+		record, err := x.record()
+		if err != nil {
+			return Record{}, &ParseError{Record: rd.count, Err: err}
+		}
+		return record, nil
+	}
+}
+
+func (x xmlRecord) record() (Record, error) {
+	record := Record{Leader: x.Leader}
+	for _, f := range x.ControlFields {
+		if len(f.Tag) != 3 {
+			return Record{}, fmt.Errorf("invalid tag %q", f.Tag)
+		}
+		record.ControlFields = append(record.ControlFields, ControlField{Tag: f.Tag, Value: f.Value})
+	}
+	for _, f := range x.DataFields {
+		if len(f.Tag) != 3 {
+			return Record{}, fmt.Errorf("invalid tag %q", f.Tag)
+		}
+		if len(f.Ind1) > 1 || len(f.Ind2) > 1 {
+			return Record{}, fmt.Errorf("field %s: invalid indicator", f.Tag)
+		}
+		field := DataField{Tag: f.Tag, Ind1: firstByte(f.Ind1), Ind2: firstByte(f.Ind2)}
+		for _, s := range f.Subfields {
+			if len(s.Code) != 1 {
+				return Record{}, fmt.Errorf("field %s: invalid subfield code %q", f.Tag, s.Code)
+			}
+			field.Subfields = append(field.Subfields, Subfield{Code: s.Code[0], Value: s.Value})
+		}
+		record.DataFields = append(record.DataFields, field)
+	}
+	return record, nil
+}
+
+func firstByte(s string) byte {
+	if s == "" {
+		return ' '
+	}
+	return s[0]
+}
+
+// XMLWriter пишет записи внутри элемента <collection>; после последней записи нужен Close
+type XMLWriter struct {
+	enc     *xml.Encoder
+	started bool
+}
+
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{enc: xml.NewEncoder(w)}
+}
+
+var collectionStart = xml.StartElement{
+	Name: xml.Name{Local: "collection"},
+	Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: Namespace}},
+}
+
+func (w *XMLWriter) Write(record Record) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	leader := record.Leader
+	if len(leader) != leaderLength {
+		leader = defaultLeader
+	}
+	x := xmlRecord{Leader: leader}
+	for _, f := range record.ControlFields {
+		x.ControlFields = append(x.ControlFields, xmlControlField{Tag: f.Tag, Value: f.Value})
+	}
+	for _, f := range record.DataFields {
+		field := xmlDataField{Tag: f.Tag, Ind1: string(indicator(f.Ind1)), Ind2: string(indicator(f.Ind2))}
+		for _, s := range f.Subfields {
+			field.Subfields = append(field.Subfields, xmlSubfield{Code: string(s.Code), Value: s.Value})
+		}
+		x.DataFields = append(x.DataFields, field)
+	}
+	return w.enc.Encode(x)
+}
+
+func (w *XMLWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	if err := w.enc.EncodeToken(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)}); err != nil {
+		return err
+	}
+	return w.enc.EncodeToken(collectionStart)
+}
+
+func (w *XMLWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if err := w.enc.EncodeToken(collectionStart.End()); err != nil {
+		return err
+	}
+	return w.enc.Flush()
+}
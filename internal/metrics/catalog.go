@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"api_library/internal/repository"
+	"context"
+	"log"
+)
+
+// RegisterCatalogStats добавляет число авторов и книг в каталоге; значения читаются из базы при каждом сборе
+func RegisterCatalogStats(registry *Registry, repo repository.StatsRepository) {
+	items := registry.Gauge("library_catalog_items", "Число записей каталога без учёта корзины", "entity")
+	registry.OnScrape(func(ctx context.Context) {
+		stats, err := repo.GetCatalogStats(ctx)
+		if err != nil {
+			// остаются значения предыдущего сбора
+			log.Printf("Метрики каталога не обновлены: %v", err)
+			return
+		}
+		items.Set(float64(stats.Authors), "authors")
+		items.Set(float64(stats.Books), "books")
+	})
+}
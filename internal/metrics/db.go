@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"api_library/internal/repository"
+	"context"
+	"database/sql"
+)
+
+// RegisterDBStats добавляет метрики пула соединений из sql.DB.Stats()
+func RegisterDBStats(registry *Registry, db *sql.DB) {
+	gauge := func(name, help string, value func(sql.DBStats) float64) {
+		registry.GaugeFunc(name, help, func() float64 { return value(db.Stats()) })
+	}
+	counter := func(name, help string, value func(sql.DBStats) float64) {
+		registry.CounterFunc(name, help, func() float64 { return value(db.Stats()) })
+	}
+
+	gauge("library_db_max_open_connections", "Максимальное число открытых соединений",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	gauge("library_db_open_connections", "Открытые соединения",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("library_db_in_use_connections", "Соединения, занятые запросами",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("library_db_idle_connections", "Свободные соединения",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	counter("library_db_wait_count_total", "Сколько раз запрос ждал свободного соединения",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	counter("library_db_wait_duration_seconds_total", "Суммарное время ожидания свободного соединения",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	counter("library_db_max_idle_closed_total", "Соединения, закрытые из-за лимита свободных",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	counter("library_db_max_idle_time_closed_total", "Соединения, закрытые по времени простоя",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) })
+	counter("library_db_max_lifetime_closed_total", "Соединения, закрытые по времени жизни",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
+
+// RegisterReplicaStats добавляет отставание реплик и то, используются ли они для чтения, по последней проверке Router
+func RegisterReplicaStats(registry *Registry, router *repository.Router) {
+	lag := registry.Gauge("library_db_replica_lag_seconds", "Отставание реплики по pg_last_xact_replay_timestamp", "replica")
+	usable := registry.Gauge("library_db_replica_usable", "1, если реплика доступна и отстаёт не больше допустимого", "replica")
+	registry.OnScrape(func(ctx context.Context) {
+		for _, status := range router.Replicas() {
+			lag.Set(status.Lag.Seconds(), status.Name)
+			value := 0.0
+			if status.Usable {
+				value = 1
+			}
+			usable.Set(value, status.Name)
+		}
+	})
+}
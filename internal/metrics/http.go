@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPMetrics считает запросы и их длительность по маршруту, методу и статусу ответа
+type HTTPMetrics struct {
+	requests *CounterVec
+	duration *HistogramVec
+}
+
+func NewHTTPMetrics(registry *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: registry.Counter("library_http_requests_total", "Число HTTP-запросов", "route", "method", "status"),
+		duration: registry.Histogram("library_http_request_duration_seconds", "Длительность HTTP-запросов в секундах", DefaultBuckets, "route", "method", "status"),
+	}
+}
+
+// Wrap измеряет все запросы, в том числе отклонённые до маршрутизации (например, без аутентификации).
+// Маршрут - шаблон, под которым обработчик зарегистрирован в mux, чтобы ID в пути не размножали ряды.
+func (m *HTTPMetrics) Wrap(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		status := strconv.Itoa(recorder.status)
+		method := normalizeMethod(r.Method)
+		m.requests.Inc(route, method, status)
+		m.duration.Observe(time.Since(start).Seconds(), route, method, status)
+	})
+}
+
+// normalizeMethod ограничивает метку method известными методами
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
+
+// statusRecorder запоминает статус ответа
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap даёт http.ResponseController доступ к исходному ResponseWriter (Flush для выгрузки)
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/repository"
+	"context"
+	"strconv"
+	"time"
+)
+
+// instrumentedRepository измеряет длительность и ошибки каждого метода repository.Repository
+type instrumentedRepository struct {
+	next     repository.Repository
+	duration *HistogramVec
+	failures *CounterVec
+}
+
+// InstrumentRepository оборачивает репозиторий метриками; у выгрузки в длительность входит и передача данных клиенту
+func InstrumentRepository(repo repository.Repository, registry *Registry) repository.Repository {
+	return &instrumentedRepository{
+		next:     repo,
+		duration: registry.Histogram("library_repository_query_duration_seconds", "Длительность вызовов репозитория в секундах", DefaultBuckets, "method"),
+		failures: registry.Counter("library_repository_errors_total", "Ошибки вызовов репозитория по HTTP-коду ошибки", "method", "code"),
+	}
+}
+
+func (r *instrumentedRepository) observe(method string, start time.Time, err *error) {
+	r.duration.Observe(time.Since(start).Seconds(), method)
+	if *err != nil {
+		r.failures.Inc(method, strconv.Itoa(errors.MapErrorToHTTP(*err).Code))
+	}
+}
+
+func (r *instrumentedRepository) GetAllAuthors(ctx context.Context, filter entity.AuthorFilter) (authors []entity.Author, err error) {
+	defer r.observe("GetAllAuthors", time.Now(), &err)
+	return r.next.GetAllAuthors(ctx, filter)
+}
+
+func (r *instrumentedRepository) GetAuthor(ctx context.Context, authorID int) (author entity.Author, err error) {
+	defer r.observe("GetAuthor", time.Now(), &err)
+	return r.next.GetAuthor(ctx, authorID)
+}
+
+func (r *instrumentedRepository) GetAuthorsByIDs(ctx context.Context, authorIDs []int) (authors []entity.Author, err error) {
+	defer r.observe("GetAuthorsByIDs", time.Now(), &err)
+	return r.next.GetAuthorsByIDs(ctx, authorIDs)
+}
+
+func (r *instrumentedRepository) FindAuthorByName(ctx context.Context, firstName, lastName string) (author entity.Author, err error) {
+	defer r.observe("FindAuthorByName", time.Now(), &err)
+	return r.next.FindAuthorByName(ctx, firstName, lastName)
+}
+
+func (r *instrumentedRepository) CreateAuthor(ctx context.Context, firstName, lastName, biography string, birthDate time.Time) (id int, err error) {
+	defer r.observe("CreateAuthor", time.Now(), &err)
+	return r.next.CreateAuthor(ctx, firstName, lastName, biography, birthDate)
+}
+
+func (r *instrumentedRepository) UpdateAuthor(ctx context.Context, authorID int, firstName, lastName, biography string, birthDate time.Time) (err error) {
+	defer r.observe("UpdateAuthor", time.Now(), &err)
+	return r.next.UpdateAuthor(ctx, authorID, firstName, lastName, biography, birthDate)
+}
+
+func (r *instrumentedRepository) DeleteAuthor(ctx context.Context, authorID int) (err error) {
+	defer r.observe("DeleteAuthor", time.Now(), &err)
+	return r.next.DeleteAuthor(ctx, authorID)
+}
+
+func (r *instrumentedRepository) GetAllBooks(ctx context.Context, filter entity.BookFilter) (books []entity.Book, err error) {
+	defer r.observe("GetAllBooks", time.Now(), &err)
+	return r.next.GetAllBooks(ctx, filter)
+}
+
+func (r *instrumentedRepository) GetBooksByAuthor(ctx context.Context, authorID int) (books []entity.Book, err error) {
+	defer r.observe("GetBooksByAuthor", time.Now(), &err)
+	return r.next.GetBooksByAuthor(ctx, authorID)
+}
+
+func (r *instrumentedRepository) GetBooksByAuthors(ctx context.Context, authorIDs []int) (books []entity.Book, err error) {
+	defer r.observe("GetBooksByAuthors", time.Now(), &err)
+	return r.next.GetBooksByAuthors(ctx, authorIDs)
+}
+
+func (r *instrumentedRepository) GetBook(ctx context.Context, bookID int) (book entity.Book, err error) {
+	defer r.observe("GetBook", time.Now(), &err)
+	return r.next.GetBook(ctx, bookID)
+}
+
+func (r *instrumentedRepository) CreateBook(ctx context.Context, title string, year int, isbn string, authorID int) (id int, err error) {
+	defer r.observe("CreateBook", time.Now(), &err)
+	return r.next.CreateBook(ctx, title, year, isbn, authorID)
+}
+
+func (r *instrumentedRepository) UpdateBook(ctx context.Context, bookID int, title string, year int, isbn string) (err error) {
+	defer r.observe("UpdateBook", time.Now(), &err)
+	return r.next.UpdateBook(ctx, bookID, title, year, isbn)
+}
+
+func (r *instrumentedRepository) DeleteBook(ctx context.Context, bookID int) (err error) {
+	defer r.observe("DeleteBook", time.Now(), &err)
+	return r.next.DeleteBook(ctx, bookID)
+}
+
+func (r *instrumentedRepository) UpdateBookAndAuthor(ctx context.Context, bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) (err error) {
+	defer r.observe("UpdateBookAndAuthor", time.Now(), &err)
+	return r.next.UpdateBookAndAuthor(ctx, bookID, newTitle, newYear, newISBN, authorID, newFirstName, newLastName, newBiography, newBirthDate)
+}
+
+func (r *instrumentedRepository) RestoreAuthor(ctx context.Context, authorID int) (err error) {
+	defer r.observe("RestoreAuthor", time.Now(), &err)
+	return r.next.RestoreAuthor(ctx, authorID)
+}
+
+func (r *instrumentedRepository) RestoreBook(ctx context.Context, bookID int) (err error) {
+	defer r.observe("RestoreBook", time.Now(), &err)
+	return r.next.RestoreBook(ctx, bookID)
+}
+
+func (r *instrumentedRepository) GetTrash(ctx context.Context) (trash entity.Trash, err error) {
+	defer r.observe("GetTrash", time.Now(), &err)
+	return r.next.GetTrash(ctx)
+}
+
+func (r *instrumentedRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (purged int, err error) {
+	defer r.observe("PurgeDeleted", time.Now(), &err)
+	return r.next.PurgeDeleted(ctx, deletedBefore)
+}
+
+func (r *instrumentedRepository) GetAuthorVersions(ctx context.Context, authorID int) (versions []entity.AuthorVersion, err error) {
+	defer r.observe("GetAuthorVersions", time.Now(), &err)
+	return r.next.GetAuthorVersions(ctx, authorID)
+}
+
+func (r *instrumentedRepository) GetAuthorAsOf(ctx context.Context, authorID int, asOf time.Time) (author entity.Author, err error) {
+	defer r.observe("GetAuthorAsOf", time.Now(), &err)
+	return r.next.GetAuthorAsOf(ctx, authorID, asOf)
+}
+
+func (r *instrumentedRepository) RevertAuthor(ctx context.Context, authorID, version int) (err error) {
+	defer r.observe("RevertAuthor", time.Now(), &err)
+	return r.next.RevertAuthor(ctx, authorID, version)
+}
+
+func (r *instrumentedRepository) GetBookVersions(ctx context.Context, bookID int) (versions []entity.BookVersion, err error) {
+	defer r.observe("GetBookVersions", time.Now(), &err)
+	return r.next.GetBookVersions(ctx, bookID)
+}
+
+func (r *instrumentedRepository) GetBookAsOf(ctx context.Context, bookID int, asOf time.Time) (book entity.Book, err error) {
+	defer r.observe("GetBookAsOf", time.Now(), &err)
+	return r.next.GetBookAsOf(ctx, bookID, asOf)
+}
+
+func (r *instrumentedRepository) RevertBook(ctx context.Context, bookID, version int) (err error) {
+	defer r.observe("RevertBook", time.Now(), &err)
+	return r.next.RevertBook(ctx, bookID, version)
+}
+
+func (r *instrumentedRepository) ExportAuthors(ctx context.Context, filter entity.AuthorFilter, fn func(entity.Author) error) (err error) {
+	defer r.observe("ExportAuthors", time.Now(), &err)
+	return r.next.ExportAuthors(ctx, filter, fn)
+}
+
+func (r *instrumentedRepository) ExportBooks(ctx context.Context, filter entity.BookFilter, fn func(entity.BookRecord) error) (err error) {
+	defer r.observe("ExportBooks", time.Now(), &err)
+	return r.next.ExportBooks(ctx, filter, fn)
+}
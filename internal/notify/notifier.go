@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier - канал доставки уведомлений (email и т.п.)
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogNotifier пишет уведомления в лог; используется, когда SMTP не настроен
+type LogNotifier struct{}
+
+func (LogNotifier) Send(ctx context.Context, msg Message) error {
+	log.Printf("Уведомление для %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
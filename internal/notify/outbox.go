@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/repository"
+	"context"
+	"log"
+	"math/rand"
+	"time"
+)
+
+const (
+	dispatchBatchSize = 50
+	// время, на которое уведомление закрепляется за отправителем
+	dispatchLease = 5 * time.Minute
+
+	maxAttempts    = 8
+	backoffBase    = time.Minute
+	backoffCeiling = 6 * time.Hour
+)
+
+// Outbox сохраняет уведомления в БД и доставляет их через Notifier с повторами.
+// Благодаря таблице-outbox уведомления не теряются при перезапуске сервиса.
+type Outbox struct {
+	repo     repository.OutboxRepository
+	notifier Notifier
+}
+
+func NewOutbox(repo repository.OutboxRepository, notifier Notifier) *Outbox {
+	return &Outbox{repo: repo, notifier: notifier}
+}
+
+// Enqueue формирует текст по шаблону и ставит уведомление в очередь.
+// Выдач и броней в сервисе пока нет, поэтому вызывать Enqueue пока некому.
+func (o *Outbox) Enqueue(ctx context.Context, kind, lang, recipient string, data map[string]interface{}) (int, error) {
+	subject, body, err := Render(kind, lang, data)
+	if err != nil {
+		return 0, err
+	}
+	return o.repo.EnqueueNotification(ctx, entity.Notification{
+		Kind:      kind,
+		Recipient: recipient,
+		Subject:   subject,
+		Body:      body,
+	})
+}
+
+// Dispatch отправляет накопившиеся уведомления; предназначен для запуска из планировщика
+func (o *Outbox) Dispatch(ctx context.Context) error {
+	for {
+		notifications, err := o.repo.ClaimNotifications(ctx, dispatchBatchSize, dispatchLease)
+		if err != nil {
+			return err
+		}
+		for _, n := range notifications {
+			if err := o.deliver(ctx, n); err != nil {
+				return err
+			}
+		}
+		if len(notifications) < dispatchBatchSize {
+			return nil
+		}
+	}
+}
+
+func (o *Outbox) deliver(ctx context.Context, n entity.Notification) error {
+	sendErr := o.notifier.Send(ctx, Message{To: n.Recipient, Subject: n.Subject, Body: n.Body})
+	if sendErr == nil {
+		return o.repo.MarkNotificationSent(ctx, n.ID)
+	}
+
+	if n.Attempts >= maxAttempts {
+		log.Printf("Уведомление %d не доставлено после %d попыток: %v", n.ID, n.Attempts, sendErr)
+		return o.repo.MarkNotificationFailed(ctx, n.ID, sendErr.Error(), nil)
+	}
+	next := time.Now().Add(backoff(n.Attempts))
+	return o.repo.MarkNotificationFailed(ctx, n.ID, sendErr.Error(), &next)
+}
+
+// экспоненциальная задержка с джиттером: 1m, 2m, 4m, ... но не больше backoffCeiling
+func backoff(attempt int) time.Duration {
+	d := backoffBase << uint(attempt-1)
+	if d <= 0 || d > backoffCeiling {
+		d = backoffCeiling
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
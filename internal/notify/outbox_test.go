@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"api_library/internal/entity"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeOutboxRepository хранит уведомления в памяти; ClaimNotifications отдаёт все ожидающие
+type fakeOutboxRepository struct {
+	notifications map[int]*entity.Notification
+	nextAttempt   map[int]*time.Time
+	status        map[int]string
+}
+
+func newFakeOutboxRepository() *fakeOutboxRepository {
+	return &fakeOutboxRepository{
+		notifications: make(map[int]*entity.Notification),
+		nextAttempt:   make(map[int]*time.Time),
+		status:        make(map[int]string),
+	}
+}
+
+func (r *fakeOutboxRepository) EnqueueNotification(ctx context.Context, n entity.Notification) (int, error) {
+	n.ID = len(r.notifications) + 1
+	r.notifications[n.ID] = &n
+	r.status[n.ID] = "pending"
+	return n.ID, nil
+}
+
+func (r *fakeOutboxRepository) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]entity.Notification, error) {
+	var claimed []entity.Notification
+	for id, n := range r.notifications {
+		if r.status[id] != "pending" || len(claimed) == limit {
+			continue
+		}
+		n.Attempts++
+		r.status[id] = "sending"
+		claimed = append(claimed, *n)
+	}
+	return claimed, nil
+}
+
+func (r *fakeOutboxRepository) MarkNotificationSent(ctx context.Context, id int) error {
+	r.status[id] = "sent"
+	return nil
+}
+
+func (r *fakeOutboxRepository) MarkNotificationFailed(ctx context.Context, id int, errMsg string, nextAttemptAt *time.Time) error {
+	r.status[id] = "failed"
+	r.nextAttempt[id] = nextAttemptAt
+	return nil
+}
+
+type fakeNotifier struct {
+	err  error
+	sent []Message
+}
+
+func (n *fakeNotifier) Send(ctx context.Context, msg Message) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestOutboxDispatch(t *testing.T) {
+	repo := newFakeOutboxRepository()
+	notifier := &fakeNotifier{}
+	outbox := NewOutbox(repo, notifier)
+
+	id, err := outbox.Enqueue(context.Background(), KindHoldReady, "en", "reader@example.org", map[string]interface{}{"BookTitle": "Dune", "ExpiresAt": "2025-03-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Dispatch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.status[id] != "sent" {
+		t.Errorf("status = %s, want sent", repo.status[id])
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Subject != `Your hold "Dune" is ready` {
+		t.Errorf("sent = %+v", notifier.sent)
+	}
+}
+
+func TestOutboxDispatchRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		attempts  int
+		wantRetry bool
+	}{
+		{"first failure", 0, true},
+		{"last attempt", maxAttempts - 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeOutboxRepository()
+			outbox := NewOutbox(repo, &fakeNotifier{err: errors.New("connection refused")})
+			id, _ := repo.EnqueueNotification(context.Background(), entity.Notification{Recipient: "reader@example.org", Attempts: tt.attempts})
+
+			before := time.Now()
+			if err := outbox.Dispatch(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if repo.status[id] != "failed" {
+				t.Fatalf("status = %s, want failed", repo.status[id])
+			}
+			next := repo.nextAttempt[id]
+			if !tt.wantRetry {
+				if next != nil {
+					t.Errorf("next attempt = %v, want none after %d attempts", next, maxAttempts)
+				}
+				return
+			}
+			if next == nil || next.Before(before.Add(backoffBase/2)) || next.After(time.Now().Add(backoffBase)) {
+				t.Errorf("next attempt = %v, want within %s", next, backoffBase)
+			}
+		})
+	}
+}
+
+func TestBackoffCeiling(t *testing.T) {
+	for attempt := 1; attempt <= 64; attempt++ {
+		if d := backoff(attempt); d <= 0 || d > backoffCeiling {
+			t.Fatalf("backoff(%d) = %s", attempt, d)
+		}
+	}
+}
+
+func TestRenderFallsBackToDefaultLanguage(t *testing.T) {
+	subject, _, err := Render(KindDueSoon, "de", map[string]interface{}{"BookTitle": "Дюна", "DueDate": "01.03.2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Срок возврата книги «Дюна» истекает 01.03.2025" {
+		t.Errorf("subject = %q", subject)
+	}
+	if _, _, err := Render("unknown", "ru", nil); err == nil {
+		t.Error("expected an error for unknown kind")
+	}
+}
@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer - минимальный SMTP-сервер без TLS и аутентификации, запоминающий принятые письма
+type fakeSMTPServer struct {
+	listener net.Listener
+	// получатели, которым сервер отвечает 550
+	rejected map[string]bool
+
+	mu       sync.Mutex
+	messages []fakeSMTPMessage
+}
+
+type fakeSMTPMessage struct {
+	from, to string
+	data     string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{listener: listener, rejected: make(map[string]bool)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) config() SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return SMTPConfig{Host: host, Port: port, From: "library@example.org"}
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	var msg fakeSMTPMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			msg.from = envelopeAddress(line)
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			msg.to = envelopeAddress(line)
+			if s.rejected[msg.to] {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			msg.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// envelopeAddress - адрес из "MAIL FROM:<a@b> BODY=8BITMIME"
+func envelopeAddress(line string) string {
+	_, rest, _ := strings.Cut(line, "<")
+	address, _, _ := strings.Cut(rest, ">")
+	return address
+}
+
+func (s *fakeSMTPServer) received() []fakeSMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeSMTPMessage(nil), s.messages...)
+}
+
+func TestSMTPNotifierSend(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	notifier := NewSMTPNotifier(server.config())
+
+	subject, body, err := Render(KindOverdue, "ru", map[string]interface{}{"BookTitle": "Война и мир", "DueDate": "01.03.2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Send(context.Background(), Message{To: "reader@example.org", Subject: subject, Body: body}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("received %d messages, want 1", len(messages))
+	}
+	got := messages[0]
+	if got.from != "library@example.org" || got.to != "reader@example.org" {
+		t.Errorf("envelope = %s -> %s", got.from, got.to)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	decodedSubject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedSubject != subject {
+		t.Errorf("Subject = %q, want %q", decodedSubject, subject)
+	}
+	if !strings.Contains(got.data, "Война и мир") {
+		t.Errorf("body does not contain the book title:\n%s", got.data)
+	}
+}
+
+func TestSMTPNotifierRejectedRecipient(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.rejected["nobody@example.org"] = true
+	notifier := NewSMTPNotifier(server.config())
+
+	err := notifier.Send(context.Background(), Message{To: "nobody@example.org", Subject: "s", Body: "b"})
+	if err == nil || !strings.Contains(err.Error(), "rcpt") {
+		t.Fatalf("Send error = %v, want rcpt error", err)
+	}
+	if n := len(server.received()); n != 0 {
+		t.Errorf("received %d messages, want 0", n)
+	}
+}
+
+func TestSMTPNotifierUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	notifier := NewSMTPNotifier(SMTPConfig{Host: host, Port: port, From: "library@example.org"})
+	if err := notifier.Send(context.Background(), Message{To: "reader@example.org"}); err == nil {
+		t.Fatal("expected a dial error")
+	}
+}
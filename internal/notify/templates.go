@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const (
+	KindDueSoon   = "due_soon"
+	KindOverdue   = "overdue"
+	KindHoldReady = "hold_ready"
+
+	DefaultLanguage = "ru"
+)
+
+type messageTemplate struct {
+	subject string
+	body    string
+}
+
+// тексты уведомлений по виду и языку; data - произвольные поля (PatronName, BookTitle, DueDate, ...)
+var messageTemplates = map[string]map[string]messageTemplate{
+	KindDueSoon: {
+		"ru": {
+			subject: `Срок возврата книги «{{.BookTitle}}» истекает {{.DueDate}}`,
+			body: `Здравствуйте{{with .PatronName}}, {{.}}{{end}}!
+
+Напоминаем, что книгу «{{.BookTitle}}» нужно вернуть до {{.DueDate}}.
+`,
+		},
+		"en": {
+			subject: `"{{.BookTitle}}" is due on {{.DueDate}}`,
+			body: `Hello{{with .PatronName}} {{.}}{{end}},
+
+This is a reminder that "{{.BookTitle}}" is due back on {{.DueDate}}.
+`,
+		},
+	},
+	KindOverdue: {
+		"ru": {
+			subject: `Книга «{{.BookTitle}}» просрочена`,
+			body: `Здравствуйте{{with .PatronName}}, {{.}}{{end}}!
+
+Срок возврата книги «{{.BookTitle}}» истёк {{.DueDate}}. Пожалуйста, верните её как можно скорее.
+`,
+		},
+		"en": {
+			subject: `"{{.BookTitle}}" is overdue`,
+			body: `Hello{{with .PatronName}} {{.}}{{end}},
+
+"{{.BookTitle}}" was due on {{.DueDate}}. Please return it as soon as possible.
+`,
+		},
+	},
+	KindHoldReady: {
+		"ru": {
+			subject: `Книга «{{.BookTitle}}» ждёт вас`,
+			body: `Здравствуйте{{with .PatronName}}, {{.}}{{end}}!
+
+Забронированная книга «{{.BookTitle}}» готова к выдаче. Бронь действует до {{.ExpiresAt}}.
+`,
+		},
+		"en": {
+			subject: `Your hold "{{.BookTitle}}" is ready`,
+			body: `Hello{{with .PatronName}} {{.}}{{end}},
+
+Your hold "{{.BookTitle}}" is ready for pickup. It will be kept for you until {{.ExpiresAt}}.
+`,
+		},
+	},
+}
+
+// Render формирует тему и текст уведомления; при отсутствии перевода используется DefaultLanguage
+func Render(kind, lang string, data map[string]interface{}) (string, string, error) {
+	byLang, ok := messageTemplates[kind]
+	if !ok {
+		return "", "", fmt.Errorf("unknown notification kind %q", kind)
+	}
+	tmpl, ok := byLang[lang]
+	if !ok {
+		tmpl = byLang[DefaultLanguage]
+	}
+
+	subject, err := execute(kind+".subject", tmpl.subject, data)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := execute(kind+".body", tmpl.body, data)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+func execute(name, text string, data map[string]interface{}) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse template %s: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render template %s: %v", name, err)
+	}
+	return buf.String(), nil
+}
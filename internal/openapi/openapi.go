@@ -0,0 +1,104 @@
+// Package openapi отдаёт описание API в формате OpenAPI 3.1 и страницу документации
+// и проверяет, что описание совпадает с зарегистрированными маршрутами.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+//go:embed docs.html
+var docs []byte
+
+// Spec возвращает документ OpenAPI
+func Spec() []byte {
+	return spec
+}
+
+// HandleSpec - /openapi.json
+func HandleSpec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not supported", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+// HandleDocs - /docs, страница Redoc, которая читает /openapi.json
+func HandleDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not supported", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docs)
+}
+
+// Verify сверяет пути описания с шаблонами ServeMux: каждый маршрут должен быть описан,
+// а каждый описанный путь - обслуживаться каким-то маршрутом.
+// Шаблон с «/» на конце («/authors/») покрывает все пути с этим префиксом.
+func Verify(patterns []string) error {
+	var document struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &document); err != nil {
+		return fmt.Errorf("openapi: invalid document: %w", err)
+	}
+
+	var undocumented, unrouted []string
+	for _, pattern := range patterns {
+		documented := false
+		for path := range document.Paths {
+			if matches(pattern, path) {
+				documented = true
+				break
+			}
+		}
+		if !documented {
+			undocumented = append(undocumented, pattern)
+		}
+	}
+	for path := range document.Paths {
+		routed := false
+		for _, pattern := range patterns {
+			if matches(pattern, path) {
+				routed = true
+				break
+			}
+		}
+		if !routed {
+			unrouted = append(unrouted, path)
+		}
+	}
+	if len(undocumented) == 0 && len(unrouted) == 0 {
+		return nil
+	}
+
+	sort.Strings(undocumented)
+	sort.Strings(unrouted)
+	var problems []string
+	if len(undocumented) > 0 {
+		problems = append(problems, "routes missing from spec: "+strings.Join(undocumented, ", "))
+	}
+	if len(unrouted) > 0 {
+		problems = append(problems, "spec paths without route: "+strings.Join(unrouted, ", "))
+	}
+	return fmt.Errorf("openapi: %s", strings.Join(problems, "; "))
+}
+
+func matches(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(path, pattern)
+	}
+	return path == pattern
+}
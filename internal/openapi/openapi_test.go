@@ -0,0 +1,66 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func specPaths(t *testing.T) []string {
+	t.Helper()
+	var document struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &document); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for path := range document.Paths {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestVerify(t *testing.T) {
+	paths := specPaths(t)
+	tests := []struct {
+		name     string
+		patterns []string
+		wantErr  string
+	}{
+		{"every path routed exactly", paths, ""},
+		{"undocumented route", append(append([]string(nil), paths...), "/loans"), "routes missing from spec: /loans"},
+		{"path without route", nil, "spec paths without route"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.patterns)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Verify() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/books", "/books", true},
+		{"/books", "/books/{id}", false},
+		{"/books/", "/books/{id}", true},
+		{"/books/", "/books", false},
+	}
+	for _, tt := range tests {
+		if got := matches(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matches(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Package ratelimit ограничивает частоту запросов корзинами токенов.
+// До аутентификации запрос списывается из корзины IP, после неё - из корзины пользователя.
+// Дорогие маршруты списывают больше токенов.
+package ratelimit
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StoreNone     = "none"
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+
+	defaultPerMinute = 600
+	defaultBurst     = 100
+)
+
+// DefaultCosts - стоимость маршрутов в токенах; ключ - "МЕТОД шаблон" или шаблон маршрута для всех методов.
+// Остальные запросы стоят 1 токен.
+var DefaultCosts = map[string]int{
+	// полные выборки таблиц
+	"GET /authors":    5,
+	"GET /books":      5,
+	"/export/authors": 20,
+	"/export/books":   20,
+	"/graphql":        5,
+	"/batch":          10,
+	"/import":         20,
+	"/import/marc":    20,
+	// проверка пароля медленная и привлекает подбор
+	"/auth/token": 5,
+}
+
+// Limit - корзина на Burst токенов, пополняемая со скоростью Rate токенов в секунду
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Result struct {
+	Allowed bool
+	// токенов в корзине после запроса
+	Remaining float64
+}
+
+// Store хранит корзины клиентов. MemoryStore - для одного экземпляра сервиса,
+// PostgresStore - общие корзины для всех экземпляров.
+type Store interface {
+	// Take пополняет корзину key за прошедшее время и списывает cost токенов, если их хватает
+	Take(ctx context.Context, key string, cost int, limit Limit) (Result, error)
+	// Cleanup удаляет корзины, к которым не обращались дольше idle
+	Cleanup(ctx context.Context, idle time.Duration) error
+}
+
+type Config struct {
+	// none - ограничение выключено, memory - в памяти процесса, postgres - в базе
+	Store string
+	// корзина пользователя, прошедшего аутентификацию
+	Limit Limit
+	// корзина IP, из которой списываются все запросы ещё до проверки учётных данных
+	IPLimit Limit
+	Costs   map[string]int
+	// брать IP клиента из X-Forwarded-For; включается, только если сервис стоит за прокси
+	TrustProxy bool
+}
+
+// ConfigFromEnv читает RATE_LIMIT_STORE, RATE_LIMIT_PER_MINUTE, RATE_LIMIT_BURST,
+// RATE_LIMIT_IP_PER_MINUTE, RATE_LIMIT_IP_BURST, RATE_LIMIT_COSTS ("GET /books=10,/graphql=3", дополняет DefaultCosts) и RATE_LIMIT_TRUST_PROXY
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Store:   os.Getenv("RATE_LIMIT_STORE"),
+		Limit:   Limit{Rate: defaultPerMinute / 60.0, Burst: defaultBurst},
+		IPLimit: Limit{Rate: defaultPerMinute / 60.0, Burst: defaultBurst},
+		Costs:   make(map[string]int, len(DefaultCosts)),
+	}
+	for route, cost := range DefaultCosts {
+		cfg.Costs[route] = cost
+	}
+	if cfg.Store == "" {
+		cfg.Store = StoreMemory
+	}
+	if cfg.Store != StoreNone && cfg.Store != StoreMemory && cfg.Store != StorePostgres {
+		return cfg, fmt.Errorf("invalid RATE_LIMIT_STORE %q: expected none, memory or postgres", cfg.Store)
+	}
+	if err := limitFromEnv("RATE_LIMIT_PER_MINUTE", "RATE_LIMIT_BURST", &cfg.Limit); err != nil {
+		return cfg, err
+	}
+	if err := limitFromEnv("RATE_LIMIT_IP_PER_MINUTE", "RATE_LIMIT_IP_BURST", &cfg.IPLimit); err != nil {
+		return cfg, err
+	}
+	if v := os.Getenv("RATE_LIMIT_COSTS"); v != "" {
+		for _, item := range strings.Split(v, ",") {
+			route, costValue, found := strings.Cut(strings.TrimSpace(item), "=")
+			cost, err := strconv.Atoi(strings.TrimSpace(costValue))
+			if !found || err != nil || cost < 0 || strings.TrimSpace(route) == "" {
+				return cfg, fmt.Errorf("invalid RATE_LIMIT_COSTS item %q: expected ROUTE=COST", item)
+			}
+			cfg.Costs[strings.TrimSpace(route)] = cost
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_TRUST_PROXY"); v != "" {
+		trust, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid RATE_LIMIT_TRUST_PROXY %q: expected true or false", v)
+		}
+		cfg.TrustProxy = trust
+	}
+	return cfg, nil
+}
+
+func limitFromEnv(perMinuteName, burstName string, limit *Limit) error {
+	if v := os.Getenv(perMinuteName); v != "" {
+		perMinute, err := strconv.Atoi(v)
+		if err != nil || perMinute <= 0 {
+			return fmt.Errorf("invalid %s %q: expected a positive integer", perMinuteName, v)
+		}
+		limit.Rate = float64(perMinute) / 60
+	}
+	if v := os.Getenv(burstName); v != "" {
+		burst, err := strconv.Atoi(v)
+		if err != nil || burst <= 0 {
+			return fmt.Errorf("invalid %s %q: expected a positive integer", burstName, v)
+		}
+		limit.Burst = burst
+	}
+	return nil
+}
+
+type Middleware struct {
+	store       Store
+	cfg         Config
+	mux         *http.ServeMux
+	exemptPaths map[string]bool
+}
+
+// mux нужен, чтобы найти шаблон маршрута для стоимости запроса;
+// exemptPaths не ограничиваются (например, /healthz для оркестратора)
+func NewMiddleware(store Store, cfg Config, mux *http.ServeMux, exemptPaths ...string) *Middleware {
+	m := &Middleware{store: store, cfg: cfg, mux: mux, exemptPaths: make(map[string]bool)}
+	for _, path := range exemptPaths {
+		m.exemptPaths[path] = true
+	}
+	return m
+}
+
+// WrapIP списывает стоимость запроса из корзины IP клиента. Ставится перед аутентификацией,
+// чтобы подбор паролей и API-ключей ограничивался так же, как остальные запросы.
+func (m *Middleware) WrapIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.serve(w, r, next, "ip:"+m.clientIP(r), m.cfg.IPLimit)
+	})
+}
+
+// Wrap списывает стоимость запроса из корзины пользователя. Ставится после аутентификации:
+// корзина выбирается по проверенному пользователю, а не по присланному ключу.
+// Запросы без пользователя уже ограничены корзиной IP в WrapIP и проходят дальше.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := auth.FromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		m.serve(w, r, next, "user:"+strconv.Itoa(identity.UserID), m.cfg.Limit)
+	})
+}
+
+// serve списывает стоимость запроса из корзины key и сообщает остаток в заголовках RateLimit-*.
+// Если токенов не хватает, отвечает 429 с Retry-After. При недоступности хранилища запросы пропускаются.
+// Корзина пользователя проверяется позже корзины IP, поэтому её заголовки заменяют заголовки IP.
+func (m *Middleware) serve(w http.ResponseWriter, r *http.Request, next http.Handler, key string, limit Limit) {
+	if m.store == nil || m.exemptPaths[r.URL.Path] {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	// дороже ёмкости корзины запрос быть не может, иначе он не пройдёт никогда
+	cost := min(m.cost(r), limit.Burst)
+	result, err := m.store.Take(r.Context(), key, cost, limit)
+	if err != nil {
+		log.Printf("Ограничение частоты запросов не проверено: %v", err)
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	header := w.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	header.Set("RateLimit-Remaining", strconv.Itoa(int(math.Floor(result.Remaining))))
+	header.Set("RateLimit-Reset", strconv.Itoa(seconds(float64(limit.Burst)-result.Remaining, limit.Rate)))
+	header.Set("RateLimit-Policy", strconv.Itoa(limit.Burst)+";w="+strconv.Itoa(seconds(float64(limit.Burst), limit.Rate)))
+	if !result.Allowed {
+		header.Set("Retry-After", strconv.Itoa(max(seconds(float64(cost)-result.Remaining, limit.Rate), 1)))
+		sendHTTPError(w, errors.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded", "ratelimit"))
+		return
+	}
+	next.ServeHTTP(w, r)
+}
+
+// Cleanup удаляет корзины, которые уже заполнились; вызывается планировщиком
+func (m *Middleware) Cleanup(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+	// корзины IP и пользователей лежат в одном хранилище, поэтому ждём дольше из двух сроков заполнения
+	idle := max(idleTime(m.cfg.Limit), idleTime(m.cfg.IPLimit))
+	return m.store.Cleanup(ctx, idle)
+}
+
+// idleTime - время, за которое пустая корзина заполняется целиком
+func idleTime(limit Limit) time.Duration {
+	return time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second))
+}
+
+func (m *Middleware) cost(r *http.Request) int {
+	_, pattern := m.mux.Handler(r)
+	if cost, ok := m.cfg.Costs[r.Method+" "+pattern]; ok {
+		return cost
+	}
+	if cost, ok := m.cfg.Costs[pattern]; ok {
+		return cost
+	}
+	return 1
+}
+
+// clientIP - адрес соединения или, за доверенным прокси, последний адрес из X-Forwarded-For:
+// его добавил сам прокси, а более ранние клиент мог подставить
+func (m *Middleware) clientIP(r *http.Request) string {
+	if m.cfg.TrustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			addresses := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(addresses[len(addresses)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// seconds - время в целых секундах, за которое накопится tokens токенов
+func seconds(tokens, rate float64) int {
+	if tokens <= 0 {
+		return 0
+	}
+	return int(math.Ceil(tokens / rate))
+}
+
+func sendHTTPError(w http.ResponseWriter, httpErr *errors.HTTPError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(httpErr)
+}
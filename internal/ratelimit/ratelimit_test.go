@@ -0,0 +1,293 @@
+package ratelimit
+
+import (
+	"api_library/internal/auth"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRefill(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	limit := Limit{Rate: 2, Burst: 4}
+
+	for i := 0; i < 4; i++ {
+		if result, _ := s.Take(ctx, "k", 1, limit); !result.Allowed {
+			t.Fatalf("take %d rejected with full bucket", i)
+		}
+	}
+	if result, _ := s.Take(ctx, "k", 1, limit); result.Allowed {
+		t.Fatal("empty bucket allowed a request")
+	}
+
+	// через секунду при Rate=2 в корзине снова 2 токена
+	s.buckets["k"].updated = s.buckets["k"].updated.Add(-time.Second)
+	result, _ := s.Take(ctx, "k", 2, limit)
+	if !result.Allowed || result.Remaining > 0.01 {
+		t.Errorf("after 1s refill: %+v, want allowed with ~0 remaining", result)
+	}
+
+	// пополнение не превышает ёмкость корзины
+	s.buckets["k"].updated = s.buckets["k"].updated.Add(-time.Hour)
+	result, _ = s.Take(ctx, "k", 1, limit)
+	if !result.Allowed || result.Remaining < 2.99 || result.Remaining > 3.01 {
+		t.Errorf("after long idle: %+v, want remaining 3", result)
+	}
+}
+
+func TestMemoryStoreRejectedTakeKeepsTokens(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	limit := Limit{Rate: 0.001, Burst: 5}
+
+	s.Take(ctx, "k", 4, limit)
+	if result, _ := s.Take(ctx, "k", 3, limit); result.Allowed {
+		t.Fatal("cost above remaining tokens allowed")
+	}
+	if result, _ := s.Take(ctx, "k", 1, limit); !result.Allowed {
+		t.Error("rejected take consumed tokens")
+	}
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	s.Take(ctx, "idle", 1, limit)
+	s.Take(ctx, "active", 1, limit)
+	s.buckets["idle"].updated = time.Now().Add(-time.Minute)
+
+	s.Cleanup(ctx, 10*time.Second)
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("idle bucket not removed")
+	}
+	if _, ok := s.buckets["active"]; !ok {
+		t.Error("active bucket removed")
+	}
+}
+
+type fakeRateLimitRepository struct {
+	key               string
+	cost, burst, rate float64
+	tokens            float64
+	allowed           bool
+	err               error
+	idle              time.Duration
+}
+
+func (f *fakeRateLimitRepository) TakeRateLimitTokens(ctx context.Context, key string, cost, burst, rate float64) (float64, bool, error) {
+	f.key, f.cost, f.burst, f.rate = key, cost, burst, rate
+	return f.tokens, f.allowed, f.err
+}
+
+func (f *fakeRateLimitRepository) DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (int, error) {
+	f.idle = idle
+	return 3, f.err
+}
+
+func TestPostgresStore(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRateLimitRepository{tokens: 7.5, allowed: true}
+	s := NewPostgresStore(repo)
+
+	result, err := s.Take(ctx, "ip:10.0.0.1", 5, Limit{Rate: 10, Burst: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (Result{Allowed: true, Remaining: 7.5}) {
+		t.Errorf("result = %+v", result)
+	}
+	if repo.key != "ip:10.0.0.1" || repo.cost != 5 || repo.burst != 100 || repo.rate != 10 {
+		t.Errorf("repository called with key=%s cost=%v burst=%v rate=%v", repo.key, repo.cost, repo.burst, repo.rate)
+	}
+
+	if err := s.Cleanup(ctx, time.Minute); err != nil || repo.idle != time.Minute {
+		t.Errorf("Cleanup: err=%v idle=%v", err, repo.idle)
+	}
+
+	repo.err = errors.New("connection refused")
+	if _, err := s.Take(ctx, "k", 1, Limit{Rate: 1, Burst: 1}); err == nil {
+		t.Error("repository error swallowed")
+	}
+}
+
+func newTestMiddleware(store Store, userLimit, ipLimit Limit) (*Middleware, *http.ServeMux) {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	mux.HandleFunc("/books", ok)
+	mux.HandleFunc("/auth/token", ok)
+	mux.HandleFunc("/healthz", ok)
+	cfg := Config{
+		Limit:   userLimit,
+		IPLimit: ipLimit,
+		Costs:   map[string]int{"GET /books": 5, "/auth/token": 5},
+	}
+	return NewMiddleware(store, cfg, mux, "/healthz"), mux
+}
+
+func TestHeadersAndRetryAfter(t *testing.T) {
+	m, mux := newTestMiddleware(NewMemoryStore(), Limit{Rate: 1, Burst: 10}, Limit{Rate: 1, Burst: 10})
+	handler := m.WrapIP(mux)
+
+	tests := []struct {
+		method     string
+		code       int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{http.MethodGet, http.StatusOK, "5", "5", ""},
+		{http.MethodPost, http.StatusOK, "4", "6", ""},
+		{http.MethodGet, http.StatusTooManyRequests, "4", "6", "1"},
+	}
+	for i, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, "/books", nil))
+		header := rec.Header()
+		if rec.Code != tt.code {
+			t.Errorf("request %d: status %d, want %d", i, rec.Code, tt.code)
+		}
+		if header.Get("RateLimit-Limit") != "10" || header.Get("RateLimit-Policy") != "10;w=10" {
+			t.Errorf("request %d: limit %q policy %q", i, header.Get("RateLimit-Limit"), header.Get("RateLimit-Policy"))
+		}
+		if header.Get("RateLimit-Remaining") != tt.remaining {
+			t.Errorf("request %d: remaining %q, want %q", i, header.Get("RateLimit-Remaining"), tt.remaining)
+		}
+		if header.Get("RateLimit-Reset") != tt.reset {
+			t.Errorf("request %d: reset %q, want %q", i, header.Get("RateLimit-Reset"), tt.reset)
+		}
+		if header.Get("Retry-After") != tt.retryAfter {
+			t.Errorf("request %d: Retry-After %q, want %q", i, header.Get("Retry-After"), tt.retryAfter)
+		}
+	}
+}
+
+func TestExemptPathAndNoStore(t *testing.T) {
+	m, mux := newTestMiddleware(NewMemoryStore(), Limit{Rate: 1, Burst: 1}, Limit{Rate: 1, Burst: 1})
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		m.WrapIP(mux).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("exempt path limited: %d %v", rec.Code, rec.Header())
+		}
+	}
+
+	m, mux = newTestMiddleware(nil, Limit{Rate: 1, Burst: 1}, Limit{Rate: 1, Burst: 1})
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		m.WrapIP(mux).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("limiting without store: %d", rec.Code)
+		}
+	}
+}
+
+// rejectAll - аутентификация, отклоняющая любые учётные данные, как при подборе пароля
+func rejectAll(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+}
+
+func TestIPBucketThrottlesBeforeAuthentication(t *testing.T) {
+	m, mux := newTestMiddleware(NewMemoryStore(), Limit{Rate: 1, Burst: 100}, Limit{Rate: 0.001, Burst: 10})
+	handler := m.WrapIP(rejectAll(m.Wrap(mux)))
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/auth/token", nil))
+		codes = append(codes, rec.Code)
+	}
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Fatalf("codes = %v, want %v", codes, want)
+		}
+	}
+}
+
+func TestUserBucketAfterAuthentication(t *testing.T) {
+	store := NewMemoryStore()
+	m, mux := newTestMiddleware(store, Limit{Rate: 0.001, Burst: 2}, Limit{Rate: 1, Burst: 100})
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), auth.Identity{UserID: 7})))
+		})
+	}
+	handler := m.WrapIP(authenticate(m.Wrap(mux)))
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/books", nil))
+		if rec.Code != want {
+			t.Fatalf("request %d: status %d, want %d", i, rec.Code, want)
+		}
+		// заголовки корзины пользователя заменяют заголовки корзины IP
+		if rec.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("request %d: RateLimit-Limit %q, want user bucket 2", i, rec.Header().Get("RateLimit-Limit"))
+		}
+	}
+	if _, ok := store.buckets["user:7"]; !ok {
+		t.Error("user bucket not created")
+	}
+
+	// неаутентифицированный запрос списывается только из корзины IP
+	rec := httptest.NewRecorder()
+	m.Wrap(mux).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/books", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("anonymous request charged in user bucket: %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		forwarded  []string
+		want       string
+	}{
+		{"connection address", false, nil, "192.0.2.1"},
+		{"forwarded ignored without proxy", false, []string{"203.0.113.5"}, "192.0.2.1"},
+		{"last forwarded address", true, []string{"198.51.100.9, 203.0.113.5"}, "203.0.113.5"},
+		{"last forwarded header", true, []string{"198.51.100.9", "203.0.113.5"}, "203.0.113.5"},
+		{"no forwarded header", true, nil, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Middleware{cfg: Config{TrustProxy: tt.trustProxy}}
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = "192.0.2.1:5555"
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := m.clientIP(r); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnvIPLimit(t *testing.T) {
+	t.Setenv("RATE_LIMIT_IP_PER_MINUTE", "120")
+	t.Setenv("RATE_LIMIT_IP_BURST", "20")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.IPLimit != (Limit{Rate: 2, Burst: 20}) {
+		t.Errorf("IPLimit = %+v", cfg.IPLimit)
+	}
+	if cfg.Limit != (Limit{Rate: defaultPerMinute / 60.0, Burst: defaultBurst}) {
+		t.Errorf("Limit changed by IP settings: %+v", cfg.Limit)
+	}
+
+	t.Setenv("RATE_LIMIT_IP_BURST", "0")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("RATE_LIMIT_IP_BURST=0 accepted")
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"api_library/internal/repository"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryStore хранит корзины в памяти процесса; у каждого экземпляра сервиса свои лимиты
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, cost int, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+	if b.tokens < float64(cost) {
+		return Result{Allowed: false, Remaining: b.tokens}, nil
+	}
+	b.tokens -= float64(cost)
+	return Result{Allowed: true, Remaining: b.tokens}, nil
+}
+
+func (s *MemoryStore) Cleanup(ctx context.Context, idle time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline := time.Now().Add(-idle)
+	for key, b := range s.buckets {
+		if b.updated.Before(deadline) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
+
+// PostgresStore хранит корзины в таблице rate_limit_buckets, так что лимит общий для всех экземпляров.
+// Каждый запрос к API добавляет один запрос к базе.
+type PostgresStore struct {
+	repo repository.RateLimitRepository
+}
+
+func NewPostgresStore(repo repository.RateLimitRepository) *PostgresStore {
+	return &PostgresStore{repo: repo}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, cost int, limit Limit) (Result, error) {
+	tokens, allowed, err := s.repo.TakeRateLimitTokens(ctx, key, float64(cost), float64(limit.Burst), limit.Rate)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Allowed: allowed, Remaining: tokens}, nil
+}
+
+func (s *PostgresStore) Cleanup(ctx context.Context, idle time.Duration) error {
+	deleted, err := s.repo.DeleteIdleRateLimitBuckets(ctx, idle)
+	if err != nil {
+		return err
+	}
+	log.Printf("Удалено корзин ограничения частоты запросов: %d", deleted)
+	return nil
+}
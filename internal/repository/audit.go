@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/requestid"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+
+	AuditEntityAuthor = "author"
+	AuditEntityBook   = "book"
+
+	// действия, выполненные не от имени пользователя (фоновые задачи и т.п.)
+	systemActor = "system"
+
+	defaultAuditLimit = 100
+)
+
+type AuditRepository interface {
+	GetAuditLog(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, error)
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+func (r *auditRepository) GetAuditLog(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, error) {
+	query := "SELECT id, actor, action, entity, entity_id, before_data, after_data, changes, COALESCE(request_id, ''), created_at FROM audit_log WHERE true"
+	var args []interface{}
+	if filter.Entity != "" {
+		args = append(args, filter.Entity)
+		query += " AND entity = $" + strconv.Itoa(len(args))
+	}
+	if filter.EntityID != 0 {
+		args = append(args, filter.EntityID)
+		query += " AND entity_id = $" + strconv.Itoa(len(args))
+	}
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		query += " AND actor = $" + strconv.Itoa(len(args))
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	args = append(args, limit)
+	query += " ORDER BY created_at DESC, id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var entries []entity.AuditEntry
+	for rows.Next() {
+		var entry entity.AuditEntry
+		var before, after, changes []byte
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.Entity, &entry.EntityID, &before, &after, &changes, &entry.RequestID, &entry.CreatedAt); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		entry.Before, entry.After, entry.Changes = before, after, changes
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return entries, nil
+}
+
+// writeAudit записывает изменение в журнал в той же транзакции, что и само изменение.
+// before == nil для создания, after == nil для удаления.
+func writeAudit(ctx context.Context, tx *sql.Tx, action, entityName string, entityID int, before, after interface{}) error {
+	beforeJSON, err := marshalAuditState(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalAuditState(after)
+	if err != nil {
+		return err
+	}
+	changes, err := diffAuditStates(beforeJSON, afterJSON)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO audit_log (actor, action, entity, entity_id, before_data, after_data, changes, request_id) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))",
+		auditActor(ctx), action, entityName, entityID, nullableJSON(beforeJSON), nullableJSON(afterJSON), nullableJSON(changes), requestid.FromContext(ctx))
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+func auditActor(ctx context.Context) string {
+	if identity, ok := auth.FromContext(ctx); ok {
+		return identity.Username
+	}
+	return systemActor
+}
+
+func marshalAuditState(state interface{}) ([]byte, error) {
+	if state == nil || reflect.ValueOf(state).IsNil() {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+func nullableJSON(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
+
+// diffAuditStates возвращает только изменившиеся поля: {"title": {"old": ..., "new": ...}}
+func diffAuditStates(before, after []byte) ([]byte, error) {
+	var oldFields, newFields map[string]interface{}
+	if before != nil {
+		if err := json.Unmarshal(before, &oldFields); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &newFields); err != nil {
+			return nil, err
+		}
+	}
+
+	type change struct {
+		Old interface{} `json:"old"`
+		New interface{} `json:"new"`
+	}
+	changes := make(map[string]change)
+	for field, oldValue := range oldFields {
+		if newValue, ok := newFields[field]; !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes[field] = change{Old: oldValue, New: newFields[field]}
+		}
+	}
+	for field, newValue := range newFields {
+		if _, ok := oldFields[field]; !ok {
+			changes[field] = change{New: newValue}
+		}
+	}
+	return json.Marshal(changes)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+type AuthRepository interface {
+	GetUser(ctx context.Context, userID int) (entity.User, error)
+	GetUserByUsername(ctx context.Context, username string) (entity.User, error)
+	CreateUser(ctx context.Context, username, passwordHash string) (int, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (entity.APIKey, error)
+	CreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	TouchAPIKey(ctx context.Context, keyID int) error
+}
+
+type authRepository struct {
+	db *sql.DB
+}
+
+func NewAuthRepository(db *sql.DB) AuthRepository {
+	return &authRepository{
+		db: db,
+	}
+}
+
+const apiKeyColumns = "id, name, prefix, key_hash, user_id, created_at, expires_at, last_used_at, revoked_at"
+
+func (r *authRepository) GetUser(ctx context.Context, userID int) (entity.User, error) {
+	var user entity.User
+	err := r.db.QueryRowContext(ctx, "SELECT id, username, password_hash, disabled, created_at FROM users WHERE id = $1", userID).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Disabled, &user.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return user, errors.ErrNotFound
+		}
+		return user, errors.WrapDB(err)
+	}
+	return user, nil
+}
+
+func (r *authRepository) GetUserByUsername(ctx context.Context, username string) (entity.User, error) {
+	var user entity.User
+	err := r.db.QueryRowContext(ctx, "SELECT id, username, password_hash, disabled, created_at FROM users WHERE username = $1", username).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Disabled, &user.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return user, errors.ErrNotFound
+		}
+		return user, errors.WrapDB(err)
+	}
+	return user, nil
+}
+
+func (r *authRepository) CreateUser(ctx context.Context, username, passwordHash string) (int, error) {
+	var userID int
+	err := r.db.QueryRowContext(ctx, "INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING id", username, passwordHash).Scan(&userID)
+	var pqErr *pq.Error
+	if stderrors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return 0, errors.NewHTTPError(http.StatusConflict, "user already exists", "CreateUser")
+	}
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	return userID, nil
+}
+
+func (r *authRepository) GetAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var keys []entity.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return keys, nil
+}
+
+func (r *authRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (entity.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", keyHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return key, errors.ErrNotFound
+		}
+		return key, errors.WrapDB(err)
+	}
+	return key, nil
+}
+
+func (r *authRepository) CreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	err := r.db.QueryRowContext(ctx, "INSERT INTO api_keys (name, prefix, key_hash, user_id, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at", key.Name, key.Prefix, key.KeyHash, key.UserID, key.ExpiresAt).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return key, errors.MapErrorToHTTP(err)
+	}
+	return key, nil
+}
+
+func (r *authRepository) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", keyID, userID)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.NewHTTPError(http.StatusNotFound, "api key not found", "RevokeAPIKey")
+	}
+	return nil
+}
+
+func (r *authRepository) TouchAPIKey(ctx context.Context, keyID int) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = now() WHERE id = $1", keyID)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (entity.APIKey, error) {
+	var key entity.APIKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &key.UserID, &key.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt)
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, err
+}
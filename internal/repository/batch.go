@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	"net/http"
+)
+
+type BatchRepository interface {
+	ExecuteBatch(ctx context.Context, operations []entity.BatchOperation, atomic bool) ([]entity.BatchResult, error)
+}
+
+// пишет в основную базу router и отмечает клиента для read-your-writes
+type batchRepository struct {
+	db     *sql.DB
+	router *Router
+}
+
+func NewBatchRepository(router *Router) BatchRepository {
+	return &batchRepository{
+		db:     router.Primary(),
+		router: router,
+	}
+}
+
+// ExecuteBatch выполняет операции в одной транзакции. В атомарном режиме первая ошибка откатывает
+// весь пакет, остальные операции получают статус 424. В независимом режиме каждая операция
+// защищена точкой сохранения: ошибка откатывает только её.
+func (r *batchRepository) ExecuteBatch(ctx context.Context, operations []entity.BatchOperation, atomic bool) ([]entity.BatchResult, error) {
+	results := make([]entity.BatchResult, len(operations))
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer tx.Rollback()
+
+	failed := -1
+	for i, op := range operations {
+		if !atomic {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_operation"); err != nil {
+				return nil, errors.MapErrorToHTTP(err)
+			}
+		}
+
+		results[i] = executeBatchOperation(ctx, tx, op)
+		if results[i].Error == "" {
+			if !atomic {
+				if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_operation"); err != nil {
+					return nil, errors.MapErrorToHTTP(err)
+				}
+			}
+			continue
+		}
+
+		if atomic {
+			failed = i
+			break
+		}
+		// ROLLBACK TO оставляет точку сохранения, поэтому её тоже освобождаем
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_operation; RELEASE SAVEPOINT batch_operation"); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+	}
+
+	if failed >= 0 {
+		for i, op := range operations {
+			if i != failed {
+				results[i] = entity.BatchResult{Index: op.Index, Status: http.StatusFailedDependency, Error: "batch rolled back"}
+			}
+		}
+		return results, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	r.router.Wrote(ctx)
+	return results, nil
+}
+
+func executeBatchOperation(ctx context.Context, tx *sql.Tx, op entity.BatchOperation) entity.BatchResult {
+	result := entity.BatchResult{Index: op.Index, ID: op.ID}
+	var err error
+	switch {
+	case op.Entity == entity.BatchEntityAuthor && op.Op == entity.BatchCreate:
+		a := op.Author
+		result.ID, err = createAuthor(ctx, tx, a.FirstName, a.LastName, a.Biography, a.BirthDate.Time)
+		result.Status = http.StatusCreated
+	case op.Entity == entity.BatchEntityAuthor && op.Op == entity.BatchUpdate:
+		a := op.Author
+		err = updateAuthor(ctx, tx, op.ID, a.FirstName, a.LastName, a.Biography, a.BirthDate.Time, "ExecuteBatch")
+	case op.Entity == entity.BatchEntityAuthor && op.Op == entity.BatchDelete:
+		err = deleteAuthor(ctx, tx, op.ID)
+	case op.Entity == entity.BatchEntityBook && op.Op == entity.BatchCreate:
+		b := op.Book
+		result.ID, err = createBook(ctx, tx, b.Title, b.Year, b.ISBN, b.AuthorID)
+		result.Status = http.StatusCreated
+	case op.Entity == entity.BatchEntityBook && op.Op == entity.BatchUpdate:
+		b := op.Book
+		err = updateBook(ctx, tx, op.ID, b.Title, b.Year, b.ISBN, "ExecuteBatch")
+	case op.Entity == entity.BatchEntityBook && op.Op == entity.BatchDelete:
+		err = deleteBook(ctx, tx, op.ID)
+	default:
+		err = errors.NewHTTPError(http.StatusBadRequest, "unsupported operation", "ExecuteBatch")
+	}
+
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		return entity.BatchResult{Index: op.Index, ID: op.ID, Status: httpErr.Code, Error: httpErr.Message}
+	}
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+	return result
+}
@@ -1,36 +1,171 @@
 package repository
 
 import (
+	"api_library/internal/tracing"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"math/rand"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
-func ConnectDB() (*sql.DB, error) {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
+const (
+	// задержка перед второй попыткой подключения; дальше удваивается до connectMaxDelay
+	connectBaseDelay = 500 * time.Millisecond
+	connectMaxDelay  = 10 * time.Second
+	// таймаут одного ping при подключении
+	connectPingTimeout = 5 * time.Second
+)
 
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
-	log.Println(connStr)
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		log.Fatalf("Ошибка при подключении к базе данных: %v", err)
-		return nil, err
+type DBConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	// сколько ждать доступности базы при запуске
+	ConnectTimeout  time.Duration
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// реплики для чтения в виде host:port; пользователь, пароль и база - как у основной
+	ReplicaHosts []string
+}
+
+// DBConfigFromEnv читает DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME, настройки пула
+// DB_CONNECT_TIMEOUT, DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME, DB_CONN_MAX_IDLE_TIME
+// и список реплик DB_REPLICA_HOSTS ("replica1:5432,replica2:5432")
+func DBConfigFromEnv() (DBConfig, error) {
+	cfg := DBConfig{
+		Host:            os.Getenv("DB_HOST"),
+		Port:            os.Getenv("DB_PORT"),
+		User:            os.Getenv("DB_USER"),
+		Password:        os.Getenv("DB_PASSWORD"),
+		Name:            os.Getenv("DB_NAME"),
+		ConnectTimeout:  time.Minute,
+		MaxOpenConns:    25,
+		MaxIdleConns:    25,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
 	}
+	for _, v := range []struct {
+		name  string
+		value *int
+	}{
+		{"DB_MAX_OPEN_CONNS", &cfg.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", &cfg.MaxIdleConns},
+	} {
+		if s := os.Getenv(v.name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				return cfg, fmt.Errorf("invalid %s %q: expected a non-negative integer", v.name, s)
+			}
+			*v.value = n
+		}
+	}
+	for _, v := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"DB_CONNECT_TIMEOUT", &cfg.ConnectTimeout},
+		{"DB_CONN_MAX_LIFETIME", &cfg.ConnMaxLifetime},
+		{"DB_CONN_MAX_IDLE_TIME", &cfg.ConnMaxIdleTime},
+	} {
+		if s := os.Getenv(v.name); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d < 0 {
+				return cfg, fmt.Errorf("invalid %s %q: expected a duration such as 30s", v.name, s)
+			}
+			*v.value = d
+		}
+	}
+	if v := os.Getenv("DB_REPLICA_HOSTS"); v != "" {
+		for _, hostPort := range strings.Split(v, ",") {
+			hostPort = strings.TrimSpace(hostPort)
+			if _, _, err := net.SplitHostPort(hostPort); err != nil {
+				return cfg, fmt.Errorf("invalid DB_REPLICA_HOSTS item %q: expected host:port", hostPort)
+			}
+			cfg.ReplicaHosts = append(cfg.ReplicaHosts, hostPort)
+		}
+	}
+	return cfg, nil
+}
 
-	err = db.Ping()
+// ConnectDB открывает пул и ждёт доступности базы: ping повторяется с экспоненциальной задержкой
+// и случайным разбросом, пока не истечёт cfg.ConnectTimeout
+func ConnectDB(ctx context.Context, cfg DBConfig) (*sql.DB, error) {
+	log.Printf("Подключение к базе данных %s на %s:%s", cfg.Name, cfg.Host, cfg.Port)
+	db, err := openDB(cfg, cfg.Host, cfg.Port)
 	if err != nil {
-		log.Fatalf("Ошибка при пинге базы данных: %v", err)
 		return nil, err
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+	delay := connectBaseDelay
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancelPing := context.WithTimeout(ctx, connectPingTimeout)
+		err = db.PingContext(pingCtx)
+		cancelPing()
+		if err == nil {
+			break
+		}
+
+		// от delay/2 до delay, чтобы несколько экземпляров не подключались одновременно
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		log.Printf("База данных недоступна (попытка %d): %v; повтор через %s", attempt, err, wait.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, fmt.Errorf("database is unavailable after %d attempts: %w", attempt, err)
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, connectMaxDelay)
+	}
+
 	log.Println("Успешное подключение к базе данных PostgreSQL")
 
 	return db, nil
 }
+
+// OpenReplicas открывает пулы соединений с репликами из cfg.ReplicaHosts. Доступность реплик не проверяется:
+// пока реплика недоступна, Router.Monitor не направляет на неё чтения.
+func OpenReplicas(cfg DBConfig) (map[string]*sql.DB, error) {
+	replicas := make(map[string]*sql.DB, len(cfg.ReplicaHosts))
+	for _, hostPort := range cfg.ReplicaHosts {
+		host, port, _ := net.SplitHostPort(hostPort)
+		db, err := openDB(cfg, host, port)
+		if err != nil {
+			for _, opened := range replicas {
+				opened.Close()
+			}
+			return nil, err
+		}
+		replicas[hostPort] = db
+	}
+	return replicas, nil
+}
+
+// openDB создаёт пул соединений с настройками из cfg, не подключаясь к базе
+func openDB(cfg DBConfig, host, port string) (*sql.DB, error) {
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, cfg.User, cfg.Password, cfg.Name)
+	// каждый SQL-запрос получает спан трассировки
+	connector, err := pq.NewConnector(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database settings: %w", err)
+	}
+	db := sql.OpenDB(tracing.WrapConnector(connector))
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return db, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"strconv"
+	"strings"
+)
+
+// authorFilterQuery возвращает условия " AND ..." для фильтра авторов; table - имя или псевдоним таблицы authors
+func authorFilterQuery(filter entity.AuthorFilter, table string) (string, []interface{}) {
+	var where strings.Builder
+	var args []interface{}
+	if filter.Name != "" {
+		args = append(args, "%"+escapeLike(filter.Name)+"%")
+		n := strconv.Itoa(len(args))
+		where.WriteString(" AND (" + table + ".first_name ILIKE $" + n + " OR " + table + ".last_name ILIKE $" + n + ")")
+	}
+	return where.String(), args
+}
+
+func bookFilterQuery(filter entity.BookFilter, table string) (string, []interface{}) {
+	var where strings.Builder
+	var args []interface{}
+	if filter.AuthorID != 0 {
+		args = append(args, filter.AuthorID)
+		where.WriteString(" AND " + table + ".author_id = $" + strconv.Itoa(len(args)))
+	}
+	if filter.Year != 0 {
+		args = append(args, filter.Year)
+		where.WriteString(" AND " + table + ".year = $" + strconv.Itoa(len(args)))
+	}
+	if filter.Title != "" {
+		args = append(args, "%"+escapeLike(filter.Title)+"%")
+		where.WriteString(" AND " + table + ".title ILIKE $" + strconv.Itoa(len(args)))
+	}
+	if filter.ISBN != "" {
+		args = append(args, filter.ISBN)
+		where.WriteString(" AND " + table + ".isbn = $" + strconv.Itoa(len(args)))
+	}
+	return where.String(), args
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы подстрока искалась буквально
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ExportAuthors передаёт авторов в fn по одному, не собирая выборку в памяти
+func (r *repository) ExportAuthors(ctx context.Context, filter entity.AuthorFilter, fn func(entity.Author) error) error {
+	where, args := authorFilterQuery(filter, "authors")
+	rows, err := r.router.reader(ctx).QueryContext(ctx, "SELECT id, first_name, last_name, biography, birth_date FROM authors WHERE deleted_at IS NULL"+where+" ORDER BY id", args...)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var author entity.Author
+		if err := rows.Scan(&author.ID, &author.FirstName, &author.LastName, &author.Biography, &author.BirthDate); err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+		if err := fn(author); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+// ExportBooks передаёт книги вместе с авторами в fn по одному
+func (r *repository) ExportBooks(ctx context.Context, filter entity.BookFilter, fn func(entity.BookRecord) error) error {
+	where, args := bookFilterQuery(filter, "b")
+	rows, err := r.router.reader(ctx).QueryContext(ctx, `SELECT b.id, b.title, b.year, b.isbn, b.author_id,
+			COALESCE(a.first_name, ''), COALESCE(a.last_name, ''), COALESCE(a.biography, ''), a.birth_date
+		FROM books b LEFT JOIN authors a ON a.id = b.author_id AND a.deleted_at IS NULL
+		WHERE b.deleted_at IS NULL`+where+" ORDER BY b.id", args...)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record entity.BookRecord
+		if err := rows.Scan(&record.ID, &record.Title, &record.Year, &record.ISBN, &record.AuthorID,
+			&record.Author.FirstName, &record.Author.LastName, &record.Author.Biography, &record.Author.BirthDate); err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+		record.Author.ID = record.AuthorID
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
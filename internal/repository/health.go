@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// requiredTables - таблицы из init.sql; если какой-то нет, схема применена не полностью
+var requiredTables = []string{
+	"authors", "books", "author_versions", "book_versions", "job_runs", "notification_outbox",
+	"users", "api_keys", "roles", "role_permissions", "user_roles", "audit_log", "idempotency_keys", "rate_limit_buckets",
+}
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	CheckSchema(ctx context.Context) error
+}
+
+type healthRepository struct {
+	db *sql.DB
+}
+
+func NewHealthRepository(db *sql.DB) HealthRepository {
+	return &healthRepository{
+		db: db,
+	}
+}
+
+func (r *healthRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// CheckSchema проверяет, что все таблицы схемы созданы
+func (r *healthRepository) CheckSchema(ctx context.Context) error {
+	var missing []string
+	err := r.db.QueryRowContext(ctx, "SELECT COALESCE(array_agg(t), '{}') FROM unnest($1::text[]) t WHERE to_regclass(t) IS NULL",
+		pq.Array(requiredTables)).Scan(pq.Array(&missing))
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing tables: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// saveAuthorVersion сохраняет текущее состояние автора в историю перед изменением.
+// Текущая строка действовала с updated_at; now() совпадает с моментом изменения в этой транзакции.
+func saveAuthorVersion(ctx context.Context, tx *sql.Tx, authorID int) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO author_versions (author_id, version, first_name, last_name, biography, birth_date, valid_from, valid_to)
+		SELECT id, (SELECT COALESCE(MAX(version), 0) + 1 FROM author_versions WHERE author_id = $1), first_name, last_name, biography, birth_date, updated_at, now()
+		FROM authors WHERE id = $1`, authorID)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+func saveBookVersion(ctx context.Context, tx *sql.Tx, bookID int) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO book_versions (book_id, version, title, author_id, year, isbn, valid_from, valid_to)
+		SELECT id, (SELECT COALESCE(MAX(version), 0) + 1 FROM book_versions WHERE book_id = $1), title, author_id, year, isbn, updated_at, now()
+		FROM books WHERE id = $1`, bookID)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+// GetAuthorVersions возвращает все версии автора по возрастанию; последняя - текущая, без valid_to
+func (r *repository) GetAuthorVersions(ctx context.Context, authorID int) ([]entity.AuthorVersion, error) {
+	rows, err := r.router.reader(ctx).QueryContext(ctx, `SELECT version, valid_from, valid_to, first_name, last_name, biography, birth_date
+		FROM author_versions WHERE author_id = $1 AND EXISTS (SELECT 1 FROM authors WHERE id = $1 AND deleted_at IS NULL)
+		UNION ALL
+		SELECT (SELECT COALESCE(MAX(version), 0) + 1 FROM author_versions WHERE author_id = $1), updated_at, NULL, first_name, last_name, biography, birth_date
+		FROM authors WHERE id = $1 AND deleted_at IS NULL
+		ORDER BY 1`, authorID)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var versions []entity.AuthorVersion
+	for rows.Next() {
+		var v entity.AuthorVersion
+		var validTo sql.NullTime
+		if err := rows.Scan(&v.Version, &v.ValidFrom, &validTo, &v.Author.FirstName, &v.Author.LastName, &v.Author.Biography, &v.Author.BirthDate); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		v.Author.ID = authorID
+		if validTo.Valid {
+			v.ValidTo = &validTo.Time
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	if len(versions) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	return versions, nil
+}
+
+// GetAuthorAsOf возвращает состояние автора на момент asOf
+func (r *repository) GetAuthorAsOf(ctx context.Context, authorID int, asOf time.Time) (entity.Author, error) {
+	author := entity.Author{ID: authorID}
+	err := r.router.reader(ctx).QueryRowContext(ctx, `SELECT first_name, last_name, biography, birth_date
+		FROM authors WHERE id = $1 AND deleted_at IS NULL AND updated_at <= $2
+		UNION ALL
+		SELECT first_name, last_name, biography, birth_date
+		FROM author_versions WHERE author_id = $1 AND valid_from <= $2 AND valid_to > $2
+			AND EXISTS (SELECT 1 FROM authors WHERE id = $1 AND deleted_at IS NULL)
+		LIMIT 1`, authorID, asOf).Scan(&author.FirstName, &author.LastName, &author.Biography, &author.BirthDate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return author, errors.ErrNotFound
+		}
+		return author, errors.WrapDB(err)
+	}
+	return author, nil
+}
+
+// RevertAuthor делает версию version текущей; откат сам становится новой версией.
+// Автор блокируется до чтения версии, чтобы параллельное изменение не вклинилось между чтением и откатом.
+func (r *repository) RevertAuthor(ctx context.Context, authorID, version int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := getAuthor(ctx, tx, authorID, true); err == errors.ErrNotFound {
+			return errors.NewHTTPError(http.StatusNotFound, "author not found", "RevertAuthor")
+		} else if err != nil {
+			return err
+		}
+
+		// последняя версия - текущее состояние, в author_versions её нет
+		var target entity.Author
+		err := tx.QueryRowContext(ctx, `SELECT first_name, last_name, biography, birth_date
+			FROM author_versions WHERE author_id = $1 AND version = $2
+			UNION ALL
+			SELECT first_name, last_name, biography, birth_date
+			FROM authors WHERE id = $1 AND $2 = (SELECT COALESCE(MAX(version), 0) + 1 FROM author_versions WHERE author_id = $1)
+			LIMIT 1`, authorID, version).Scan(&target.FirstName, &target.LastName, &target.Biography, &target.BirthDate)
+		if err == sql.ErrNoRows {
+			return errors.NewHTTPError(http.StatusNotFound, "version not found", "RevertAuthor")
+		} else if err != nil {
+			return errors.WrapDB(err)
+		}
+		return updateAuthor(ctx, tx, authorID, target.FirstName, target.LastName, target.Biography, target.BirthDate.Time, "RevertAuthor")
+	})
+}
+
+func (r *repository) GetBookVersions(ctx context.Context, bookID int) ([]entity.BookVersion, error) {
+	rows, err := r.router.reader(ctx).QueryContext(ctx, `SELECT version, valid_from, valid_to, title, author_id, year, isbn
+		FROM book_versions WHERE book_id = $1 AND EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL)
+		UNION ALL
+		SELECT (SELECT COALESCE(MAX(version), 0) + 1 FROM book_versions WHERE book_id = $1), updated_at, NULL, title, author_id, year, isbn
+		FROM books WHERE id = $1 AND deleted_at IS NULL
+		ORDER BY 1`, bookID)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var versions []entity.BookVersion
+	for rows.Next() {
+		var v entity.BookVersion
+		var validTo sql.NullTime
+		if err := rows.Scan(&v.Version, &v.ValidFrom, &validTo, &v.Book.Title, &v.Book.AuthorID, &v.Book.Year, &v.Book.ISBN); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		v.Book.ID = bookID
+		if validTo.Valid {
+			v.ValidTo = &validTo.Time
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	if len(versions) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	return versions, nil
+}
+
+func (r *repository) GetBookAsOf(ctx context.Context, bookID int, asOf time.Time) (entity.Book, error) {
+	book := entity.Book{ID: bookID}
+	err := r.router.reader(ctx).QueryRowContext(ctx, `SELECT title, author_id, year, isbn
+		FROM books WHERE id = $1 AND deleted_at IS NULL AND updated_at <= $2
+		UNION ALL
+		SELECT title, author_id, year, isbn
+		FROM book_versions WHERE book_id = $1 AND valid_from <= $2 AND valid_to > $2
+			AND EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL)
+		LIMIT 1`, bookID, asOf).Scan(&book.Title, &book.AuthorID, &book.Year, &book.ISBN)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return book, errors.ErrNotFound
+		}
+		return book, errors.WrapDB(err)
+	}
+	return book, nil
+}
+
+func (r *repository) RevertBook(ctx context.Context, bookID, version int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := getBook(ctx, tx, bookID, true); err == errors.ErrNotFound {
+			return errors.NewHTTPError(http.StatusNotFound, "book not found", "RevertBook")
+		} else if err != nil {
+			return err
+		}
+
+		var target entity.Book
+		err := tx.QueryRowContext(ctx, `SELECT title, year, isbn
+			FROM book_versions WHERE book_id = $1 AND version = $2
+			UNION ALL
+			SELECT title, year, isbn
+			FROM books WHERE id = $1 AND $2 = (SELECT COALESCE(MAX(version), 0) + 1 FROM book_versions WHERE book_id = $1)
+			LIMIT 1`, bookID, version).Scan(&target.Title, &target.Year, &target.ISBN)
+		if err == sql.ErrNoRows {
+			return errors.NewHTTPError(http.StatusNotFound, "version not found", "RevertBook")
+		} else if err != nil {
+			return errors.WrapDB(err)
+		}
+		return updateBook(ctx, tx, bookID, target.Title, target.Year, target.ISBN, "RevertBook")
+	})
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(ctx context.Context, record entity.IdempotencyRecord) (entity.IdempotencyRecord, bool, error)
+	ExtendIdempotencyKey(ctx context.Context, scope, key string, lockedUntil time.Time) error
+	CompleteIdempotencyKey(ctx context.Context, record entity.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int, error)
+}
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+// ReserveIdempotencyKey занимает ключ для выполнения запроса до record.LockedUntil. Истёкший ключ занимается заново.
+// Ключ без ответа, который не продлили вовремя (процесс упал посреди запроса), занимает повтор того же запроса.
+// Если ключ уже занят, возвращается сохранённая запись и false.
+func (r *idempotencyRepository) ReserveIdempotencyKey(ctx context.Context, record entity.IdempotencyRecord) (entity.IdempotencyRecord, bool, error) {
+	var reserved bool
+	err := r.db.QueryRowContext(ctx, `INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at, locked_until) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, expires_at = EXCLUDED.expires_at,
+			locked_until = EXCLUDED.locked_until, status = NULL, headers = NULL, body = NULL, created_at = now()
+		WHERE idempotency_keys.expires_at < now()
+			OR (idempotency_keys.status IS NULL AND idempotency_keys.locked_until < now() AND idempotency_keys.fingerprint = EXCLUDED.fingerprint)
+		RETURNING true`, record.Scope, record.Key, record.Fingerprint, record.ExpiresAt, record.LockedUntil).Scan(&reserved)
+	if err == nil {
+		return record, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return record, false, errors.MapErrorToHTTP(err)
+	}
+
+	var existing entity.IdempotencyRecord
+	var status sql.NullInt64
+	var headers []byte
+	err = r.db.QueryRowContext(ctx, "SELECT scope, key, fingerprint, status, headers, body, expires_at FROM idempotency_keys WHERE scope = $1 AND key = $2",
+		record.Scope, record.Key).Scan(&existing.Scope, &existing.Key, &existing.Fingerprint, &status, &headers, &existing.Body, &existing.ExpiresAt)
+	if err == sql.ErrNoRows {
+		// ключ успели освободить между запросами - пусть клиент повторит
+		return record, false, errors.NewHTTPError(http.StatusConflict, "request with this idempotency key is in progress", "ReserveIdempotencyKey")
+	}
+	if err != nil {
+		return record, false, errors.MapErrorToHTTP(err)
+	}
+	existing.Status = int(status.Int64)
+	if headers != nil {
+		if err := json.Unmarshal(headers, &existing.Headers); err != nil {
+			return record, false, err
+		}
+	}
+	return existing, false, nil
+}
+
+// ExtendIdempotencyKey продлевает занятость ключа, пока запрос ещё выполняется
+func (r *idempotencyRepository) ExtendIdempotencyKey(ctx context.Context, scope, key string, lockedUntil time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE idempotency_keys SET locked_until = $1 WHERE scope = $2 AND key = $3 AND status IS NULL", lockedUntil, scope, key)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+// CompleteIdempotencyKey сохраняет ответ для повторов
+func (r *idempotencyRepository) CompleteIdempotencyKey(ctx context.Context, record entity.IdempotencyRecord) error {
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, "UPDATE idempotency_keys SET status = $1, headers = $2, body = $3, locked_until = NULL WHERE scope = $4 AND key = $5",
+		record.Status, string(headers), record.Body, record.Scope, record.Key)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey освобождает ключ, если результат не нужно запоминать (например, при ошибке сервера)
+func (r *idempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2", scope, key); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < now()")
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	return int(deleted), nil
+}
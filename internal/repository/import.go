@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/requestid"
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// AuthorRows и BookRows отдают следующую запись импорта; ok == false - записи закончились
+type AuthorRows func() (author entity.Author, ok bool, err error)
+type BookRows func() (book entity.Book, ok bool, err error)
+
+type ImportRepository interface {
+	GetAuthorIDs(ctx context.Context) (map[int]bool, error)
+	ImportAuthors(ctx context.Context, next AuthorRows, dryRun bool) (int, error)
+	ImportBooks(ctx context.Context, next BookRows, dryRun bool) (int, error)
+}
+
+// пишет в основную базу router и отмечает клиента для read-your-writes
+type importRepository struct {
+	db     *sql.DB
+	router *Router
+}
+
+func NewImportRepository(router *Router) ImportRepository {
+	return &importRepository{
+		db:     router.Primary(),
+		router: router,
+	}
+}
+
+// GetAuthorIDs возвращает ID всех неудалённых авторов для проверки ссылок в импортируемых книгах
+func (r *importRepository) GetAuthorIDs(ctx context.Context) (map[int]bool, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM authors WHERE deleted_at IS NULL")
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		ids[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return ids, nil
+}
+
+// ImportAuthors загружает авторов через COPY во временную таблицу и одним запросом
+// переносит их в authors, записывая создание каждого в журнал аудита.
+// При dryRun транзакция откатывается, возвращается число записей, которые были бы созданы.
+func (r *importRepository) ImportAuthors(ctx context.Context, next AuthorRows, dryRun bool) (int, error) {
+	return r.copyIn(ctx, dryRun,
+		`CREATE TEMP TABLE import_authors (first_name VARCHAR(100), last_name VARCHAR(100), biography TEXT, birth_date DATE) ON COMMIT DROP`,
+		pq.CopyIn("import_authors", "first_name", "last_name", "biography", "birth_date"),
+		func(stmt *sql.Stmt) (bool, error) {
+			author, ok, err := next()
+			if err != nil || !ok {
+				return false, err
+			}
+			_, err = stmt.ExecContext(ctx, author.FirstName, author.LastName, author.Biography, author.BirthDate)
+			return true, err
+		},
+		"",
+		`WITH inserted AS (
+			INSERT INTO authors (first_name, last_name, biography, birth_date)
+			SELECT first_name, last_name, biography, birth_date FROM import_authors
+			RETURNING id, first_name, last_name, biography, birth_date
+		), states AS (
+			SELECT id, jsonb_build_object('id', id, 'first_name', first_name, 'last_name', last_name, 'biography', biography, 'birth_date', birth_date) AS after_data
+			FROM inserted
+		)
+		INSERT INTO audit_log (actor, action, entity, entity_id, after_data, changes, request_id)
+		SELECT $1, $2, $3, id, after_data,
+			(SELECT jsonb_object_agg(key, jsonb_build_object('old', NULL, 'new', value)) FROM jsonb_each(after_data)),
+			NULLIF($4, '')
+		FROM states`, AuditEntityAuthor)
+}
+
+// ImportBooks дополнительно блокирует упомянутых авторов FOR SHARE: ссылки проверены до транзакции,
+// и автор, удалённый после проверки, иначе получил бы новые книги. Если такой автор уже удалён,
+// импорт отменяется с 409 и его можно повторить - строки с этим автором будут отклонены.
+func (r *importRepository) ImportBooks(ctx context.Context, next BookRows, dryRun bool) (int, error) {
+	return r.copyIn(ctx, dryRun,
+		`CREATE TEMP TABLE import_books (title VARCHAR(255), author_id INT, year INT, isbn VARCHAR(13)) ON COMMIT DROP`,
+		pq.CopyIn("import_books", "title", "author_id", "year", "isbn"),
+		func(stmt *sql.Stmt) (bool, error) {
+			book, ok, err := next()
+			if err != nil || !ok {
+				return false, err
+			}
+			_, err = stmt.ExecContext(ctx, book.Title, book.AuthorID, book.Year, book.ISBN)
+			return true, err
+		},
+		`WITH locked AS (
+			SELECT id FROM authors
+			WHERE id IN (SELECT author_id FROM import_books) AND deleted_at IS NULL
+			FOR SHARE
+		)
+		SELECT (SELECT count(DISTINCT author_id) FROM import_books) - (SELECT count(*) FROM locked)`,
+		`WITH inserted AS (
+			INSERT INTO books (title, author_id, year, isbn)
+			SELECT title, author_id, year, isbn FROM import_books
+			RETURNING id, title, author_id, year, isbn
+		), states AS (
+			SELECT id, jsonb_build_object('id', id, 'title', title, 'author_id', author_id, 'year', year, 'isbn', isbn) AS after_data
+			FROM inserted
+		)
+		INSERT INTO audit_log (actor, action, entity, entity_id, after_data, changes, request_id)
+		SELECT $1, $2, $3, id, after_data,
+			(SELECT jsonb_object_agg(key, jsonb_build_object('old', NULL, 'new', value)) FROM jsonb_each(after_data)),
+			NULLIF($4, '')
+		FROM states`, AuditEntityBook)
+}
+
+// copyIn выполняет общий сценарий импорта: временная таблица, COPY построчно по мере чтения
+// входных данных, затем перенос в основную таблицу вместе с записями аудита.
+// lockQuery, если задан, блокирует строки, на которые ссылаются данные, и возвращает число пропавших.
+func (r *importRepository) copyIn(ctx context.Context, dryRun bool, createStaging, copyQuery string, copyRow func(stmt *sql.Stmt) (bool, error), lockQuery, insertQuery, entityName string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, createStaging); err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, copyQuery)
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	for {
+		more, err := copyRow(stmt)
+		if err != nil {
+			stmt.Close()
+			return 0, errors.MapErrorToHTTP(err)
+		}
+		if !more {
+			break
+		}
+	}
+	// пустой Exec завершает COPY и отправляет оставшийся буфер
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	if err := stmt.Close(); err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+
+	if lockQuery != "" {
+		var missing int
+		if err := tx.QueryRowContext(ctx, lockQuery).Scan(&missing); err != nil {
+			return 0, errors.MapErrorToHTTP(err)
+		}
+		if missing > 0 {
+			return 0, errors.NewHTTPError(http.StatusConflict, "referenced authors were deleted during import", "Import")
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, insertQuery, auditActor(ctx), AuditCreate, entityName, requestid.FromContext(ctx))
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	imported, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+
+	if dryRun {
+		return int(imported), nil
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	r.router.Wrote(ctx)
+	return int(imported), nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type JobRepository interface {
+	StartJobRun(ctx context.Context, jobName, trigger string, scheduledAt time.Time) (entity.JobRun, bool, error)
+	FinishJobRun(ctx context.Context, runID int, status, errMsg string) error
+	GetJobRuns(ctx context.Context, jobName string, limit int) ([]entity.JobRun, error)
+	TryJobLock(ctx context.Context, jobName string) (func(), bool, error)
+	PurgeJobRuns(ctx context.Context, finishedBefore time.Time) (int, error)
+}
+
+type jobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) JobRepository {
+	return &jobRepository{
+		db: db,
+	}
+}
+
+// StartJobRun фиксирует начало запуска; false, если этот слот расписания уже занят другой репликой
+func (r *jobRepository) StartJobRun(ctx context.Context, jobName, trigger string, scheduledAt time.Time) (entity.JobRun, bool, error) {
+	run := entity.JobRun{JobName: jobName, Trigger: trigger, Status: "running", ScheduledAt: scheduledAt}
+	err := r.db.QueryRowContext(ctx, "INSERT INTO job_runs (job_name, trigger, status, scheduled_at, started_at) VALUES ($1, $2, $3, $4, now()) ON CONFLICT (job_name, scheduled_at) DO NOTHING RETURNING id, started_at", jobName, trigger, run.Status, scheduledAt).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return run, false, nil
+		}
+		return run, false, errors.MapErrorToHTTP(err)
+	}
+	return run, true, nil
+}
+
+func (r *jobRepository) FinishJobRun(ctx context.Context, runID int, status, errMsg string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE job_runs SET status = $1, error = NULLIF($2, ''), finished_at = now() WHERE id = $3", status, errMsg, runID)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+func (r *jobRepository) GetJobRuns(ctx context.Context, jobName string, limit int) ([]entity.JobRun, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, job_name, trigger, status, COALESCE(error, ''), scheduled_at, started_at, finished_at FROM job_runs WHERE job_name = $1 ORDER BY started_at DESC LIMIT $2", jobName, limit)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var runs []entity.JobRun
+	for rows.Next() {
+		var run entity.JobRun
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.JobName, &run.Trigger, &run.Status, &run.Error, &run.ScheduledAt, &run.StartedAt, &finishedAt); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return runs, nil
+}
+
+// PurgeJobRuns удаляет завершённые запуски, закончившиеся раньше finishedBefore; незавершённые остаются
+func (r *jobRepository) PurgeJobRuns(ctx context.Context, finishedBefore time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM job_runs WHERE finished_at < $1", finishedBefore)
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	return int(purged), nil
+}
+
+// TryJobLock берёт advisory lock на имя задачи, чтобы задачу выполняла только одна реплика.
+// Блокировка сессионная, поэтому соединение удерживается до вызова unlock.
+func (r *jobRepository) TryJobLock(ctx context.Context, jobName string) (func(), bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, errors.MapErrorToHTTP(err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", jobName).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, errors.MapErrorToHTTP(err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", jobName)
+		conn.Close()
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+// oaiRecordsQuery - книги вместе с удалёнными (для заголовков status="deleted").
+// Datestamp учитывает изменение автора, так как dc:creator берётся из него.
+const oaiRecordsQuery = `SELECT id, title, year, isbn, author_id, first_name, last_name, datestamp, deleted FROM (
+	SELECT b.id, COALESCE(b.title, '') AS title, COALESCE(b.year, 0) AS year, COALESCE(b.isbn, '') AS isbn, COALESCE(b.author_id, 0) AS author_id,
+		COALESCE(a.first_name, '') AS first_name, COALESCE(a.last_name, '') AS last_name,
+		GREATEST(b.updated_at, b.deleted_at, a.updated_at) AS datestamp, b.deleted_at IS NOT NULL AS deleted
+	FROM books b LEFT JOIN authors a ON a.id = b.author_id
+) r WHERE true`
+
+type OAIRepository interface {
+	GetEarliestDatestamp(ctx context.Context) (time.Time, error)
+	GetOAIRecords(ctx context.Context, filter entity.OAIFilter) ([]entity.OAIRecord, error)
+	GetOAIRecord(ctx context.Context, bookID int) (entity.OAIRecord, error)
+}
+
+type oaiRepository struct {
+	db *sql.DB
+}
+
+func NewOAIRepository(db *sql.DB) OAIRepository {
+	return &oaiRepository{
+		db: db,
+	}
+}
+
+func (r *oaiRepository) GetEarliestDatestamp(ctx context.Context) (time.Time, error) {
+	var earliest sql.NullTime
+	if err := r.db.QueryRowContext(ctx, "SELECT MIN(created_at) FROM books").Scan(&earliest); err != nil {
+		return time.Time{}, errors.MapErrorToHTTP(err)
+	}
+	if !earliest.Valid {
+		return time.Now(), nil
+	}
+	return earliest.Time, nil
+}
+
+// GetOAIRecords возвращает страницу записей; границы From/Until сравниваются с точностью до секунды,
+// как datestamp показывается сборщику
+func (r *oaiRepository) GetOAIRecords(ctx context.Context, filter entity.OAIFilter) ([]entity.OAIRecord, error) {
+	query := oaiRecordsQuery
+	var args []interface{}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += " AND date_trunc('second', datestamp) >= $" + strconv.Itoa(len(args))
+	}
+	if filter.Until != nil {
+		args = append(args, *filter.Until)
+		query += " AND date_trunc('second', datestamp) <= $" + strconv.Itoa(len(args))
+	}
+	if filter.AfterDatestamp != nil {
+		args = append(args, *filter.AfterDatestamp, filter.AfterID)
+		query += " AND (datestamp, id) > ($" + strconv.Itoa(len(args)-1) + ", $" + strconv.Itoa(len(args)) + ")"
+	}
+	args = append(args, filter.Limit)
+	query += " ORDER BY datestamp, id LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var records []entity.OAIRecord
+	for rows.Next() {
+		record, err := scanOAIRecord(rows)
+		if err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return records, nil
+}
+
+func (r *oaiRepository) GetOAIRecord(ctx context.Context, bookID int) (entity.OAIRecord, error) {
+	record, err := scanOAIRecord(r.db.QueryRowContext(ctx, oaiRecordsQuery+" AND id = $1", bookID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return record, errors.ErrNotFound
+		}
+		return record, errors.WrapDB(err)
+	}
+	return record, nil
+}
+
+func scanOAIRecord(row rowScanner) (entity.OAIRecord, error) {
+	var record entity.OAIRecord
+	err := row.Scan(&record.ID, &record.Title, &record.Year, &record.ISBN, &record.AuthorID,
+		&record.Author.FirstName, &record.Author.LastName, &record.Datestamp, &record.Deleted)
+	record.Author.ID = record.AuthorID
+	return record, err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	NotificationPending = "pending"
+	NotificationSending = "sending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+)
+
+type OutboxRepository interface {
+	EnqueueNotification(ctx context.Context, notification entity.Notification) (int, error)
+	ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]entity.Notification, error)
+	MarkNotificationSent(ctx context.Context, notificationID int) error
+	MarkNotificationFailed(ctx context.Context, notificationID int, errMsg string, nextAttemptAt *time.Time) error
+}
+
+type outboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+func (r *outboxRepository) EnqueueNotification(ctx context.Context, notification entity.Notification) (int, error) {
+	var notificationID int
+	err := r.db.QueryRowContext(ctx, "INSERT INTO notification_outbox (kind, recipient, subject, body) VALUES ($1, $2, $3, $4) RETURNING id", notification.Kind, notification.Recipient, notification.Subject, notification.Body).Scan(&notificationID)
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	return notificationID, nil
+}
+
+// ClaimNotifications забирает готовые к отправке уведомления на время lease.
+// Если процесс упадёт во время отправки, после истечения lease уведомления снова станут доступны.
+func (r *outboxRepository) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]entity.Notification, error) {
+	rows, err := r.db.QueryContext(ctx, `UPDATE notification_outbox SET status = $1, attempts = attempts + 1, locked_until = now() + $2 * interval '1 second'
+		WHERE id IN (
+			SELECT id FROM notification_outbox
+			WHERE (status = $3 AND next_attempt_at <= now()) OR (status = $1 AND locked_until < now())
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, recipient, subject, body, status, attempts, COALESCE(last_error, ''), next_attempt_at, created_at`,
+		NotificationSending, lease.Seconds(), NotificationPending, limit)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var notifications []entity.Notification
+	for rows.Next() {
+		var n entity.Notification
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Recipient, &n.Subject, &n.Body, &n.Status, &n.Attempts, &n.LastError, &n.NextAttemptAt, &n.CreatedAt); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return notifications, nil
+}
+
+func (r *outboxRepository) MarkNotificationSent(ctx context.Context, notificationID int) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE notification_outbox SET status = $1, sent_at = now(), locked_until = NULL, last_error = NULL WHERE id = $2", NotificationSent, notificationID)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+// MarkNotificationFailed откладывает повторную попытку до nextAttemptAt; nil означает, что попытки исчерпаны
+func (r *outboxRepository) MarkNotificationFailed(ctx context.Context, notificationID int, errMsg string, nextAttemptAt *time.Time) error {
+	var err error
+	if nextAttemptAt == nil {
+		_, err = r.db.ExecContext(ctx, "UPDATE notification_outbox SET status = $1, last_error = $2, locked_until = NULL WHERE id = $3", NotificationFailed, errMsg, notificationID)
+	} else {
+		_, err = r.db.ExecContext(ctx, "UPDATE notification_outbox SET status = $1, last_error = $2, next_attempt_at = $3, locked_until = NULL WHERE id = $4", NotificationPending, errMsg, *nextAttemptAt, notificationID)
+	}
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
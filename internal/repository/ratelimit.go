@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RateLimitRepository interface {
+	TakeRateLimitTokens(ctx context.Context, key string, cost, burst, rate float64) (tokens float64, allowed bool, err error)
+	DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (int, error)
+}
+
+type rateLimitRepository struct {
+	db *sql.DB
+}
+
+func NewRateLimitRepository(db *sql.DB) RateLimitRepository {
+	return &rateLimitRepository{
+		db: db,
+	}
+}
+
+// TakeRateLimitTokens пополняет корзину за прошедшее время (rate токенов в секунду, не больше burst)
+// и списывает cost, если токенов хватает. Всё делается одним запросом, поэтому одновременные запросы
+// разных экземпляров сервиса к одной корзине не теряют списаний. Время начала запроса, ожидавшего
+// блокировку строки, может быть раньше updated_at, поэтому прошедшее время не бывает отрицательным.
+func (r *rateLimitRepository) TakeRateLimitTokens(ctx context.Context, key string, cost, burst, rate float64) (float64, bool, error) {
+	var tokens float64
+	var allowed bool
+	err := r.db.QueryRowContext(ctx, `INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, CASE WHEN $3::float8 >= $2::float8 THEN $3::float8 - $2::float8 ELSE $3::float8 END, $3::float8 >= $2::float8, now())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE
+				WHEN LEAST($3::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $4::float8) >= $2::float8
+				THEN LEAST($3::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $4::float8) - $2::float8
+				ELSE LEAST($3::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $4::float8)
+			END,
+			allowed = LEAST($3::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $4::float8) >= $2::float8,
+			updated_at = GREATEST(b.updated_at, now())
+		RETURNING tokens, allowed`, key, cost, burst, rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return 0, false, errors.MapErrorToHTTP(err)
+	}
+	return tokens, allowed, nil
+}
+
+// DeleteIdleRateLimitBuckets удаляет корзины, к которым не обращались дольше idle: они уже заполнились
+func (r *rateLimitRepository) DeleteIdleRateLimitBuckets(ctx context.Context, idle time.Duration) (int, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < now() - $1::float8 * interval '1 second'", idle.Seconds())
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+type RBACRepository interface {
+	GetRoles(ctx context.Context) ([]entity.Role, error)
+	GetRole(ctx context.Context, name string) (entity.Role, error)
+	CreateRole(ctx context.Context, role entity.Role) error
+	UpdateRole(ctx context.Context, role entity.Role) error
+	DeleteRole(ctx context.Context, name string) error
+	GetUserRoles(ctx context.Context, userID int) ([]string, error)
+	SetUserRoles(ctx context.Context, userID int, roles []string) error
+	GetUserPermissions(ctx context.Context, userID int) ([]string, error)
+}
+
+type rbacRepository struct {
+	db *sql.DB
+}
+
+func NewRBACRepository(db *sql.DB) RBACRepository {
+	return &rbacRepository{
+		db: db,
+	}
+}
+
+func (r *rbacRepository) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT r.name, r.description, COALESCE(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}')
+		FROM roles r LEFT JOIN role_permissions p ON p.role = r.name
+		GROUP BY r.name, r.description ORDER BY r.name`)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var roles []entity.Role
+	for rows.Next() {
+		var role entity.Role
+		if err := rows.Scan(&role.Name, &role.Description, pq.Array(&role.Permissions)); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return roles, nil
+}
+
+func (r *rbacRepository) GetRole(ctx context.Context, name string) (entity.Role, error) {
+	var role entity.Role
+	err := r.db.QueryRowContext(ctx, `SELECT r.name, r.description, COALESCE(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}')
+		FROM roles r LEFT JOIN role_permissions p ON p.role = r.name
+		WHERE r.name = $1 GROUP BY r.name, r.description`, name).Scan(&role.Name, &role.Description, pq.Array(&role.Permissions))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return role, errors.ErrNotFound
+		}
+		return role, errors.WrapDB(err)
+	}
+	return role, nil
+}
+
+func (r *rbacRepository) CreateRole(ctx context.Context, role entity.Role) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO roles (name, description) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING", role.Name, role.Description)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.NewHTTPError(http.StatusConflict, "role already exists", "CreateRole")
+	}
+	if err := insertRolePermissions(ctx, tx, role); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+// UpdateRole меняет описание и полностью заменяет набор прав роли
+func (r *rbacRepository) UpdateRole(ctx context.Context, role entity.Role) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	defer tx.Rollback()
+	managers, err := lockRoleManagers(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "UPDATE roles SET description = $1 WHERE name = $2", role.Description, role.Name)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.NewHTTPError(http.StatusNotFound, "role not found", "UpdateRole")
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role = $1", role.Name); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	if err := insertRolePermissions(ctx, tx, role); err != nil {
+		return err
+	}
+	if err := keepRoleManagers(ctx, tx, managers, "UpdateRole"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+func insertRolePermissions(ctx context.Context, tx *sql.Tx, role entity.Role) error {
+	if len(role.Permissions) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "INSERT INTO role_permissions (role, permission) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING", role.Name, pq.Array(role.Permissions))
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+func (r *rbacRepository) DeleteRole(ctx context.Context, name string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	defer tx.Rollback()
+	managers, err := lockRoleManagers(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM roles WHERE name = $1", name)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errors.NewHTTPError(http.StatusNotFound, "role not found", "DeleteRole")
+	}
+	if err := keepRoleManagers(ctx, tx, managers, "DeleteRole"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+func (r *rbacRepository) GetUserRoles(ctx context.Context, userID int) ([]string, error) {
+	var roles []string
+	err := r.db.QueryRowContext(ctx, "SELECT COALESCE(array_agg(role ORDER BY role), '{}') FROM user_roles WHERE user_id = $1", userID).Scan(pq.Array(&roles))
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return roles, nil
+}
+
+// SetUserRoles полностью заменяет набор ролей пользователя
+func (r *rbacRepository) SetUserRoles(ctx context.Context, userID int, roles []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	defer tx.Rollback()
+	managers, err := lockRoleManagers(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = $1", userID); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	if len(roles) > 0 {
+		result, err := tx.ExecContext(ctx, "INSERT INTO user_roles (user_id, role) SELECT $1, name FROM roles WHERE name = ANY($2::text[])", userID, pq.Array(roles))
+		if err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+		if rows, _ := result.RowsAffected(); int(rows) != len(roles) {
+			return errors.NewHTTPError(http.StatusBadRequest, "unknown role", "SetUserRoles")
+		}
+	}
+	if err := keepRoleManagers(ctx, tx, managers, "SetUserRoles"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	return nil
+}
+
+func (r *rbacRepository) GetUserPermissions(ctx context.Context, userID int) ([]string, error) {
+	var permissions []string
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(array_agg(DISTINCT p.permission), '{}')
+		FROM user_roles u JOIN role_permissions p ON p.role = u.role
+		WHERE u.user_id = $1`, userID).Scan(pq.Array(&permissions))
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return permissions, nil
+}
+
+// lockRoleManagers блокирует изменения ролей до конца транзакции и возвращает число активных
+// пользователей с правом roles:manage. Без блокировки два параллельных изменения могли бы
+// вместе отобрать право у всех, хотя каждое по отдельности его оставляет.
+func lockRoleManagers(ctx context.Context, tx *sql.Tx) (int, error) {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('rbac'))"); err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	return countRoleManagers(ctx, tx)
+}
+
+// keepRoleManagers отклоняет изменение, после которого управлять ролями стало бы некому
+func keepRoleManagers(ctx context.Context, tx *sql.Tx, before int, source string) error {
+	after, err := countRoleManagers(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if before > 0 && after == 0 {
+		return errors.NewHTTPError(http.StatusConflict, "at least one user must keep the roles:manage permission", source)
+	}
+	return nil
+}
+
+func countRoleManagers(ctx context.Context, tx *sql.Tx) (int, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, `SELECT count(DISTINCT u.id)
+		FROM users u JOIN user_roles ur ON ur.user_id = u.id JOIN role_permissions p ON p.role = ur.role
+		WHERE NOT u.disabled AND p.permission IN ('*', 'roles:*', 'roles:manage')`).Scan(&count)
+	if err != nil {
+		return 0, errors.MapErrorToHTTP(err)
+	}
+	return count, nil
+}
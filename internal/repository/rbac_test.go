@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// managerDB - соединение, отвечающее на запросы транзакций изменения ролей. counts - число
+// пользователей с правом roles:manage, которое вернут подсчёты по порядку: до изменения и после.
+type managerDB struct {
+	counts    []int64
+	committed bool
+}
+
+func (c *managerDB) Connect(ctx context.Context) (driver.Conn, error) { return c, nil }
+func (c *managerDB) Driver() driver.Driver                            { return nil }
+
+func (c *managerDB) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *managerDB) Close() error                              { return nil }
+func (c *managerDB) Begin() (driver.Tx, error)                 { return c, nil }
+
+func (c *managerDB) Commit() error {
+	c.committed = true
+	return nil
+}
+
+func (c *managerDB) Rollback() error { return nil }
+
+func (c *managerDB) CheckNamedValue(v *driver.NamedValue) error {
+	if valuer, ok := v.Value.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		v.Value = value
+		return err
+	}
+	return nil
+}
+
+func (c *managerDB) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	// INSERT INTO user_roles вставляет по строке на каждую роль из массива
+	if strings.Contains(query, "INSERT INTO user_roles") {
+		var roles pq.StringArray
+		roles.Scan(args[1].Value)
+		return driver.RowsAffected(len(roles)), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *managerDB) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.Contains(query, "count(DISTINCT u.id)") {
+		return nil, io.ErrUnexpectedEOF
+	}
+	count := c.counts[0]
+	c.counts = c.counts[1:]
+	return &countRows{count: count}, nil
+}
+
+type countRows struct {
+	count int64
+	done  bool
+}
+
+func (r *countRows) Columns() []string { return []string{"count"} }
+func (r *countRows) Close() error      { return nil }
+
+func (r *countRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.count
+	return nil
+}
+
+func TestKeepRoleManagers(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		counts []int64
+		change func(RBACRepository) error
+		want   int
+	}{
+		{"last manager loses role", []int64{1, 0}, func(r RBACRepository) error { return r.SetUserRoles(ctx, 1, []string{"patron"}) }, http.StatusConflict},
+		{"roles cleared", []int64{1, 0}, func(r RBACRepository) error { return r.SetUserRoles(ctx, 1, nil) }, http.StatusConflict},
+		{"manager role deleted", []int64{2, 0}, func(r RBACRepository) error { return r.DeleteRole(ctx, "librarian") }, http.StatusConflict},
+		{"manage permission removed", []int64{1, 0}, func(r RBACRepository) error {
+			return r.UpdateRole(ctx, entity.Role{Name: "librarian", Permissions: []string{"books:*"}})
+		}, http.StatusConflict},
+		{"another manager remains", []int64{2, 1}, func(r RBACRepository) error { return r.SetUserRoles(ctx, 1, []string{"patron"}) }, 0},
+		{"role granted", []int64{1, 2}, func(r RBACRepository) error { return r.SetUserRoles(ctx, 2, []string{"admin"}) }, 0},
+		// до первого администратора управлять ролями некому, и запрещать изменения нечего
+		{"no managers before", []int64{0, 0}, func(r RBACRepository) error { return r.SetUserRoles(ctx, 1, []string{"patron"}) }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &managerDB{counts: tt.counts}
+			db := sql.OpenDB(conn)
+			defer db.Close()
+
+			err := tt.change(NewRBACRepository(db))
+			if tt.want == 0 {
+				if err != nil || !conn.committed {
+					t.Fatalf("err = %v, committed = %v; want committed change", err, conn.committed)
+				}
+				return
+			}
+			httpErr, ok := err.(*errors.HTTPError)
+			if !ok || httpErr.Code != tt.want {
+				t.Fatalf("err = %v, want HTTP %d", err, tt.want)
+			}
+			if conn.committed {
+				t.Error("rejected change committed")
+			}
+			if len(conn.counts) != 0 {
+				t.Errorf("managers counted %d times less than expected", len(conn.counts))
+			}
+		})
+	}
+}
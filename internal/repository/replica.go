@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ReplicaConfig struct {
+	// сколько после изменения чтения того же клиента идут на основную базу (read-your-writes)
+	StickyWindow time.Duration
+	// реплика с большим отставанием не используется
+	MaxLag time.Duration
+	// как часто проверяется отставание реплик
+	CheckInterval time.Duration
+}
+
+// ReplicaConfigFromEnv читает DB_REPLICA_STICKY_WINDOW и DB_REPLICA_MAX_LAG. Окно привязки не может быть
+// короче допустимого отставания, иначе клиент успел бы прочитать с реплики, ещё не получившей его изменение.
+func ReplicaConfigFromEnv() (ReplicaConfig, error) {
+	cfg := ReplicaConfig{StickyWindow: 5 * time.Second, MaxLag: 2 * time.Second, CheckInterval: time.Second}
+	for _, v := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"DB_REPLICA_STICKY_WINDOW", &cfg.StickyWindow},
+		{"DB_REPLICA_MAX_LAG", &cfg.MaxLag},
+	} {
+		if s := os.Getenv(v.name); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 {
+				return cfg, fmt.Errorf("invalid %s %q: expected a duration such as 5s", v.name, s)
+			}
+			*v.value = d
+		}
+	}
+	if cfg.StickyWindow < cfg.MaxLag+cfg.CheckInterval {
+		return cfg, fmt.Errorf("DB_REPLICA_STICKY_WINDOW must be at least DB_REPLICA_MAX_LAG plus %s", cfg.CheckInterval)
+	}
+	return cfg, nil
+}
+
+type replica struct {
+	name   string
+	db     *sql.DB
+	usable atomic.Bool
+	// была ли хотя бы одна проверка: о реплике, непригодной с самого начала, тоже пишется в лог
+	checked atomic.Bool
+	// отставание в наносекундах по последней проверке
+	lag atomic.Int64
+}
+
+// ReplicaStatus - состояние реплики по последней проверке
+type ReplicaStatus struct {
+	Name   string
+	Usable bool
+	Lag    time.Duration
+}
+
+// Router выбирает базу для запроса: изменения и транзакции идут на основную базу, чтения - на реплики
+// по кругу. Реплика пропускается, если она недоступна или отстаёт больше MaxLag; если подходящих
+// реплик нет, чтение идёт на основную базу.
+type Router struct {
+	primary  *sql.DB
+	replicas []*replica
+	cfg      ReplicaConfig
+	next     atomic.Uint64
+}
+
+// replicas - пулы соединений с репликами и их имена для логов и метрик (обычно host:port)
+func NewRouter(primary *sql.DB, replicas map[string]*sql.DB, cfg ReplicaConfig) *Router {
+	r := &Router{primary: primary, cfg: cfg}
+	names := make([]string, 0, len(replicas))
+	for name := range replicas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.replicas = append(r.replicas, &replica{name: name, db: replicas[name]})
+	}
+	return r
+}
+
+func (r *Router) Primary() *sql.DB {
+	return r.primary
+}
+
+type primaryKey struct{}
+
+// WithPrimary направляет чтения с этим контекстом на основную базу. Нужно, когда прочитанное
+// переживёт запрос: например, сохраняется в кэш, где значение с отстающей реплики продержалось бы весь TTL.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// reader - база для чтения вне транзакции
+func (r *Router) reader(ctx context.Context) *sql.DB {
+	if len(r.replicas) == 0 || ctx.Value(primaryKey{}) != nil || r.sticky(ctx) {
+		return r.primary
+	}
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		candidate := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if candidate.usable.Load() {
+			return candidate.db
+		}
+	}
+	return r.primary
+}
+
+// LastWriteHeader и LastWriteCookie передают клиенту время его последнего изменения в миллисекундах Unix.
+// Клиент возвращает значение в следующих запросах, и StickyWindow после изменения его чтения идут
+// на основную базу, на каком бы экземпляре сервиса они ни выполнялись.
+const (
+	LastWriteHeader = "X-Last-Write"
+	LastWriteCookie = "last_write"
+)
+
+type lastWriteKey struct{}
+
+// lastWrite - время последнего изменения клиента: приходит с запросом и обновляется в Wrote
+type lastWrite struct {
+	at atomic.Int64
+}
+
+// Wrap читает время последнего изменения клиента из X-Last-Write или cookie last_write
+// и возвращает новое, если запрос изменил данные
+func (r *Router) Wrap(next http.Handler) http.Handler {
+	if len(r.replicas) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received := lastWriteFromRequest(req)
+		state := &lastWrite{}
+		state.at.Store(received)
+		ctx := context.WithValue(req.Context(), lastWriteKey{}, state)
+		next.ServeHTTP(&lastWriteWriter{ResponseWriter: w, state: state, received: received, window: r.cfg.StickyWindow}, req.WithContext(ctx))
+	})
+}
+
+func lastWriteFromRequest(req *http.Request) int64 {
+	value := req.Header.Get(LastWriteHeader)
+	if value == "" {
+		if cookie, err := req.Cookie(LastWriteCookie); err == nil {
+			value = cookie.Value
+		}
+	}
+	at, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || at <= 0 {
+		return 0
+	}
+	return at
+}
+
+// lastWriteWriter добавляет к ответу время изменения, если оно сделано в этом запросе
+type lastWriteWriter struct {
+	http.ResponseWriter
+	state       *lastWrite
+	received    int64
+	window      time.Duration
+	wroteHeader bool
+}
+
+func (w *lastWriteWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if at := w.state.at.Load(); at != w.received {
+			value := strconv.FormatInt(at, 10)
+			w.Header().Set(LastWriteHeader, value)
+			http.SetCookie(w, &http.Cookie{
+				Name:     LastWriteCookie,
+				Value:    value,
+				Path:     "/",
+				MaxAge:   int((w.window + time.Second - 1) / time.Second),
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *lastWriteWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *lastWriteWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Wrote отмечает, что запрос из ctx изменил данные: время изменения уходит клиенту в ответе,
+// и до конца запроса его чтения тоже идут на основную базу
+func (r *Router) Wrote(ctx context.Context) {
+	if state, ok := ctx.Value(lastWriteKey{}).(*lastWrite); ok {
+		state.at.Store(time.Now().UnixMilli())
+	}
+}
+
+// sticky сообщает, что клиент изменил данные меньше StickyWindow назад. Время из будущего допускается
+// в пределах того же окна - часы экземпляров расходятся; подставленное клиентом значение дальше
+// в будущем не привязывает его к основной базе.
+func (r *Router) sticky(ctx context.Context) bool {
+	state, ok := ctx.Value(lastWriteKey{}).(*lastWrite)
+	if !ok || state.at.Load() == 0 {
+		return false
+	}
+	since := time.Since(time.UnixMilli(state.at.Load()))
+	return since > -r.cfg.StickyWindow && since < r.cfg.StickyWindow
+}
+
+// Monitor проверяет отставание реплик каждые CheckInterval, пока не отменён ctx.
+// До первой проверки реплики не используются.
+func (r *Router) Monitor(ctx context.Context) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(r.cfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		r.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Replicas возвращает состояние реплик по последней проверке
+func (r *Router) Replicas() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, len(r.replicas))
+	for i, rep := range r.replicas {
+		statuses[i] = ReplicaStatus{Name: rep.name, Usable: rep.usable.Load(), Lag: time.Duration(rep.lag.Load())}
+	}
+	return statuses
+}
+
+func (r *Router) check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func(rep *replica) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, r.cfg.CheckInterval)
+			defer cancel()
+			lag, err := replicationLag(ctx, rep.db)
+			usable := err == nil && lag <= r.cfg.MaxLag
+			if err == nil {
+				rep.lag.Store(int64(lag))
+			}
+			if rep.usable.Swap(usable) != usable || !rep.checked.Swap(true) {
+				switch {
+				case usable:
+					log.Printf("Реплика %s используется для чтения, отставание %s", rep.name, lag)
+				case err != nil:
+					log.Printf("Реплика %s недоступна: %v", rep.name, err)
+				default:
+					log.Printf("Реплика %s отстаёт на %s и не используется", rep.name, lag)
+				}
+			}
+		}(rep)
+	}
+	wg.Wait()
+}
+
+// replicationLag - насколько реплика отстаёт от основной базы по времени последней применённой транзакции.
+// Если реплика применила всё полученное, отставание нулевое, даже когда на основной базе давно не было изменений.
+// Узел не в режиме восстановления - не реплика (например, повышен до основной базы после переключения),
+// и читать с него нельзя: изменения основной базы туда не приходят.
+func replicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	var (
+		inRecovery bool
+		seconds    sql.NullFloat64
+	)
+	err := db.QueryRowContext(ctx, `SELECT pg_is_in_recovery(), CASE
+			WHEN NOT pg_is_in_recovery() THEN NULL
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+		END`).Scan(&inRecovery, &seconds)
+	if err != nil {
+		return 0, err
+	}
+	if !inRecovery {
+		return 0, fmt.Errorf("node is not in recovery and does not replicate from the primary")
+	}
+	if !seconds.Valid {
+		return 0, fmt.Errorf("replica has not replayed any transaction yet")
+	}
+	return time.Duration(max(seconds.Float64, 0) * float64(time.Second)), nil
+}
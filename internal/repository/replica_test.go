@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// replicaDB отвечает на проверку отставания: режим восстановления и отставание в секундах (nil - NULL)
+type replicaDB struct {
+	inRecovery bool
+	lag        driver.Value
+	err        error
+}
+
+func (c *replicaDB) Connect(ctx context.Context) (driver.Conn, error) { return c, nil }
+func (c *replicaDB) Driver() driver.Driver                            { return nil }
+
+func (c *replicaDB) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *replicaDB) Close() error                              { return nil }
+func (c *replicaDB) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (c *replicaDB) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &lagRows{values: []driver.Value{c.inRecovery, c.lag}}, nil
+}
+
+type lagRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *lagRows) Columns() []string { return []string{"pg_is_in_recovery", "lag"} }
+func (r *lagRows) Close() error      { return nil }
+
+func (r *lagRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func newTestRouter(t *testing.T, replicas map[string]*replicaDB) (*Router, map[string]*sql.DB) {
+	t.Helper()
+	dbs := map[string]*sql.DB{"primary": sql.OpenDB(&replicaDB{})}
+	pools := make(map[string]*sql.DB)
+	for name, conn := range replicas {
+		dbs[name] = sql.OpenDB(conn)
+		pools[name] = dbs[name]
+	}
+	t.Cleanup(func() {
+		for _, db := range dbs {
+			db.Close()
+		}
+	})
+	cfg := ReplicaConfig{StickyWindow: 5 * time.Second, MaxLag: 2 * time.Second, CheckInterval: time.Second}
+	return NewRouter(dbs["primary"], pools, cfg), dbs
+}
+
+func TestReaderRouting(t *testing.T) {
+	r, dbs := newTestRouter(t, map[string]*replicaDB{"a": {}, "b": {}})
+	ctx := context.Background()
+
+	// до первой проверки реплики не используются
+	if r.reader(ctx) != dbs["primary"] {
+		t.Error("read went to an unchecked replica")
+	}
+
+	r.replicas[0].usable.Store(true)
+	r.replicas[1].usable.Store(true)
+	seen := map[*sql.DB]int{}
+	for i := 0; i < 4; i++ {
+		seen[r.reader(ctx)]++
+	}
+	if seen[dbs["a"]] != 2 || seen[dbs["b"]] != 2 {
+		t.Errorf("reads not spread across replicas: a=%d b=%d", seen[dbs["a"]], seen[dbs["b"]])
+	}
+
+	r.replicas[0].usable.Store(false)
+	for i := 0; i < 3; i++ {
+		if r.reader(ctx) != dbs["b"] {
+			t.Fatal("read went past the only usable replica")
+		}
+	}
+	if r.reader(WithPrimary(ctx)) != dbs["primary"] {
+		t.Error("WithPrimary read went to a replica")
+	}
+
+	r.replicas[1].usable.Store(false)
+	if r.reader(ctx) != dbs["primary"] {
+		t.Error("read without usable replicas did not fall back to the primary")
+	}
+}
+
+func TestStickiness(t *testing.T) {
+	r, dbs := newTestRouter(t, map[string]*replicaDB{"a": {}})
+	r.replicas[0].usable.Store(true)
+
+	var read *sql.DB
+	handler := r.Wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.Wrote(req.Context())
+		}
+		read = r.reader(req.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(method string, set func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/authors", nil)
+		if set != nil {
+			set(req)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve(http.MethodGet, nil); read != dbs["a"] || w.Header().Get(LastWriteHeader) != "" {
+		t.Fatalf("read without writes: replica %v, %s %q", read == dbs["a"], LastWriteHeader, w.Header().Get(LastWriteHeader))
+	}
+
+	w := serve(http.MethodPost, nil)
+	if read != dbs["primary"] {
+		t.Error("read after a write in the same request went to a replica")
+	}
+	lastWrite := w.Header().Get(LastWriteHeader)
+	cookies := w.Result().Cookies()
+	if lastWrite == "" || len(cookies) != 1 || cookies[0].Name != LastWriteCookie || cookies[0].Value != lastWrite {
+		t.Fatalf("write not returned to the client: %s %q, cookies %v", LastWriteHeader, lastWrite, cookies)
+	}
+
+	// значение, которое вернул клиент, действует на любом экземпляре: состояния в Router нет
+	other, otherDBs := newTestRouter(t, map[string]*replicaDB{"a": {}})
+	other.replicas[0].usable.Store(true)
+	req := httptest.NewRequest(http.MethodGet, "/authors", nil)
+	req.Header.Set(LastWriteHeader, lastWrite)
+	other.Wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		read = other.reader(req.Context())
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	if read != otherDBs["primary"] {
+		t.Error("read with a recent write on another instance went to a replica")
+	}
+
+	at := func(d time.Duration) string { return strconv.FormatInt(time.Now().Add(d).UnixMilli(), 10) }
+	tests := []struct {
+		name    string
+		set     func(*http.Request)
+		primary bool
+	}{
+		{"header", func(req *http.Request) { req.Header.Set(LastWriteHeader, at(-time.Second)) }, true},
+		{"cookie", func(req *http.Request) { req.AddCookie(&http.Cookie{Name: LastWriteCookie, Value: at(-time.Second)}) }, true},
+		{"clock skew", func(req *http.Request) { req.Header.Set(LastWriteHeader, at(time.Second)) }, true},
+		{"window passed", func(req *http.Request) { req.Header.Set(LastWriteHeader, at(-10*time.Second)) }, false},
+		{"far future", func(req *http.Request) { req.Header.Set(LastWriteHeader, at(time.Hour)) }, false},
+		{"malformed", func(req *http.Request) { req.Header.Set(LastWriteHeader, "yesterday") }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(http.MethodGet, tt.set)
+			if (read == dbs["primary"]) != tt.primary {
+				t.Errorf("read from primary = %v, want %v", read == dbs["primary"], tt.primary)
+			}
+			// чтение не продлевает окно
+			if w.Header().Get(LastWriteHeader) != "" {
+				t.Errorf("read returned %s", LastWriteHeader)
+			}
+		})
+	}
+}
+
+func TestCheckLag(t *testing.T) {
+	r, dbs := newTestRouter(t, map[string]*replicaDB{
+		"caught-up": {inRecovery: true, lag: 0.5},
+		"behind":    {inRecovery: true, lag: 30.0},
+		"down":      {err: errors.New("connection refused")},
+		// бывшая реплика, повышенная до основной базы
+		"promoted": {inRecovery: false},
+		"fresh":    {inRecovery: true, lag: nil},
+	})
+	r.check(context.Background())
+
+	want := map[string]bool{"caught-up": true, "behind": false, "down": false, "promoted": false, "fresh": false}
+	for _, status := range r.Replicas() {
+		if status.Usable != want[status.Name] {
+			t.Errorf("%s usable = %v, want %v", status.Name, status.Usable, want[status.Name])
+		}
+		if status.Name == "behind" && status.Lag != 30*time.Second {
+			t.Errorf("behind lag = %s, want 30s", status.Lag)
+		}
+	}
+	for i := 0; i < len(r.replicas); i++ {
+		if read := r.reader(context.Background()); read != dbs["caught-up"] {
+			t.Fatal("read went to a replica that failed the check")
+		}
+	}
+
+	// единственная подходящая реплика отстала - чтения идут на основную базу
+	for _, rep := range r.replicas {
+		if rep.name == "caught-up" {
+			rep.db = dbs["behind"]
+		}
+	}
+	r.check(context.Background())
+	if read := r.reader(context.Background()); read != dbs["primary"] {
+		t.Error("read without usable replicas did not fall back to the primary")
+	}
+}
@@ -3,39 +3,90 @@ package repository
 import (
 	"api_library/internal/entity"
 	"api_library/internal/errors"
+	"context"
 	"database/sql"
-	"log"
 	"net/http"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type Repository interface {
-	GetAllAuthors() ([]entity.Author, error)
-	GetAuthor(authorID int) (entity.Author, error)
-	CreateAuthor(firstName, lastName, biography string, birthDate time.Time) (int, error)
-	UpdateAuthor(authorID int, firstName, lastName, biography string, birthDate time.Time) error
-	DeleteAuthor(authorID int) error
-	GetAllBooks() ([]entity.Book, error)
-	GetBooksByAuthor(authorID int) ([]entity.Book, error)
-	GetBook(bookID int) (entity.Book, error)
-	CreateBook(title string, year int, isbn string, authorID int) (int, error)
-	UpdateBook(bookID int, title string, year int, isbn string) error
-	DeleteBook(bookID int) error
-	UpdateBookAndAuthor(bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error
+	GetAllAuthors(ctx context.Context, filter entity.AuthorFilter) ([]entity.Author, error)
+	GetAuthor(ctx context.Context, authorID int) (entity.Author, error)
+	GetAuthorsByIDs(ctx context.Context, authorIDs []int) ([]entity.Author, error)
+	FindAuthorByName(ctx context.Context, firstName, lastName string) (entity.Author, error)
+	CreateAuthor(ctx context.Context, firstName, lastName, biography string, birthDate time.Time) (int, error)
+	UpdateAuthor(ctx context.Context, authorID int, firstName, lastName, biography string, birthDate time.Time) error
+	DeleteAuthor(ctx context.Context, authorID int) error
+	GetAllBooks(ctx context.Context, filter entity.BookFilter) ([]entity.Book, error)
+	GetBooksByAuthor(ctx context.Context, authorID int) ([]entity.Book, error)
+	GetBooksByAuthors(ctx context.Context, authorIDs []int) ([]entity.Book, error)
+	GetBook(ctx context.Context, bookID int) (entity.Book, error)
+	CreateBook(ctx context.Context, title string, year int, isbn string, authorID int) (int, error)
+	UpdateBook(ctx context.Context, bookID int, title string, year int, isbn string) error
+	DeleteBook(ctx context.Context, bookID int) error
+	UpdateBookAndAuthor(ctx context.Context, bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error
+	RestoreAuthor(ctx context.Context, authorID int) error
+	RestoreBook(ctx context.Context, bookID int) error
+	GetTrash(ctx context.Context) (entity.Trash, error)
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error)
+	GetAuthorVersions(ctx context.Context, authorID int) ([]entity.AuthorVersion, error)
+	GetAuthorAsOf(ctx context.Context, authorID int, asOf time.Time) (entity.Author, error)
+	RevertAuthor(ctx context.Context, authorID, version int) error
+	GetBookVersions(ctx context.Context, bookID int) ([]entity.BookVersion, error)
+	GetBookAsOf(ctx context.Context, bookID int, asOf time.Time) (entity.Book, error)
+	RevertBook(ctx context.Context, bookID, version int) error
+	ExportAuthors(ctx context.Context, filter entity.AuthorFilter, fn func(entity.Author) error) error
+	ExportBooks(ctx context.Context, filter entity.BookFilter, fn func(entity.BookRecord) error) error
 }
 
 type repository struct {
-	db *sql.DB
+	// основная база: изменения и транзакции
+	db     *sql.DB
+	router *Router
 }
 
 func NewRepository(db *sql.DB) Repository {
+	return NewReplicatedRepository(NewRouter(db, nil, ReplicaConfig{}))
+}
+
+// NewReplicatedRepository читает вне транзакций через реплики router, остальное выполняет на основной базе
+func NewReplicatedRepository(router *Router) Repository {
 	return &repository{
-		db: db,
+		db:     router.Primary(),
+		router: router,
 	}
 }
 
-func (r *repository) GetAllAuthors() ([]entity.Author, error) {
-	rows, err := r.db.Query("SELECT id, first_name, last_name, biography, birth_date FROM authors")
+// querier - общее у *sql.DB и *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// inTx выполняет fn в транзакции: изменение и запись в журнал аудита либо применяются вместе, либо откатываются
+func (r *repository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	r.router.Wrote(ctx)
+	return nil
+}
+
+func (r *repository) GetAllAuthors(ctx context.Context, filter entity.AuthorFilter) ([]entity.Author, error) {
+	where, args := authorFilterQuery(filter, "authors")
+	rows, err := r.router.reader(ctx).QueryContext(ctx, "SELECT id, first_name, last_name, biography, birth_date FROM authors WHERE deleted_at IS NULL"+where+" ORDER BY id", args...)
 	if err != nil {
 		return nil, errors.MapErrorToHTTP(err)
 	}
@@ -55,63 +106,224 @@ func (r *repository) GetAllAuthors() ([]entity.Author, error) {
 	return authors, nil
 }
 
-func (r *repository) GetAuthor(authorID int) (entity.Author, error) {
+func (r *repository) GetAuthor(ctx context.Context, authorID int) (entity.Author, error) {
+	return getAuthor(ctx, r.router.reader(ctx), authorID, false)
+}
+
+// GetAuthorsByIDs загружает авторов одним запросом; отсутствующие и удалённые пропускаются
+func (r *repository) GetAuthorsByIDs(ctx context.Context, authorIDs []int) ([]entity.Author, error) {
+	rows, err := r.router.reader(ctx).QueryContext(ctx, "SELECT id, first_name, last_name, biography, birth_date FROM authors WHERE id = ANY($1::int[]) AND deleted_at IS NULL ORDER BY id", pq.Array(authorIDs))
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var authors []entity.Author
+	for rows.Next() {
+		var author entity.Author
+		if err := rows.Scan(&author.ID, &author.FirstName, &author.LastName, &author.Biography, &author.BirthDate); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		authors = append(authors, author)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	return authors, nil
+}
+
+// FindAuthorByName ищет автора по имени и фамилии без учёта регистра; при совпадениях берётся самый ранний
+func (r *repository) FindAuthorByName(ctx context.Context, firstName, lastName string) (entity.Author, error) {
 	var author entity.Author
-	err := r.db.QueryRow("SELECT id, first_name, last_name, biography, birth_date FROM authors WHERE id = $1", authorID).Scan(&author.ID, &author.FirstName, &author.LastName, &author.Biography, &author.BirthDate)
+	err := r.router.reader(ctx).QueryRowContext(ctx, `SELECT id, first_name, last_name, biography, birth_date FROM authors
+		WHERE lower(COALESCE(first_name, '')) = lower($1) AND lower(COALESCE(last_name, '')) = lower($2) AND deleted_at IS NULL
+		ORDER BY id LIMIT 1`, firstName, lastName).Scan(&author.ID, &author.FirstName, &author.LastName, &author.Biography, &author.BirthDate)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return author, errors.ErrNotFound
 		}
-		return author, errors.ErrDB
+		return author, errors.WrapDB(err)
 	}
 	return author, nil
 }
 
-func (r *repository) CreateAuthor(firstName, lastName, biography string, birthDate time.Time) (int, error) {
+// forUpdate блокирует строку до конца транзакции, чтобы состояние "до" в аудите было точным
+func getAuthor(ctx context.Context, q querier, authorID int, forUpdate bool) (entity.Author, error) {
+	query := "SELECT id, first_name, last_name, biography, birth_date, updated_at FROM authors WHERE id = $1 AND deleted_at IS NULL"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	var author entity.Author
+	err := q.QueryRowContext(ctx, query, authorID).Scan(&author.ID, &author.FirstName, &author.LastName, &author.Biography, &author.BirthDate, &author.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return author, errors.ErrNotFound
+		}
+		return author, errors.WrapDB(err)
+	}
+	return author, nil
+}
+
+func (r *repository) CreateAuthor(ctx context.Context, firstName, lastName, biography string, birthDate time.Time) (int, error) {
 	var authorID int
-	err := r.db.QueryRow("INSERT INTO authors (first_name, last_name, biography, birth_date) VALUES ($1, $2, $3, $4) RETURNING id", firstName, lastName, biography, birthDate).Scan(&authorID)
-	log.Printf("error=%+v", err)
+	err := r.inTx(ctx, func(tx *sql.Tx) (err error) {
+		authorID, err = createAuthor(ctx, tx, firstName, lastName, biography, birthDate)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return authorID, nil
+}
+
+func createAuthor(ctx context.Context, tx *sql.Tx, firstName, lastName, biography string, birthDate time.Time) (int, error) {
+	var authorID int
+	err := tx.QueryRowContext(ctx, "INSERT INTO authors (first_name, last_name, biography, birth_date) VALUES ($1, $2, $3, $4) RETURNING id", firstName, lastName, biography, birthDate).Scan(&authorID)
 	if err != nil {
 		return 0, errors.MapErrorToHTTP(err)
 	}
+	after := entity.Author{ID: authorID, FirstName: firstName, LastName: lastName, Biography: biography, BirthDate: entity.Date{Time: birthDate}}
+	if err := writeAudit(ctx, tx, AuditCreate, AuditEntityAuthor, authorID, nil, &after); err != nil {
+		return 0, err
+	}
 	return authorID, nil
 }
 
-func (r *repository) UpdateAuthor(authorID int, firstName, lastName, biography string, birthDate time.Time) error {
-	result, err := r.db.Exec("UPDATE authors SET first_name = $1, last_name = $2, biography = $3, birth_date = $4 WHERE id = $5", firstName, lastName, biography, birthDate, authorID)
-	rows, _ := result.RowsAffected()
+func (r *repository) UpdateAuthor(ctx context.Context, authorID int, firstName, lastName, biography string, birthDate time.Time) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return updateAuthor(ctx, tx, authorID, firstName, lastName, biography, birthDate, "UpdateAuthor")
+	})
+}
+
+func updateAuthor(ctx context.Context, tx *sql.Tx, authorID int, firstName, lastName, biography string, birthDate time.Time, source string) error {
+	before, err := getAuthor(ctx, tx, authorID, true)
+	if err == errors.ErrNotFound {
+		return errors.NewHTTPError(http.StatusNotFound, "author not found", source)
+	} else if err != nil {
+		return err
+	}
+
+	if err := saveAuthorVersion(ctx, tx, authorID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE authors SET first_name = $1, last_name = $2, biography = $3, birth_date = $4, updated_at = now() WHERE id = $5", firstName, lastName, biography, birthDate, authorID)
 	if err != nil {
 		return errors.MapErrorToHTTP(err)
-	} else if rows == 0 {
-		return errors.NewHTTPError(http.StatusNotFound, "author not found", "UpdateAuthor")
 	}
+	after := entity.Author{ID: authorID, FirstName: firstName, LastName: lastName, Biography: biography, BirthDate: entity.Date{Time: birthDate}}
+	return writeAudit(ctx, tx, AuditUpdate, AuditEntityAuthor, authorID, &before, &after)
+}
 
-	return nil
+// DeleteAuthor помещает автора и его книги в корзину с одной меткой времени,
+// чтобы при восстановлении автора вернулись и книги, удалённые вместе с ним.
+// now() в PostgreSQL - время начала транзакции, поэтому метка у автора и книг общая.
+func (r *repository) DeleteAuthor(ctx context.Context, authorID int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return deleteAuthor(ctx, tx, authorID)
+	})
 }
 
-func (r *repository) DeleteAuthor(authorID int) error {
-	result, err := r.db.Exec("DELETE FROM authors WHERE id = $1", authorID)
-	rows, _ := result.RowsAffected()
-	if err != nil {
+func deleteAuthor(ctx context.Context, tx *sql.Tx, authorID int) error {
+	before, err := getAuthor(ctx, tx, authorID, true)
+	if err == errors.ErrNotFound {
+		return errors.NewHTTPError(http.StatusNotFound, "author not found", "DeleteAuthor")
+	} else if err != nil {
+		return err
+	}
+
+	// updated_at меняется вместе с deleted_at, чтобы удаление было видно в выборках по дате изменения (OAI-PMH)
+	if _, err := tx.ExecContext(ctx, "UPDATE authors SET deleted_at = now(), updated_at = now() WHERE id = $1", authorID); err != nil {
 		return errors.MapErrorToHTTP(err)
-	} else if rows == 0 {
-		return errors.NewHTTPError(http.StatusNotFound, "author not found", "UpdateAuthor")
 	}
 
-	books, err := r.GetBooksByAuthor(authorID)
+	books, err := getBooksByAuthor(ctx, tx, authorID)
 	if err != nil {
-		return errors.MapErrorToHTTP(err)
+		return err
 	}
 	for _, book := range books {
-		if err := r.DeleteBook(book.ID); err != nil {
+		if err := deleteBook(ctx, tx, book.ID); err != nil {
+			return err
+		}
+	}
+	return writeAudit(ctx, tx, AuditDelete, AuditEntityAuthor, authorID, &before, nil)
+}
+
+// RestoreAuthor возвращает автора из корзины вместе с книгами, удалёнными в тот же момент
+func (r *repository) RestoreAuthor(ctx context.Context, authorID int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var deletedAt time.Time
+		err := tx.QueryRowContext(ctx, "SELECT deleted_at FROM authors WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE", authorID).Scan(&deletedAt)
+		if err == sql.ErrNoRows {
+			return errors.NewHTTPError(http.StatusNotFound, "deleted author not found", "RestoreAuthor")
+		} else if err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE authors SET deleted_at = NULL, updated_at = now() WHERE id = $1", authorID); err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+		after, err := getAuthor(ctx, tx, authorID, false)
+		if err != nil {
+			return err
+		}
+		if err := writeAudit(ctx, tx, AuditRestore, AuditEntityAuthor, authorID, nil, &after); err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, "SELECT id FROM books WHERE author_id = $1 AND deleted_at = $2", authorID, deletedAt)
+		if err != nil {
 			return errors.MapErrorToHTTP(err)
 		}
+		var bookIDs []int
+		for rows.Next() {
+			var bookID int
+			if err := rows.Scan(&bookID); err != nil {
+				rows.Close()
+				return errors.MapErrorToHTTP(err)
+			}
+			bookIDs = append(bookIDs, bookID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+
+		for _, bookID := range bookIDs {
+			if err := restoreBook(ctx, tx, bookID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *repository) GetAllBooks(ctx context.Context, filter entity.BookFilter) ([]entity.Book, error) {
+	where, args := bookFilterQuery(filter, "books")
+	rows, err := r.router.reader(ctx).QueryContext(ctx, "SELECT id, title, year, isbn, author_id FROM books WHERE deleted_at IS NULL"+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, errors.MapErrorToHTTP(err)
+	}
+	defer rows.Close()
+
+	var books []entity.Book
+	for rows.Next() {
+		var book entity.Book
+		if err := rows.Scan(&book.ID, &book.Title, &book.Year, &book.ISBN, &book.AuthorID); err != nil {
+			return nil, errors.MapErrorToHTTP(err)
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.MapErrorToHTTP(err)
 	}
-	return err
+	return books, nil
 }
 
-func (r *repository) GetAllBooks() ([]entity.Book, error) {
-	rows, err := r.db.Query("SELECT id, title, year, isbn, author_id FROM books")
+func (r *repository) GetBooksByAuthor(ctx context.Context, authorID int) ([]entity.Book, error) {
+	return getBooksByAuthor(ctx, r.router.reader(ctx), authorID)
+}
+
+func getBooksByAuthor(ctx context.Context, q querier, authorID int) ([]entity.Book, error) {
+	rows, err := q.QueryContext(ctx, "SELECT id, title, year, isbn, author_id FROM books WHERE author_id = $1 AND deleted_at IS NULL", authorID)
 	if err != nil {
 		return nil, errors.MapErrorToHTTP(err)
 	}
@@ -131,8 +343,9 @@ func (r *repository) GetAllBooks() ([]entity.Book, error) {
 	return books, nil
 }
 
-func (r *repository) GetBooksByAuthor(authorID int) ([]entity.Book, error) {
-	rows, err := r.db.Query("SELECT id, title, year, isbn, author_id FROM books WHERE author_id = $1", authorID)
+// GetBooksByAuthors загружает книги нескольких авторов одним запросом
+func (r *repository) GetBooksByAuthors(ctx context.Context, authorIDs []int) ([]entity.Book, error) {
+	rows, err := r.router.reader(ctx).QueryContext(ctx, "SELECT id, title, year, isbn, author_id FROM books WHERE author_id = ANY($1::int[]) AND deleted_at IS NULL ORDER BY id", pq.Array(authorIDs))
 	if err != nil {
 		return nil, errors.MapErrorToHTTP(err)
 	}
@@ -152,82 +365,249 @@ func (r *repository) GetBooksByAuthor(authorID int) ([]entity.Book, error) {
 	return books, nil
 }
 
-func (r *repository) GetBook(bookID int) (entity.Book, error) {
+func (r *repository) GetBook(ctx context.Context, bookID int) (entity.Book, error) {
+	return getBook(ctx, r.router.reader(ctx), bookID, false)
+}
+
+func getBook(ctx context.Context, q querier, bookID int, forUpdate bool) (entity.Book, error) {
+	query := "SELECT id, title, year, isbn, author_id, updated_at FROM books WHERE id = $1 AND deleted_at IS NULL"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
 	var book entity.Book
-	err := r.db.QueryRow("SELECT id, title, year, isbn, author_id FROM books WHERE id = $1", bookID).Scan(&book.ID, &book.Title, &book.Year, &book.ISBN, &book.AuthorID)
+	err := q.QueryRowContext(ctx, query, bookID).Scan(&book.ID, &book.Title, &book.Year, &book.ISBN, &book.AuthorID, &book.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return book, errors.ErrNotFound
 		}
-		return book, errors.ErrDB
+		return book, errors.WrapDB(err)
 	}
 	return book, nil
 }
 
-func (r *repository) CreateBook(title string, year int, isbn string, authorID int) (int, error) {
+func (r *repository) CreateBook(ctx context.Context, title string, year int, isbn string, authorID int) (int, error) {
 	var bookID int
-	err := r.db.QueryRow("INSERT INTO books (title, year, isbn, author_id) VALUES ($1, $2, $3, $4) RETURNING id", title, year, isbn, authorID).Scan(&bookID)
+	err := r.inTx(ctx, func(tx *sql.Tx) (err error) {
+		bookID, err = createBook(ctx, tx, title, year, isbn, authorID)
+		return err
+	})
 	if err != nil {
-		return 0, errors.MapErrorToHTTP(err)
+		return 0, err
 	}
 	return bookID, nil
 }
 
-func (r *repository) UpdateBook(bookID int, title string, year int, isbn string) error {
-	result, err := r.db.Exec("UPDATE books SET title = $1, year = $2, isbn = $3 WHERE id = $4", title, year, isbn, bookID)
-	rows, _ := result.RowsAffected()
+func createBook(ctx context.Context, tx *sql.Tx, title string, year int, isbn string, authorID int) (int, error) {
+	// книга автора из корзины была бы удалена вместе с ним при очистке корзины;
+	// FOR SHARE не даёт удалить автора, пока транзакция не завершится
+	var exists bool
+	err := tx.QueryRowContext(ctx, "SELECT true FROM authors WHERE id = $1 AND deleted_at IS NULL FOR SHARE", authorID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return 0, errors.NewHTTPError(http.StatusUnprocessableEntity, "author not found", "CreateBook")
+	} else if err != nil {
+		return 0, errors.WrapDB(err)
+	}
+
+	var bookID int
+	err = tx.QueryRowContext(ctx, "INSERT INTO books (title, year, isbn, author_id) VALUES ($1, $2, $3, $4) RETURNING id", title, year, isbn, authorID).Scan(&bookID)
 	if err != nil {
-		return errors.MapErrorToHTTP(err)
-	} else if rows == 0 {
-		return errors.NewHTTPError(http.StatusNotFound, "book not found", "UpdateBook")
+		return 0, errors.MapErrorToHTTP(err)
 	}
-	return nil
+	after := entity.Book{ID: bookID, Title: title, AuthorID: authorID, Year: year, ISBN: isbn}
+	if err := writeAudit(ctx, tx, AuditCreate, AuditEntityBook, bookID, nil, &after); err != nil {
+		return 0, err
+	}
+	return bookID, nil
 }
 
-func (r *repository) DeleteBook(bookID int) error {
-	result, err := r.db.Exec("DELETE FROM books WHERE id = $1", bookID)
-	rows, _ := result.RowsAffected()
+func (r *repository) UpdateBook(ctx context.Context, bookID int, title string, year int, isbn string) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return updateBook(ctx, tx, bookID, title, year, isbn, "UpdateBook")
+	})
+}
+
+func updateBook(ctx context.Context, tx *sql.Tx, bookID int, title string, year int, isbn string, source string) error {
+	before, err := getBook(ctx, tx, bookID, true)
+	if err == errors.ErrNotFound {
+		return errors.NewHTTPError(http.StatusNotFound, "book not found", source)
+	} else if err != nil {
+		return err
+	}
+
+	if err := saveBookVersion(ctx, tx, bookID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE books SET title = $1, year = $2, isbn = $3, updated_at = now() WHERE id = $4", title, year, isbn, bookID)
 	if err != nil {
 		return errors.MapErrorToHTTP(err)
-	} else if rows == 0 {
-		return errors.NewHTTPError(http.StatusNotFound, "book not found", "DeleteBook")
 	}
-	return nil
+	after := before
+	after.Title, after.Year, after.ISBN = title, year, isbn
+	return writeAudit(ctx, tx, AuditUpdate, AuditEntityBook, bookID, &before, &after)
 }
 
-func (r *repository) UpdateBookAndAuthor(bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error {
-	tx, err := r.db.Begin()
-	if err != nil {
+func (r *repository) DeleteBook(ctx context.Context, bookID int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return deleteBook(ctx, tx, bookID)
+	})
+}
+
+// deleteBook помещает книгу в корзину
+func deleteBook(ctx context.Context, tx *sql.Tx, bookID int) error {
+	before, err := getBook(ctx, tx, bookID, true)
+	if err == errors.ErrNotFound {
+		return errors.NewHTTPError(http.StatusNotFound, "book not found", "DeleteBook")
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE books SET deleted_at = now(), updated_at = now() WHERE id = $1", bookID); err != nil {
 		return errors.MapErrorToHTTP(err)
 	}
+	return writeAudit(ctx, tx, AuditDelete, AuditEntityBook, bookID, &before, nil)
+}
 
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-			errors.MapErrorToHTTP(err)
-		} else {
-			err = tx.Commit()
+func (r *repository) RestoreBook(ctx context.Context, bookID int) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var authorDeleted bool
+		err := tx.QueryRowContext(ctx, "SELECT a.deleted_at IS NOT NULL FROM books b LEFT JOIN authors a ON a.id = b.author_id WHERE b.id = $1 AND b.deleted_at IS NOT NULL", bookID).Scan(&authorDeleted)
+		if err == sql.ErrNoRows {
+			return errors.NewHTTPError(http.StatusNotFound, "deleted book not found", "RestoreBook")
+		} else if err != nil {
+			return errors.MapErrorToHTTP(err)
 		}
-	}()
+		if authorDeleted {
+			return errors.NewHTTPError(http.StatusConflict, "book author is deleted, restore the author first", "RestoreBook")
+		}
+		return restoreBook(ctx, tx, bookID)
+	})
+}
 
-	var result sql.Result
-	var rows int64
+func restoreBook(ctx context.Context, tx *sql.Tx, bookID int) error {
+	if _, err := tx.ExecContext(ctx, "UPDATE books SET deleted_at = NULL, updated_at = now() WHERE id = $1", bookID); err != nil {
+		return errors.MapErrorToHTTP(err)
+	}
+	after, err := getBook(ctx, tx, bookID, false)
+	if err != nil {
+		return err
+	}
+	return writeAudit(ctx, tx, AuditRestore, AuditEntityBook, bookID, nil, &after)
+}
+
+func (r *repository) GetTrash(ctx context.Context) (entity.Trash, error) {
+	trash := entity.Trash{Authors: []entity.Author{}, Books: []entity.Book{}}
 
-	result, err = tx.Exec("UPDATE books SET title = $1, year = $2, isbn = $3 WHERE id = $4", newTitle, newYear, newISBN, bookID)
-	rows, _ = result.RowsAffected()
+	authorRows, err := r.router.reader(ctx).QueryContext(ctx, "SELECT id, first_name, last_name, biography, birth_date, deleted_at FROM authors WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
 	if err != nil {
-		return errors.MapErrorToHTTP(err)
-	} else if rows == 0 {
-		return errors.NewHTTPError(http.StatusNotFound, "book not found", "UpdateBookAndAuthor")
+		return trash, errors.MapErrorToHTTP(err)
+	}
+	defer authorRows.Close()
+	for authorRows.Next() {
+		var author entity.Author
+		if err := authorRows.Scan(&author.ID, &author.FirstName, &author.LastName, &author.Biography, &author.BirthDate, &author.DeletedAt); err != nil {
+			return trash, errors.MapErrorToHTTP(err)
+		}
+		trash.Authors = append(trash.Authors, author)
+	}
+	if err := authorRows.Err(); err != nil {
+		return trash, errors.MapErrorToHTTP(err)
 	}
 
-	result, err = tx.Exec("UPDATE authors SET first_name = $1, last_name = $2, biography = $3, birth_date = $4 WHERE id = $5", newFirstName, newLastName, newBiography, newBirthDate.Format("2006-01-02"), authorID)
-	rows, _ = result.RowsAffected()
+	bookRows, err := r.router.reader(ctx).QueryContext(ctx, "SELECT id, title, year, isbn, author_id, deleted_at FROM books WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
 	if err != nil {
-		return errors.MapErrorToHTTP(err)
-	} else if rows == 0 {
-		return errors.NewHTTPError(http.StatusNotFound, "author not found", "UpdateBookAndAuthor")
+		return trash, errors.MapErrorToHTTP(err)
+	}
+	defer bookRows.Close()
+	for bookRows.Next() {
+		var book entity.Book
+		if err := bookRows.Scan(&book.ID, &book.Title, &book.Year, &book.ISBN, &book.AuthorID, &book.DeletedAt); err != nil {
+			return trash, errors.MapErrorToHTTP(err)
+		}
+		trash.Books = append(trash.Books, book)
+	}
+	if err := bookRows.Err(); err != nil {
+		return trash, errors.MapErrorToHTTP(err)
 	}
+	return trash, nil
+}
 
-	return nil
+// PurgeDeleted окончательно удаляет записи, находящиеся в корзине с момента до deletedBefore
+func (r *repository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var purged int
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		// удаляются только книги из корзины: книги автора попадают туда вместе с ним
+		bookRows, err := tx.QueryContext(ctx, `DELETE FROM books WHERE deleted_at IS NOT NULL AND deleted_at < $1
+			RETURNING id, title, year, isbn, author_id`, deletedBefore)
+		if err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+		var books []entity.Book
+		for bookRows.Next() {
+			var book entity.Book
+			if err := bookRows.Scan(&book.ID, &book.Title, &book.Year, &book.ISBN, &book.AuthorID); err != nil {
+				bookRows.Close()
+				return errors.MapErrorToHTTP(err)
+			}
+			books = append(books, book)
+		}
+		bookRows.Close()
+		if err := bookRows.Err(); err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+
+		// автор, у которого остались книги вне корзины, остаётся в корзине, чтобы книги не ссылались на удалённого
+		authorRows, err := tx.QueryContext(ctx, `DELETE FROM authors a WHERE a.deleted_at IS NOT NULL AND a.deleted_at < $1
+			AND NOT EXISTS (SELECT 1 FROM books b WHERE b.author_id = a.id)
+			RETURNING id, first_name, last_name, biography, birth_date`, deletedBefore)
+		if err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+		var authors []entity.Author
+		for authorRows.Next() {
+			var author entity.Author
+			if err := authorRows.Scan(&author.ID, &author.FirstName, &author.LastName, &author.Biography, &author.BirthDate); err != nil {
+				authorRows.Close()
+				return errors.MapErrorToHTTP(err)
+			}
+			authors = append(authors, author)
+		}
+		authorRows.Close()
+		if err := authorRows.Err(); err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+
+		// история версий удалённых записей больше не нужна
+		if _, err := tx.ExecContext(ctx, "DELETE FROM book_versions v WHERE NOT EXISTS (SELECT 1 FROM books b WHERE b.id = v.book_id)"); err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM author_versions v WHERE NOT EXISTS (SELECT 1 FROM authors a WHERE a.id = v.author_id)"); err != nil {
+			return errors.MapErrorToHTTP(err)
+		}
+
+		for i := range books {
+			if err := writeAudit(ctx, tx, AuditPurge, AuditEntityBook, books[i].ID, &books[i], nil); err != nil {
+				return err
+			}
+		}
+		for i := range authors {
+			if err := writeAudit(ctx, tx, AuditPurge, AuditEntityAuthor, authors[i].ID, &authors[i], nil); err != nil {
+				return err
+			}
+		}
+		purged = len(books) + len(authors)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+func (r *repository) UpdateBookAndAuthor(ctx context.Context, bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := updateBook(ctx, tx, bookID, newTitle, newYear, newISBN, "UpdateBookAndAuthor"); err != nil {
+			return err
+		}
+		return updateAuthor(ctx, tx, authorID, newFirstName, newLastName, newBiography, newBirthDate, "UpdateBookAndAuthor")
+	})
 }
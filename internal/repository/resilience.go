@@ -0,0 +1,445 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql/driver"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// задержка перед повтором чтения; дальше удваивается до retryMaxDelay
+	retryBaseDelay = 50 * time.Millisecond
+	retryMaxDelay  = time.Second
+)
+
+type ResilienceConfig struct {
+	// сколько раз выполняется чтение при временных сбоях, включая первый раз
+	RetryAttempts int
+	// после скольких сбоев подряд предохранитель размыкается
+	BreakerThreshold int
+	// сколько предохранитель разомкнут до пробного запроса
+	BreakerCooldown time.Duration
+}
+
+// ResilienceConfigFromEnv читает DB_RETRY_ATTEMPTS, DB_BREAKER_THRESHOLD, DB_BREAKER_COOLDOWN
+func ResilienceConfigFromEnv() (ResilienceConfig, error) {
+	cfg := ResilienceConfig{RetryAttempts: 3, BreakerThreshold: 5, BreakerCooldown: 10 * time.Second}
+	for _, v := range []struct {
+		name  string
+		value *int
+	}{
+		{"DB_RETRY_ATTEMPTS", &cfg.RetryAttempts},
+		{"DB_BREAKER_THRESHOLD", &cfg.BreakerThreshold},
+	} {
+		if s := os.Getenv(v.name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				return cfg, fmt.Errorf("invalid %s %q: expected a positive integer", v.name, s)
+			}
+			*v.value = n
+		}
+	}
+	if s := os.Getenv("DB_BREAKER_COOLDOWN"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid DB_BREAKER_COOLDOWN %q: expected a duration such as 10s", s)
+		}
+		cfg.BreakerCooldown = d
+	}
+	return cfg, nil
+}
+
+// Resilience - повторы чтений и предохранитель одной базы. Один экземпляр передаётся всем репозиториям,
+// которые в неё ходят: сбои каталога, аутентификации и ролей размыкают общий предохранитель.
+type Resilience struct {
+	cfg     ResilienceConfig
+	breaker *breaker
+}
+
+// NewResilience создаёт предохранитель: после cfg.BreakerThreshold сбоев соединения подряд все вызовы
+// сразу завершаются ошибкой 503, а через cfg.BreakerCooldown один пробный вызов проверяет, поднялась ли база
+func NewResilience(cfg ResilienceConfig) *Resilience {
+	return &Resilience{cfg: cfg, breaker: &breaker{threshold: cfg.BreakerThreshold, cooldown: cfg.BreakerCooldown}}
+}
+
+// resilientRepository повторяет идемпотентные чтения при временных сбоях базы и не обращается к базе,
+// пока она недоступна. Изменения не повторяются: после разрыва соединения неизвестно, применились ли они.
+type resilientRepository struct {
+	*Resilience
+	next Repository
+}
+
+func NewResilientRepository(next Repository, resilience *Resilience) Repository {
+	return &resilientRepository{Resilience: resilience, next: next}
+}
+
+// callbackError - ошибка функции, которой выгрузка передаёт строки; к состоянию базы она не относится
+type callbackError struct {
+	err error
+}
+
+func (e callbackError) Error() string {
+	return e.err.Error()
+}
+
+// call выполняет fn, если предохранитель замкнут, и сообщает ему результат.
+// Потеря соединения возвращается как 503 с исходной ошибкой внутри.
+func (r *Resilience) call(fn func() error) error {
+	if !r.breaker.allow() {
+		return errors.NewHTTPError(http.StatusServiceUnavailable, "database is unavailable", "")
+	}
+	err := fn()
+	var cbErr callbackError
+	if stderrors.As(err, &cbErr) {
+		r.breaker.done(nil)
+		return cbErr.err
+	}
+	r.breaker.done(err)
+	if isUnavailable(err) {
+		return errors.WrapUnavailable(err)
+	}
+	return err
+}
+
+// read выполняет чтение и повторяет его при временном сбое с экспоненциальной задержкой и разбросом.
+// canRetry, если задан, запрещает повтор, например когда выгрузка уже передала часть строк.
+func (r *Resilience) read(ctx context.Context, canRetry func() bool, fn func() error) error {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := r.call(fn)
+		if err == nil || attempt >= r.cfg.RetryAttempts || !isTransient(err) || (canRetry != nil && !canRetry()) {
+			return err
+		}
+
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
+
+// breaker - предохранитель: замкнут, пока сбоев соединения подряд меньше порога; разомкнут cooldown
+// после последнего сбоя; затем пропускает один пробный вызов, успех которого снова его замыкает
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case isUnavailable(err):
+		b.failures++
+		b.probing = false
+		if b.failures >= b.threshold {
+			if b.failures == b.threshold {
+				log.Printf("База данных недоступна после %d сбоев подряд, запросы отклоняются %s: %v", b.failures, b.cooldown, err)
+			}
+			b.openUntil = time.Now().Add(b.cooldown)
+		}
+	case stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded):
+		// вызов прерван клиентом или таймаутом запроса: о базе ничего не известно
+		b.probing = false
+	default:
+		// остальные ошибки (не найдено, нарушение ограничения) значат, что база отвечает
+		if b.failures >= b.threshold {
+			log.Println("База данных снова доступна")
+		}
+		b.failures = 0
+		b.probing = false
+	}
+}
+
+// isTransient - сбой, после которого тот же запрос может пройти: потеря соединения,
+// конфликт сериализации или взаимоблокировка
+func isTransient(err error) bool {
+	if isUnavailable(err) {
+		return true
+	}
+	var pqErr *pq.Error
+	if stderrors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", "40P01": // serialization_failure, deadlock_detected
+			return true
+		}
+	}
+	return false
+}
+
+// isUnavailable - база недоступна или соединение с ней потеряно
+func isUnavailable(err error) bool {
+	if err == nil || stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if stderrors.Is(err, driver.ErrBadConn) || stderrors.Is(err, io.EOF) || stderrors.Is(err, io.ErrUnexpectedEOF) ||
+		stderrors.Is(err, syscall.ECONNREFUSED) || stderrors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	if stderrors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if stderrors.As(err, &pqErr) {
+		if pqErr.Code.Class() == "08" { // connection_exception
+			return true
+		}
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03", "53300": // admin_shutdown, crash_shutdown, cannot_connect_now, too_many_connections
+			return true
+		}
+	}
+	return false
+}
+
+func (r *resilientRepository) GetAllAuthors(ctx context.Context, filter entity.AuthorFilter) (authors []entity.Author, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		authors, err = r.next.GetAllAuthors(ctx, filter)
+		return err
+	})
+	return authors, err
+}
+
+func (r *resilientRepository) GetAuthor(ctx context.Context, authorID int) (author entity.Author, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		author, err = r.next.GetAuthor(ctx, authorID)
+		return err
+	})
+	return author, err
+}
+
+func (r *resilientRepository) GetAuthorsByIDs(ctx context.Context, authorIDs []int) (authors []entity.Author, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		authors, err = r.next.GetAuthorsByIDs(ctx, authorIDs)
+		return err
+	})
+	return authors, err
+}
+
+func (r *resilientRepository) FindAuthorByName(ctx context.Context, firstName, lastName string) (author entity.Author, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		author, err = r.next.FindAuthorByName(ctx, firstName, lastName)
+		return err
+	})
+	return author, err
+}
+
+func (r *resilientRepository) CreateAuthor(ctx context.Context, firstName, lastName, biography string, birthDate time.Time) (id int, err error) {
+	err = r.call(func() (err error) {
+		id, err = r.next.CreateAuthor(ctx, firstName, lastName, biography, birthDate)
+		return err
+	})
+	return id, err
+}
+
+func (r *resilientRepository) UpdateAuthor(ctx context.Context, authorID int, firstName, lastName, biography string, birthDate time.Time) (err error) {
+	return r.call(func() error {
+		return r.next.UpdateAuthor(ctx, authorID, firstName, lastName, biography, birthDate)
+	})
+}
+
+func (r *resilientRepository) DeleteAuthor(ctx context.Context, authorID int) (err error) {
+	return r.call(func() error {
+		return r.next.DeleteAuthor(ctx, authorID)
+	})
+}
+
+func (r *resilientRepository) GetAllBooks(ctx context.Context, filter entity.BookFilter) (books []entity.Book, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		books, err = r.next.GetAllBooks(ctx, filter)
+		return err
+	})
+	return books, err
+}
+
+func (r *resilientRepository) GetBooksByAuthor(ctx context.Context, authorID int) (books []entity.Book, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		books, err = r.next.GetBooksByAuthor(ctx, authorID)
+		return err
+	})
+	return books, err
+}
+
+func (r *resilientRepository) GetBooksByAuthors(ctx context.Context, authorIDs []int) (books []entity.Book, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		books, err = r.next.GetBooksByAuthors(ctx, authorIDs)
+		return err
+	})
+	return books, err
+}
+
+func (r *resilientRepository) GetBook(ctx context.Context, bookID int) (book entity.Book, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		book, err = r.next.GetBook(ctx, bookID)
+		return err
+	})
+	return book, err
+}
+
+func (r *resilientRepository) CreateBook(ctx context.Context, title string, year int, isbn string, authorID int) (id int, err error) {
+	err = r.call(func() (err error) {
+		id, err = r.next.CreateBook(ctx, title, year, isbn, authorID)
+		return err
+	})
+	return id, err
+}
+
+func (r *resilientRepository) UpdateBook(ctx context.Context, bookID int, title string, year int, isbn string) (err error) {
+	return r.call(func() error {
+		return r.next.UpdateBook(ctx, bookID, title, year, isbn)
+	})
+}
+
+func (r *resilientRepository) DeleteBook(ctx context.Context, bookID int) (err error) {
+	return r.call(func() error {
+		return r.next.DeleteBook(ctx, bookID)
+	})
+}
+
+func (r *resilientRepository) UpdateBookAndAuthor(ctx context.Context, bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) (err error) {
+	return r.call(func() error {
+		return r.next.UpdateBookAndAuthor(ctx, bookID, newTitle, newYear, newISBN, authorID, newFirstName, newLastName, newBiography, newBirthDate)
+	})
+}
+
+func (r *resilientRepository) RestoreAuthor(ctx context.Context, authorID int) (err error) {
+	return r.call(func() error {
+		return r.next.RestoreAuthor(ctx, authorID)
+	})
+}
+
+func (r *resilientRepository) RestoreBook(ctx context.Context, bookID int) (err error) {
+	return r.call(func() error {
+		return r.next.RestoreBook(ctx, bookID)
+	})
+}
+
+func (r *resilientRepository) GetTrash(ctx context.Context) (trash entity.Trash, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		trash, err = r.next.GetTrash(ctx)
+		return err
+	})
+	return trash, err
+}
+
+func (r *resilientRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (purged int, err error) {
+	err = r.call(func() (err error) {
+		purged, err = r.next.PurgeDeleted(ctx, deletedBefore)
+		return err
+	})
+	return purged, err
+}
+
+func (r *resilientRepository) GetAuthorVersions(ctx context.Context, authorID int) (versions []entity.AuthorVersion, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		versions, err = r.next.GetAuthorVersions(ctx, authorID)
+		return err
+	})
+	return versions, err
+}
+
+func (r *resilientRepository) GetAuthorAsOf(ctx context.Context, authorID int, asOf time.Time) (author entity.Author, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		author, err = r.next.GetAuthorAsOf(ctx, authorID, asOf)
+		return err
+	})
+	return author, err
+}
+
+func (r *resilientRepository) RevertAuthor(ctx context.Context, authorID, version int) (err error) {
+	return r.call(func() error {
+		return r.next.RevertAuthor(ctx, authorID, version)
+	})
+}
+
+func (r *resilientRepository) GetBookVersions(ctx context.Context, bookID int) (versions []entity.BookVersion, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		versions, err = r.next.GetBookVersions(ctx, bookID)
+		return err
+	})
+	return versions, err
+}
+
+func (r *resilientRepository) GetBookAsOf(ctx context.Context, bookID int, asOf time.Time) (book entity.Book, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		book, err = r.next.GetBookAsOf(ctx, bookID, asOf)
+		return err
+	})
+	return book, err
+}
+
+func (r *resilientRepository) RevertBook(ctx context.Context, bookID, version int) (err error) {
+	return r.call(func() error {
+		return r.next.RevertBook(ctx, bookID, version)
+	})
+}
+
+// ExportAuthors повторяется, только если ни одна строка ещё не передана в fn
+func (r *resilientRepository) ExportAuthors(ctx context.Context, filter entity.AuthorFilter, fn func(entity.Author) error) error {
+	delivered := false
+	return r.read(ctx, func() bool { return !delivered }, func() error {
+		var fnErr error
+		err := r.next.ExportAuthors(ctx, filter, func(author entity.Author) error {
+			delivered = true
+			fnErr = fn(author)
+			return fnErr
+		})
+		if err != nil && err == fnErr {
+			return callbackError{err: err}
+		}
+		return err
+	})
+}
+
+func (r *resilientRepository) ExportBooks(ctx context.Context, filter entity.BookFilter, fn func(entity.BookRecord) error) error {
+	delivered := false
+	return r.read(ctx, func() bool { return !delivered }, func() error {
+		var fnErr error
+		err := r.next.ExportBooks(ctx, filter, func(record entity.BookRecord) error {
+			delivered = true
+			fnErr = fn(record)
+			return fnErr
+		})
+		if err != nil && err == fnErr {
+			return callbackError{err: err}
+		}
+		return err
+	})
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"context"
+)
+
+// resilientAuthRepository - повторы и предохранитель для аутентификации: она обращается к базе
+// на каждом запросе раньше каталога, и при недоступной базе клиент должен получить 503, а не 500
+type resilientAuthRepository struct {
+	*Resilience
+	next AuthRepository
+}
+
+func NewResilientAuthRepository(next AuthRepository, resilience *Resilience) AuthRepository {
+	return &resilientAuthRepository{Resilience: resilience, next: next}
+}
+
+func (r *resilientAuthRepository) GetUser(ctx context.Context, userID int) (user entity.User, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		user, err = r.next.GetUser(ctx, userID)
+		return err
+	})
+	return user, err
+}
+
+func (r *resilientAuthRepository) GetUserByUsername(ctx context.Context, username string) (user entity.User, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		user, err = r.next.GetUserByUsername(ctx, username)
+		return err
+	})
+	return user, err
+}
+
+func (r *resilientAuthRepository) CreateUser(ctx context.Context, username, passwordHash string) (userID int, err error) {
+	err = r.call(func() (err error) {
+		userID, err = r.next.CreateUser(ctx, username, passwordHash)
+		return err
+	})
+	return userID, err
+}
+
+func (r *resilientAuthRepository) GetAPIKeys(ctx context.Context, userID int) (keys []entity.APIKey, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		keys, err = r.next.GetAPIKeys(ctx, userID)
+		return err
+	})
+	return keys, err
+}
+
+func (r *resilientAuthRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (key entity.APIKey, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		key, err = r.next.GetAPIKeyByHash(ctx, keyHash)
+		return err
+	})
+	return key, err
+}
+
+func (r *resilientAuthRepository) CreateAPIKey(ctx context.Context, key entity.APIKey) (created entity.APIKey, err error) {
+	err = r.call(func() (err error) {
+		created, err = r.next.CreateAPIKey(ctx, key)
+		return err
+	})
+	return created, err
+}
+
+func (r *resilientAuthRepository) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	return r.call(func() error {
+		return r.next.RevokeAPIKey(ctx, userID, keyID)
+	})
+}
+
+func (r *resilientAuthRepository) TouchAPIKey(ctx context.Context, keyID int) error {
+	return r.call(func() error {
+		return r.next.TouchAPIKey(ctx, keyID)
+	})
+}
+
+// resilientRBACRepository - то же для ролей: права загружаются на каждом защищённом маршруте
+type resilientRBACRepository struct {
+	*Resilience
+	next RBACRepository
+}
+
+func NewResilientRBACRepository(next RBACRepository, resilience *Resilience) RBACRepository {
+	return &resilientRBACRepository{Resilience: resilience, next: next}
+}
+
+func (r *resilientRBACRepository) GetRoles(ctx context.Context) (roles []entity.Role, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		roles, err = r.next.GetRoles(ctx)
+		return err
+	})
+	return roles, err
+}
+
+func (r *resilientRBACRepository) GetRole(ctx context.Context, name string) (role entity.Role, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		role, err = r.next.GetRole(ctx, name)
+		return err
+	})
+	return role, err
+}
+
+func (r *resilientRBACRepository) CreateRole(ctx context.Context, role entity.Role) error {
+	return r.call(func() error {
+		return r.next.CreateRole(ctx, role)
+	})
+}
+
+func (r *resilientRBACRepository) UpdateRole(ctx context.Context, role entity.Role) error {
+	return r.call(func() error {
+		return r.next.UpdateRole(ctx, role)
+	})
+}
+
+func (r *resilientRBACRepository) DeleteRole(ctx context.Context, name string) error {
+	return r.call(func() error {
+		return r.next.DeleteRole(ctx, name)
+	})
+}
+
+func (r *resilientRBACRepository) GetUserRoles(ctx context.Context, userID int) (roles []string, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		roles, err = r.next.GetUserRoles(ctx, userID)
+		return err
+	})
+	return roles, err
+}
+
+func (r *resilientRBACRepository) SetUserRoles(ctx context.Context, userID int, roles []string) error {
+	return r.call(func() error {
+		return r.next.SetUserRoles(ctx, userID, roles)
+	})
+}
+
+func (r *resilientRBACRepository) GetUserPermissions(ctx context.Context, userID int) (permissions []string, err error) {
+	err = r.read(ctx, nil, func() (err error) {
+		permissions, err = r.next.GetUserPermissions(ctx, userID)
+		return err
+	})
+	return permissions, err
+}
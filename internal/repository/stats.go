@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"database/sql"
+)
+
+type StatsRepository interface {
+	GetCatalogStats(ctx context.Context) (entity.CatalogStats, error)
+}
+
+type statsRepository struct {
+	db *sql.DB
+}
+
+func NewStatsRepository(db *sql.DB) StatsRepository {
+	return &statsRepository{
+		db: db,
+	}
+}
+
+func (r *statsRepository) GetCatalogStats(ctx context.Context) (entity.CatalogStats, error) {
+	var stats entity.CatalogStats
+	err := r.db.QueryRowContext(ctx, `SELECT
+		(SELECT count(*) FROM authors WHERE deleted_at IS NULL),
+		(SELECT count(*) FROM books WHERE deleted_at IS NULL)`).Scan(&stats.Authors, &stats.Books)
+	if err != nil {
+		return stats, errors.MapErrorToHTTP(err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// 2025-01-15 - среда
+var base = time.Date(2025, time.January, 15, 10, 30, 0, 0, time.UTC)
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", base, base.Add(time.Minute)},
+		{"* * * * *", base.Add(20 * time.Second), base.Add(time.Minute)},
+		{"45 * * * *", base, time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"15 * * * *", base, time.Date(2025, 1, 15, 11, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", base, time.Date(2025, 1, 16, 3, 0, 0, 0, time.UTC)},
+		// диапазоны, шаги и списки
+		{"0 9-17 * * *", base, time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", base, time.Date(2025, 1, 15, 10, 40, 0, 0, time.UTC)},
+		{"10/25 * * * *", base, time.Date(2025, 1, 15, 10, 35, 0, 0, time.UTC)},
+		{"0 0-12/6 * * *", base, time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)},
+		{"5,50 8,22 * * *", base, time.Date(2025, 1, 15, 22, 5, 0, 0, time.UTC)},
+		{"0 0 1 jan,jul *", base, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * mon-fri", time.Date(2025, 1, 17, 12, 0, 0, 0, time.UTC), time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		// воскресенье - и 0, и 7
+		{"0 0 * * 7", base, time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", base, time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		// дескрипторы
+		{"@hourly", base, time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", base, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@midnight", base, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", base, time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", base, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", base, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// переход через конец месяца и года, 29 февраля
+		{"0 0 31 * *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", base, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 23 31 12 *", base, time.Date(2025, 12, 31, 23, 30, 0, 0, time.UTC)},
+		// никогда не срабатывает
+		{"0 0 30 2 *", base, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+// если ограничены и день месяца, и день недели, срабатывает совпадение любого из них;
+// если одно из полей "*", учитывается только другое
+func TestDayOfMonthOrDayOfWeek(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []time.Time
+	}{
+		// 1-е число или понедельник
+		{"0 0 1 * mon", []time.Time{
+			time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
+		}},
+		// 1-е число независимо от дня недели
+		{"0 0 1 * *", []time.Time{
+			time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		// любой понедельник
+		{"0 0 ? * 1", []time.Time{
+			time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from := base
+			for _, want := range tt.want {
+				got := s.Next(from)
+				if !got.Equal(want) {
+					t.Fatalf("Next(%v) = %v, want %v", from, got, want)
+				}
+				from = got
+			}
+		})
+	}
+}
+
+func TestEvery(t *testing.T) {
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"@every 10m", base.Add(3 * time.Minute), base.Add(10 * time.Minute)},
+		{"@every 10m", base, base.Add(10 * time.Minute)},
+		{"@every 1h", base, time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@every  90s ", base.Add(time.Second), base.Add(90 * time.Second)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.spec, err)
+		}
+		// моменты выровнены по интервалу, а не по времени вызова, поэтому у всех реплик совпадают
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%v) = %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"1,,2 * * * *",
+		"@every",
+		"@every 10",
+		"@every 500ms",
+		"@every -1m",
+		"@sometimes",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded", spec)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package scheduler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/repository"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+)
+
+type JobFunc func(ctx context.Context) error
+
+type JobInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Schedule    string         `json:"schedule"`
+	NextRun     *time.Time     `json:"next_run,omitempty"`
+	Running     bool           `json:"running"`
+	LastRun     *entity.JobRun `json:"last_run,omitempty"`
+}
+
+type job struct {
+	name        string
+	description string
+	spec        string
+	schedule    Schedule
+	fn          JobFunc
+	next        time.Time
+	running     bool
+}
+
+type Scheduler struct {
+	repo    repository.JobRepository
+	timeout time.Duration
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// timeout ограничивает время одного запуска задачи
+func New(repo repository.JobRepository, timeout time.Duration) *Scheduler {
+	return &Scheduler{
+		repo:    repo,
+		timeout: timeout,
+		jobs:    make(map[string]*job),
+	}
+}
+
+// Register добавляет задачу в реестр; регистрировать задачи нужно до Start
+func (s *Scheduler) Register(name, spec, description string, fn JobFunc) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job %q already registered", name)
+	}
+	s.jobs[name] = &job{name: name, description: description, spec: spec, schedule: schedule, fn: fn}
+	return nil
+}
+
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+	log.Printf("Планировщик запущен, задач: %d", len(s.jobs))
+}
+
+// Stop останавливает расписание и ждёт завершения выполняющихся задач
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = false
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.wg.Done()
+	for {
+		now := time.Now()
+		next := j.schedule.Next(now)
+		if next.IsZero() {
+			log.Printf("Задача %s: расписание %q больше не сработает", j.name, j.spec)
+			return
+		}
+		s.mu.Lock()
+		j.next = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := s.run(ctx, j, TriggerSchedule, next); err != nil {
+			log.Printf("Задача %s: %v", j.name, err)
+		}
+	}
+}
+
+// Trigger запускает задачу вне расписания и возвращает запись о запуске со статусом running, не дожидаясь
+// завершения. Задача выполняется на контексте, отвязанном от ctx: разрыв соединения клиента её не прерывает.
+// Итог запуска виден в истории задачи.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (entity.JobRun, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return entity.JobRun{}, errors.ErrNotFound
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	run, release, err := s.begin(ctx, j, TriggerManual, time.Now())
+	if err != nil || release == nil {
+		return run, err
+	}
+	// Stop дожидается и запусков вручную
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer release()
+		if _, err := s.finish(ctx, j, run); err != nil {
+			log.Printf("Задача %s: %v", j.name, err)
+		}
+	}()
+	return run, nil
+}
+
+func (s *Scheduler) run(ctx context.Context, j *job, trigger string, scheduledAt time.Time) (entity.JobRun, error) {
+	run, release, err := s.begin(ctx, j, trigger, scheduledAt)
+	if err != nil || release == nil {
+		return run, err
+	}
+	defer release()
+	return s.finish(ctx, j, run)
+}
+
+// begin занимает задачу в этом процессе и в базе и записывает начало запуска.
+// release снимает обе блокировки; он равен nil, если задача не запущена.
+func (s *Scheduler) begin(ctx context.Context, j *job, trigger string, scheduledAt time.Time) (entity.JobRun, func(), error) {
+	s.mu.Lock()
+	if j.running {
+		s.mu.Unlock()
+		return entity.JobRun{JobName: j.name, Trigger: trigger, Status: StatusSkipped}, nil, errors.NewHTTPError(http.StatusConflict, "job is already running", "Scheduler.run")
+	}
+	j.running = true
+	s.mu.Unlock()
+	done := func() {
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+	}
+
+	unlock, locked, err := s.repo.TryJobLock(ctx, j.name)
+	if err != nil {
+		done()
+		return entity.JobRun{}, nil, err
+	}
+	if !locked {
+		done()
+		// задачу уже выполняет другая реплика
+		return entity.JobRun{JobName: j.name, Trigger: trigger, Status: StatusSkipped}, nil, errors.NewHTTPError(http.StatusConflict, "job is running on another instance", "Scheduler.run")
+	}
+
+	run, started, err := s.repo.StartJobRun(ctx, j.name, trigger, scheduledAt)
+	if err != nil || !started {
+		unlock()
+		done()
+		if err == nil {
+			run.Status = StatusSkipped
+		}
+		return run, nil, err
+	}
+	return run, func() {
+		unlock()
+		done()
+	}, nil
+}
+
+// finish выполняет задачу и записывает итог запуска
+func (s *Scheduler) finish(ctx context.Context, j *job, run entity.JobRun) (entity.JobRun, error) {
+	runCtx := ctx
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	run.Status = StatusSucceeded
+	if jobErr := s.execute(runCtx, j); jobErr != nil {
+		run.Status = StatusFailed
+		run.Error = jobErr.Error()
+		log.Printf("Задача %s завершилась с ошибкой: %v", j.name, jobErr)
+	}
+	if err := s.repo.FinishJobRun(context.Background(), run.ID, run.Status, run.Error); err != nil {
+		return run, err
+	}
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	return run, nil
+}
+
+// паника в задаче не должна ронять весь сервис
+func (s *Scheduler) execute(ctx context.Context, j *job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.fn(ctx)
+}
+
+func (s *Scheduler) Jobs(ctx context.Context) ([]JobInfo, error) {
+	s.mu.Lock()
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		info := JobInfo{Name: j.name, Description: j.description, Schedule: j.spec, Running: j.running}
+		if !j.next.IsZero() {
+			next := j.next
+			info.NextRun = &next
+		}
+		infos = append(infos, info)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(infos, func(i, k int) bool { return infos[i].Name < infos[k].Name })
+	for i := range infos {
+		runs, err := s.repo.GetJobRuns(ctx, infos[i].Name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			infos[i].LastRun = &runs[0]
+		}
+	}
+	return infos, nil
+}
+
+// History возвращает последние запуски задачи
+func (s *Scheduler) History(ctx context.Context, name string, limit int) ([]entity.JobRun, error) {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	return s.repo.GetJobRuns(ctx, name, limit)
+}
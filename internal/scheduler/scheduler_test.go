@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/repository"
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeJobRepository struct {
+	repository.JobRepository
+
+	mu       sync.Mutex
+	locked   bool
+	runs     int
+	finished map[int]string
+}
+
+func newFakeJobRepository() *fakeJobRepository {
+	return &fakeJobRepository{finished: make(map[int]string)}
+}
+
+func (f *fakeJobRepository) TryJobLock(ctx context.Context, jobName string) (func(), bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.locked {
+		return nil, false, nil
+	}
+	f.locked = true
+	return func() {
+		f.mu.Lock()
+		f.locked = false
+		f.mu.Unlock()
+	}, true, nil
+}
+
+func (f *fakeJobRepository) StartJobRun(ctx context.Context, jobName, trigger string, scheduledAt time.Time) (entity.JobRun, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs++
+	return entity.JobRun{ID: f.runs, JobName: jobName, Trigger: trigger, Status: StatusRunning, ScheduledAt: scheduledAt, StartedAt: time.Now()}, true, nil
+}
+
+func (f *fakeJobRepository) FinishJobRun(ctx context.Context, runID int, status, errMsg string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.finished[runID] = status
+	return nil
+}
+
+func (f *fakeJobRepository) status(runID int) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.finished[runID]
+}
+
+func TestTriggerOutlivesRequest(t *testing.T) {
+	repo := newFakeJobRepository()
+	s := New(repo, time.Minute)
+	started, release := make(chan struct{}), make(chan struct{})
+	var jobErr error
+	s.Register("slow", "@daily", "", func(ctx context.Context) error {
+		close(started)
+		<-release
+		jobErr = ctx.Err()
+		return ctx.Err()
+	})
+
+	requestCtx, cancel := context.WithCancel(context.Background())
+	run, err := s.Trigger(requestCtx, "slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.ID != 1 || run.Status != StatusRunning || run.Trigger != TriggerManual {
+		t.Fatalf("Trigger returned %+v, want running run with ID", run)
+	}
+	<-started
+
+	// клиент отключился, пока задача выполняется
+	cancel()
+	if _, err := s.Trigger(context.Background(), "slow"); !isConflict(err) {
+		t.Errorf("second Trigger while running: %v, want 409", err)
+	}
+	close(release)
+
+	s.wg.Wait()
+
+	if jobErr != nil {
+		t.Errorf("job context cancelled with the request: %v", jobErr)
+	}
+	if status := repo.status(1); status != StatusSucceeded {
+		t.Errorf("run finished with %q, want %q", status, StatusSucceeded)
+	}
+	if _, err := s.Trigger(context.Background(), "slow"); err != nil {
+		t.Errorf("job not released after finishing: %v", err)
+	}
+}
+
+func TestTriggerWaitsOnStop(t *testing.T) {
+	repo := newFakeJobRepository()
+	s := New(repo, time.Minute)
+	s.Register("job", "@daily", "", func(ctx context.Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	s.Start()
+	if _, err := s.Trigger(context.Background(), "job"); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+	if status := repo.status(1); status != StatusSucceeded {
+		t.Errorf("Stop returned before the triggered run finished: status %q", status)
+	}
+}
+
+func TestTriggerErrors(t *testing.T) {
+	repo := newFakeJobRepository()
+	s := New(repo, time.Minute)
+	s.Register("job", "@daily", "", func(ctx context.Context) error { return nil })
+
+	if _, err := s.Trigger(context.Background(), "missing"); err != errors.ErrNotFound {
+		t.Errorf("unknown job: %v, want ErrNotFound", err)
+	}
+
+	// задачу держит другая реплика
+	repo.locked = true
+	if _, err := s.Trigger(context.Background(), "job"); !isConflict(err) {
+		t.Errorf("locked elsewhere: %v, want 409", err)
+	}
+	if repo.runs != 0 {
+		t.Error("run recorded without the lock")
+	}
+	repo.locked = false
+	if _, err := s.Trigger(context.Background(), "job"); err != nil {
+		t.Errorf("job still marked running after a refused lock: %v", err)
+	}
+	s.wg.Wait()
+}
+
+func isConflict(err error) bool {
+	httpErr, ok := err.(*errors.HTTPError)
+	return ok && httpErr.Code == http.StatusConflict
+}
@@ -0,0 +1,218 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// атрибуты с числом строк: в соглашениях OpenTelemetry для них пока нет имён
+const (
+	rowsAffectedKey = attribute.Key("db.rows_affected")
+	rowsReturnedKey = attribute.Key("db.rows_returned")
+)
+
+// WrapConnector добавляет спан на каждый SQL-запрос, выполненный через соединения коннектора.
+// Подготовленные выражения (COPY при импорте) не трассируются: у них спан был бы на каждую строку.
+func WrapConnector(connector driver.Connector) driver.Connector {
+	return &tracedConnector{Connector: connector, tracer: otel.Tracer(instrumentationName)}
+}
+
+type tracedConnector struct {
+	driver.Connector
+	tracer trace.Tracer
+}
+
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{conn: conn, tracer: c.tracer}, nil
+}
+
+// tracedConn передаёт вызовы соединению драйвера; методы, которых у драйвера нет,
+// возвращают driver.ErrSkip, и database/sql выбирает другой путь
+type tracedConn struct {
+	conn   driver.Conn
+	tracer trace.Tracer
+}
+
+func (c *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(query)
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.conn.Prepare(query)
+}
+
+func (c *tracedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *tracedConn) Begin() (driver.Tx, error) {
+	return c.conn.Begin()
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.conn.Begin()
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.start(ctx, query)
+	defer span.End()
+
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if affected, err := result.RowsAffected(); err == nil {
+		span.SetAttributes(rowsAffectedKey.Int64(affected))
+	}
+	return result, nil
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := c.start(ctx, query)
+
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		recordError(span, err)
+		span.End()
+		return nil, err
+	}
+	// спан завершается, когда прочитаны все строки
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (c *tracedConn) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation, table := statementName(query)
+	name := operation
+	if table != "" {
+		name += " " + table
+	}
+	attributes := []attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(query),
+	}
+	if table != "" {
+		attributes = append(attributes, semconv.DBCollectionName(table))
+	}
+	return c.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+}
+
+type tracedRows struct {
+	driver.Rows
+	span  trace.Span
+	count int64
+	done  bool
+}
+
+func (r *tracedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.count++
+	case err != io.EOF:
+		recordError(r.span, err)
+	}
+	return err
+}
+
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+	if !r.done {
+		r.done = true
+		r.span.SetAttributes(rowsReturnedKey.Int64(r.count))
+		r.span.End()
+	}
+	return err
+}
+
+func (r *tracedRows) HasNextResultSet() bool {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *tracedRows) NextResultSet() error {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.NextResultSet()
+	}
+	return io.EOF
+}
+
+func recordError(span trace.Span, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// statementName - операция и основная таблица запроса для имени спана, например "SELECT books".
+// Таблица определяется по первому FROM, INTO или UPDATE и может отсутствовать.
+func statementName(query string) (operation, table string) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "SQL", ""
+	}
+	operation = strings.ToUpper(fields[0])
+	for i, field := range fields[:len(fields)-1] {
+		switch strings.ToUpper(field) {
+		case "FROM", "INTO", "UPDATE":
+			table = strings.Trim(fields[i+1], "(),;\"")
+			if table != "" && !strings.EqualFold(table, "SELECT") {
+				return operation, table
+			}
+			table = ""
+		}
+	}
+	return operation, ""
+}
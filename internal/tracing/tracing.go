@@ -0,0 +1,103 @@
+// Package tracing - трассировка OpenTelemetry: провайдер с экспортом по OTLP,
+// спаны HTTP-запросов и SQL-запросов. Спаны методов usecase.Service создаёт usecase.NewTracedService.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+
+	defaultServiceName = "api_library"
+)
+
+type Config struct {
+	// none - трассировка выключена, otlp - экспорт по OTLP/HTTP
+	Exporter string
+	// адрес коллектора, например http://otel-collector:4318; пустой - localhost:4318
+	Endpoint    string
+	ServiceName string
+	// доля запросов, для которых записываются спаны (0..1); решение вызывающего сервиса из traceparent соблюдается
+	SampleRatio float64
+}
+
+// ConfigFromEnv читает TRACING_EXPORTER, TRACING_OTLP_ENDPOINT, TRACING_SERVICE_NAME, TRACING_SAMPLE_RATIO
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Exporter:    os.Getenv("TRACING_EXPORTER"),
+		Endpoint:    os.Getenv("TRACING_OTLP_ENDPOINT"),
+		ServiceName: os.Getenv("TRACING_SERVICE_NAME"),
+		SampleRatio: 1,
+	}
+	if cfg.Exporter == "" {
+		cfg.Exporter = ExporterNone
+	}
+	if cfg.Exporter != ExporterNone && cfg.Exporter != ExporterOTLP {
+		return cfg, fmt.Errorf("invalid TRACING_EXPORTER %q: expected none or otlp", cfg.Exporter)
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = defaultServiceName
+	}
+	if v := os.Getenv("TRACING_SAMPLE_RATIO"); v != "" {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return cfg, fmt.Errorf("invalid TRACING_SAMPLE_RATIO %q: expected a number from 0 to 1", v)
+		}
+		cfg.SampleRatio = ratio
+	}
+	return cfg, nil
+}
+
+// Setup настраивает глобальный провайдер по конфигурации. Распространение контекста
+// (W3C traceparent) включается и без экспорта, чтобы сервис не разрывал чужие трассы.
+// Возвращаемая функция отправляет накопленные спаны при остановке.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.Exporter == ExporterNone {
+		setPropagator()
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var options []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+	provider := NewProvider(cfg, sdktrace.WithBatcher(exporter))
+	return provider.Shutdown, nil
+}
+
+// NewProvider создаёт провайдер и делает его глобальным. В тестах вместо OTLP передаётся экспортёр в память:
+// NewProvider(Config{SampleRatio: 1}, sdktrace.WithSyncer(tracetest.NewInMemoryExporter())).
+func NewProvider(cfg Config, exporter sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	provider := sdktrace.NewTracerProvider(
+		exporter,
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	setPropagator()
+	return provider
+}
+
+func setPropagator() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
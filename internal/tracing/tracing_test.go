@@ -0,0 +1,262 @@
+package tracing_test
+
+import (
+	"api_library/internal/handler"
+	"api_library/internal/repository"
+	"api_library/internal/tracing"
+	"api_library/internal/usecase"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// провайдер глобальный и задаётся один раз: трассировщики, полученные до его установки, к новому провайдеру не переключатся
+var exporter = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	provider := tracing.NewProvider(tracing.Config{SampleRatio: 1}, sdktrace.WithSyncer(exporter))
+	code := m.Run()
+	provider.Shutdown(context.Background())
+	os.Exit(code)
+}
+
+// fakeConnector - драйвер без базы: SELECT из authors возвращает одного автора,
+// UPDATE меняет три строки, запрос с "missing_table" завершается ошибкой
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                            { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("transactions not supported") }
+
+var errMissingTable = errors.New(`relation "missing_table" does not exist`)
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "missing_table") {
+		return nil, errMissingTable
+	}
+	return driver.RowsAffected(3), nil
+}
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "missing_table") {
+		return nil, errMissingTable
+	}
+	return &fakeRows{
+		columns: []string{"id", "first_name", "last_name", "biography", "birth_date"},
+		values:  [][]driver.Value{{int64(1), "Лев", "Толстой", "", time.Date(1828, 9, 9, 0, 0, 0, 0, time.UTC)}},
+	}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func newTracedDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(tracing.WrapConnector(fakeConnector{}))
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	t.Fatalf("no span %q among %v", name, names)
+	return tracetest.SpanStub{}
+}
+
+func attributeValue(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestRequestTrace(t *testing.T) {
+	exporter.Reset()
+	service := usecase.NewTracedService(usecase.NewService(repository.NewRepository(newTracedDB(t))))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authors", handler.NewAuthorHandler(service).HandleAuthors)
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+	r := httptest.NewRequest(http.MethodGet, "/authors", nil)
+	r.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	w := httptest.NewRecorder()
+	tracing.Middleware(mux, mux).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	spans := exporter.GetSpans()
+	server := spanByName(t, spans, "GET /authors")
+	serviceSpan := spanByName(t, spans, "Service.GetAllAuthors")
+	query := spanByName(t, spans, "SELECT authors")
+
+	// трасса продолжается из traceparent вызывающего сервиса
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("trace ID = %s, want %s", got, traceID)
+	}
+	if got := server.Parent.SpanID().String(); got != parentSpanID || !server.Parent.IsRemote() {
+		t.Errorf("server span parent = %s (remote %v), want remote %s", got, server.Parent.IsRemote(), parentSpanID)
+	}
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %s", server.SpanKind)
+	}
+	if serviceSpan.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Error("service span is not a child of the server span")
+	}
+	if query.Parent.SpanID() != serviceSpan.SpanContext.SpanID() {
+		t.Error("SQL span is not a child of the service span")
+	}
+	for _, span := range []tracetest.SpanStub{serviceSpan, query} {
+		if span.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("span %s belongs to another trace", span.Name)
+		}
+	}
+
+	if v, _ := attributeValue(query, "db.query.text"); !strings.HasPrefix(v.AsString(), "SELECT id, first_name") {
+		t.Errorf("db.query.text = %q", v.AsString())
+	}
+	if v, ok := attributeValue(query, "db.rows_returned"); !ok || v.AsInt64() != 1 {
+		t.Errorf("db.rows_returned = %v", v.Emit())
+	}
+	if v, _ := attributeValue(server, "http.response.status_code"); v.AsInt64() != http.StatusOK {
+		t.Errorf("http.response.status_code = %v", v.Emit())
+	}
+}
+
+func TestSQLSpans(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		wantSpan     string
+		wantAffected int64
+		wantErr      bool
+	}{
+		{"update", "UPDATE books SET title = $1 WHERE author_id = $2", "UPDATE books", 3, false},
+		{"insert", "INSERT INTO audit_log (action) VALUES ($1)", "INSERT audit_log", 3, false},
+		{"delete", "DELETE FROM sessions WHERE expires_at < now()", "DELETE sessions", 3, false},
+		{"no table", "SET LOCAL lock_timeout = '1s'", "SET", 3, false},
+		{"error", "UPDATE missing_table SET x = 1", "UPDATE missing_table", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			_, err := newTracedDB(t).ExecContext(context.Background(), tt.query, 1, 2)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecContext error = %v", err)
+			}
+
+			span := spanByName(t, exporter.GetSpans(), tt.wantSpan)
+			if span.SpanKind != trace.SpanKindClient {
+				t.Errorf("span kind = %s", span.SpanKind)
+			}
+			if v, _ := attributeValue(span, "db.query.text"); v.AsString() != tt.query {
+				t.Errorf("db.query.text = %q", v.AsString())
+			}
+			affected, ok := attributeValue(span, "db.rows_affected")
+			if tt.wantErr {
+				if ok {
+					t.Errorf("db.rows_affected set on a failed statement")
+				}
+				if span.Status.Code != codes.Error || len(span.Events) == 0 {
+					t.Errorf("status = %+v, events = %d; want recorded error", span.Status, len(span.Events))
+				}
+				return
+			}
+			if !ok || affected.AsInt64() != tt.wantAffected {
+				t.Errorf("db.rows_affected = %v, want %d", affected.Emit(), tt.wantAffected)
+			}
+		})
+	}
+}
+
+func TestServerSpanStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/books/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/fail") {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	tests := []struct {
+		path      string
+		wantName  string
+		wantError bool
+	}{
+		{"/books/1/fail", "GET /books/", true},
+		{"/books/1", "GET /books/", false},
+		{"/unknown", "GET", false},
+	}
+	for _, tt := range tests {
+		exporter.Reset()
+		tracing.Middleware(mux, mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("%s: %d spans, want 1", tt.path, len(spans))
+		}
+		if spans[0].Name != tt.wantName {
+			t.Errorf("%s: span name = %q, want %q", tt.path, spans[0].Name, tt.wantName)
+		}
+		if got := spans[0].Status.Code == codes.Error; got != tt.wantError {
+			t.Errorf("%s: error status = %v, want %v", tt.path, got, tt.wantError)
+		}
+		// без traceparent начинается новая трасса
+		if spans[0].Parent.IsValid() {
+			t.Errorf("%s: unexpected parent %s", tt.path, spans[0].Parent.SpanID())
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/repository"
+	"context"
+	"net/http"
+)
+
+const maxAuditLimit = 1000
+
+type AuditService interface {
+	GetAuditLog(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) GetAuditLog(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, error) {
+	switch filter.Entity {
+	case "", repository.AuditEntityAuthor, repository.AuditEntityBook:
+	default:
+		return nil, errors.NewHTTPError(http.StatusBadRequest, "unknown entity "+filter.Entity, "GetAuditLog")
+	}
+	if filter.EntityID != 0 && filter.Entity == "" {
+		return nil, errors.NewHTTPError(http.StatusBadRequest, "id requires entity", "GetAuditLog")
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	return s.repo.GetAuditLog(ctx, filter)
+}
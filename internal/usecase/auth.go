@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/repository"
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type AuthService interface {
+	Login(ctx context.Context, username, password string) (entity.Token, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (auth.Identity, error)
+	AuthenticateUser(ctx context.Context, userID int) (auth.Identity, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error)
+	CreateAPIKey(ctx context.Context, userID int, name string, expiresAt *time.Time) (entity.APIKey, string, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	EnsureUser(ctx context.Context, username, password string) (int, bool, error)
+	CreateUser(ctx context.Context, username, password string) (entity.User, error)
+}
+
+type authService struct {
+	repo   repository.AuthRepository
+	tokens *auth.TokenIssuer
+	// хеш для сравнения, когда пользователь не найден: время ответа не выдаёт существование логина
+	dummyHash string
+}
+
+func NewAuthService(repo repository.AuthRepository, tokens *auth.TokenIssuer) AuthService {
+	dummyHash, err := auth.HashPassword("dummy password")
+	if err != nil {
+		log.Printf("Ошибка при подготовке хеша пароля: %v", err)
+	}
+	return &authService{repo: repo, tokens: tokens, dummyHash: dummyHash}
+}
+
+func (s *authService) Login(ctx context.Context, username, password string) (entity.Token, error) {
+	user, err := s.repo.GetUserByUsername(ctx, username)
+	if err == errors.ErrNotFound {
+		auth.VerifyPassword(password, s.dummyHash)
+		return entity.Token{}, errors.ErrUnauthorized
+	}
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	ok, err := auth.VerifyPassword(password, user.PasswordHash)
+	if err != nil {
+		log.Printf("Ошибка проверки пароля пользователя %s: %v", username, err)
+		return entity.Token{}, errors.ErrUnauthorized
+	}
+	if !ok || user.Disabled {
+		return entity.Token{}, errors.ErrUnauthorized
+	}
+
+	token, err := s.tokens.Issue(user.ID, user.Username)
+	if err != nil {
+		return entity.Token{}, err
+	}
+	return entity.Token{AccessToken: token, TokenType: "Bearer", ExpiresIn: int(s.tokens.TTL().Seconds())}, nil
+}
+
+func (s *authService) AuthenticateAPIKey(ctx context.Context, key string) (auth.Identity, error) {
+	apiKey, err := s.repo.GetAPIKeyByHash(ctx, auth.HashAPIKey(key))
+	if err == errors.ErrNotFound {
+		return auth.Identity{}, errors.ErrUnauthorized
+	}
+	if err != nil {
+		return auth.Identity{}, err
+	}
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt)) {
+		return auth.Identity{}, errors.ErrUnauthorized
+	}
+
+	user, err := s.repo.GetUser(ctx, apiKey.UserID)
+	if err != nil {
+		return auth.Identity{}, err
+	}
+	if user.Disabled {
+		return auth.Identity{}, errors.ErrUnauthorized
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		log.Printf("Не удалось обновить last_used_at ключа %d: %v", apiKey.ID, err)
+	}
+	return auth.Identity{UserID: user.ID, Username: user.Username, Method: auth.MethodAPIKey}, nil
+}
+
+// AuthenticateUser проверяет, что пользователь из JWT существует и не отключён
+func (s *authService) AuthenticateUser(ctx context.Context, userID int) (auth.Identity, error) {
+	user, err := s.repo.GetUser(ctx, userID)
+	if err == errors.ErrNotFound {
+		return auth.Identity{}, errors.ErrUnauthorized
+	}
+	if err != nil {
+		return auth.Identity{}, err
+	}
+	if user.Disabled {
+		return auth.Identity{}, errors.ErrUnauthorized
+	}
+	return auth.Identity{UserID: user.ID, Username: user.Username}, nil
+}
+
+func (s *authService) GetAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	return s.repo.GetAPIKeys(ctx, userID)
+}
+
+// CreateAPIKey возвращает сохранённый ключ и его открытое значение, которое больше нигде не хранится
+func (s *authService) CreateAPIKey(ctx context.Context, userID int, name string, expiresAt *time.Time) (entity.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return entity.APIKey{}, "", errors.ErrInvalidInput
+	}
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return entity.APIKey{}, "", err
+	}
+	apiKey, err := s.repo.CreateAPIKey(ctx, entity.APIKey{Name: name, Prefix: prefix, KeyHash: hash, UserID: userID, ExpiresAt: expiresAt})
+	if err != nil {
+		return entity.APIKey{}, "", err
+	}
+	return apiKey, key, nil
+}
+
+func (s *authService) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	return s.repo.RevokeAPIKey(ctx, userID, keyID)
+}
+
+// EnsureUser создаёт пользователя, если его ещё нет, и возвращает его ID и признак создания.
+// Используется для начального администратора.
+func (s *authService) EnsureUser(ctx context.Context, username, password string) (int, bool, error) {
+	user, err := s.repo.GetUserByUsername(ctx, username)
+	if err == nil {
+		return user.ID, false, nil
+	}
+	if err != errors.ErrNotFound {
+		return 0, false, err
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return 0, false, err
+	}
+	userID, err := s.repo.CreateUser(ctx, username, hash)
+	if err != nil {
+		return 0, false, err
+	}
+	log.Printf("Создан пользователь %s", username)
+	return userID, true, nil
+}
+
+// CreateUser создаёт пользователя без ролей; роли назначаются отдельно через RBACService.SetUserRoles
+func (s *authService) CreateUser(ctx context.Context, username, password string) (entity.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return entity.User{}, errors.NewHTTPError(http.StatusBadRequest, "username is required", "CreateUser")
+	}
+	if err := auth.CheckInitialPassword(username, password); err != nil {
+		return entity.User{}, errors.NewHTTPError(http.StatusBadRequest, "invalid password: "+err.Error(), "CreateUser")
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return entity.User{}, err
+	}
+	userID, err := s.repo.CreateUser(ctx, username, hash)
+	if err != nil {
+		return entity.User{}, err
+	}
+	log.Printf("Создан пользователь %s", username)
+	return s.repo.GetUser(ctx, userID)
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/repository"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// права, требуемые для каждой операции пакета
+var batchPermissions = map[string]map[string]string{
+	entity.BatchEntityAuthor: {
+		entity.BatchCreate: auth.PermAuthorsCreate,
+		entity.BatchUpdate: auth.PermAuthorsUpdate,
+		entity.BatchDelete: auth.PermAuthorsDelete,
+	},
+	entity.BatchEntityBook: {
+		entity.BatchCreate: auth.PermBooksCreate,
+		entity.BatchUpdate: auth.PermBooksUpdate,
+		entity.BatchDelete: auth.PermBooksDelete,
+	},
+}
+
+type BatchService interface {
+	Execute(ctx context.Context, request entity.BatchRequest) (entity.BatchResponse, error)
+}
+
+type batchService struct {
+	repo    repository.BatchRepository
+	maxSize int
+}
+
+func NewBatchService(repo repository.BatchRepository, maxSize int) BatchService {
+	return &batchService{repo: repo, maxSize: maxSize}
+}
+
+// Execute проверяет операции и выполняет прошедшие проверку. В атомарном режиме ошибка
+// проверки любой операции отменяет весь пакет ещё до обращения к базе.
+func (s *batchService) Execute(ctx context.Context, request entity.BatchRequest) (entity.BatchResponse, error) {
+	response := entity.BatchResponse{Atomic: request.Atomic}
+	if len(request.Operations) == 0 {
+		return response, errors.NewHTTPError(http.StatusBadRequest, "operations are required", "Batch")
+	}
+	if len(request.Operations) > s.maxSize {
+		return response, errors.NewHTTPError(http.StatusRequestEntityTooLarge, "batch is larger than "+strconv.Itoa(s.maxSize)+" operations", "Batch")
+	}
+
+	identity, authenticated := auth.FromContext(ctx)
+	results := make([]entity.BatchResult, len(request.Operations))
+	var valid []entity.BatchOperation
+	for i, op := range request.Operations {
+		op.Index = i
+		if err := prepareBatchOperation(&op); err != nil {
+			httpErr := errors.MapErrorToHTTP(err)
+			results[i] = entity.BatchResult{Index: i, ID: op.ID, Status: httpErr.Code, Error: httpErr.Message}
+			continue
+		}
+		if permission := batchPermissions[op.Entity][op.Op]; authenticated && !identity.Can(permission) {
+			results[i] = entity.BatchResult{Index: i, ID: op.ID, Status: http.StatusForbidden, Error: "permission denied: " + permission}
+			continue
+		}
+		valid = append(valid, op)
+	}
+
+	if request.Atomic && len(valid) != len(request.Operations) {
+		for i := range results {
+			if results[i].Error == "" {
+				results[i] = entity.BatchResult{Index: i, Status: http.StatusFailedDependency, Error: "batch rolled back"}
+			}
+		}
+		return summarizeBatch(response, results), nil
+	}
+
+	if len(valid) > 0 {
+		executed, err := s.repo.ExecuteBatch(ctx, valid, request.Atomic)
+		if err != nil {
+			return response, err
+		}
+		for _, result := range executed {
+			results[result.Index] = result
+		}
+	}
+	return summarizeBatch(response, results), nil
+}
+
+// prepareBatchOperation проверяет тип операции и разбирает её данные
+func prepareBatchOperation(op *entity.BatchOperation) error {
+	if _, ok := batchPermissions[op.Entity][op.Op]; !ok {
+		return errors.NewHTTPError(http.StatusBadRequest, "unsupported operation "+op.Op+" on "+op.Entity, "Batch")
+	}
+	if op.Op != entity.BatchCreate && op.ID <= 0 {
+		return errors.NewHTTPError(http.StatusBadRequest, "id is required", "Batch")
+	}
+	if op.Op == entity.BatchDelete {
+		return nil
+	}
+	if len(op.Data) == 0 {
+		return errors.NewHTTPError(http.StatusBadRequest, "data is required", "Batch")
+	}
+
+	var err error
+	if op.Entity == entity.BatchEntityAuthor {
+		op.Author = &entity.Author{}
+		err = json.Unmarshal(op.Data, op.Author)
+	} else {
+		op.Book = &entity.Book{}
+		err = json.Unmarshal(op.Data, op.Book)
+	}
+	if err != nil {
+		return errors.NewHTTPError(http.StatusBadRequest, "invalid data: "+err.Error(), "Batch")
+	}
+	return nil
+}
+
+func summarizeBatch(response entity.BatchResponse, results []entity.BatchResult) entity.BatchResponse {
+	for _, result := range results {
+		if result.Error == "" {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	response.Results = results
+	return response
+}
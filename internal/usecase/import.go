@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/repository"
+	"context"
+	"encoding/csv"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// поля, которые можно сопоставить с колонками CSV, и обязательные из них
+var importFields = map[string][]string{
+	entity.ImportAuthors: {"first_name", "last_name", "biography", "birth_date"},
+	entity.ImportBooks:   {"title", "author_id", "year", "isbn"},
+}
+
+var importRequired = map[string][]string{
+	entity.ImportAuthors: {"first_name", "last_name"},
+	entity.ImportBooks:   {"title", "author_id"},
+}
+
+// сколько отклонённых строк попадает в отчёт: файл из одних ошибок не должен разрастаться в памяти
+const maxImportErrors = 1000
+
+type ImportService interface {
+	Import(ctx context.Context, input io.Reader, opts entity.ImportOptions) (entity.ImportResult, error)
+}
+
+type importService struct {
+	repo repository.ImportRepository
+}
+
+func NewImportService(repo repository.ImportRepository) ImportService {
+	return &importService{repo: repo}
+}
+
+// fieldError - ошибка проверки конкретного поля строки
+type fieldError struct {
+	field   string
+	message string
+}
+
+func (e *fieldError) Error() string {
+	return e.field + ": " + e.message
+}
+
+// Import читает CSV потоково: каждая строка проверяется и сразу передаётся в COPY,
+// отклонённые строки собираются в отчёт и не прерывают импорт
+func (s *importService) Import(ctx context.Context, input io.Reader, opts entity.ImportOptions) (entity.ImportResult, error) {
+	result := entity.ImportResult{Entity: opts.Entity, DryRun: opts.DryRun, Errors: []entity.ImportRowError{}}
+	fields, ok := importFields[opts.Entity]
+	if !ok {
+		return result, errors.NewHTTPError(http.StatusBadRequest, "unknown import type", "Import")
+	}
+
+	reader := csv.NewReader(input)
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return result, errors.NewHTTPError(http.StatusBadRequest, "empty CSV", "Import")
+	}
+	if err != nil {
+		return result, errors.NewHTTPError(http.StatusBadRequest, "invalid CSV header: "+err.Error(), "Import")
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	result.Header = header
+
+	columns, err := mapImportColumns(header, fields, importRequired[opts.Entity], opts.Mapping)
+	if err != nil {
+		return result, err
+	}
+
+	// next читает записи до первой прошедшей проверку; ошибки строк попадают в отчёт
+	next := func(parse func(values map[string]string) error) (bool, error) {
+		for {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+			record, err := reader.Read()
+			if err == io.EOF {
+				return false, nil
+			}
+			var parseErr *csv.ParseError
+			if stderrors.As(err, &parseErr) {
+				result.Total++
+				rejectRow(&result, entity.ImportRowError{Line: parseErr.StartLine, Message: parseErr.Err.Error(), Record: record})
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+
+			result.Total++
+			line, _ := reader.FieldPos(0)
+			if len(record) != len(header) {
+				rejectRow(&result, entity.ImportRowError{Line: line, Message: fmt.Sprintf("expected %d columns, got %d", len(header), len(record)), Record: record})
+				continue
+			}
+
+			values := make(map[string]string, len(columns))
+			for field, index := range columns {
+				values[field] = strings.TrimSpace(record[index])
+			}
+			if err := parse(values); err != nil {
+				rowErr := entity.ImportRowError{Line: line, Message: err.Error(), Record: record}
+				var fe *fieldError
+				if stderrors.As(err, &fe) {
+					rowErr.Column = header[columns[fe.field]]
+					rowErr.Message = fe.message
+				}
+				rejectRow(&result, rowErr)
+				continue
+			}
+			return true, nil
+		}
+	}
+
+	switch opts.Entity {
+	case entity.ImportAuthors:
+		result.Imported, err = s.repo.ImportAuthors(ctx, func() (entity.Author, bool, error) {
+			var author entity.Author
+			ok, err := next(func(values map[string]string) (err error) {
+				author, err = parseImportAuthor(values)
+				return err
+			})
+			return author, ok, err
+		}, opts.DryRun)
+	case entity.ImportBooks:
+		var authorIDs map[int]bool
+		authorIDs, err = s.repo.GetAuthorIDs(ctx)
+		if err != nil {
+			return result, err
+		}
+		result.Imported, err = s.repo.ImportBooks(ctx, func() (entity.Book, bool, error) {
+			var book entity.Book
+			ok, err := next(func(values map[string]string) (err error) {
+				book, err = parseImportBook(values, authorIDs)
+				return err
+			})
+			return book, ok, err
+		}, opts.DryRun)
+	}
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// rejectRow учитывает отклонённую строку; в отчёт попадают только первые maxImportErrors
+func rejectRow(result *entity.ImportResult, rowErr entity.ImportRowError) {
+	result.Rejected++
+	if len(result.Errors) < maxImportErrors {
+		result.Errors = append(result.Errors, rowErr)
+		return
+	}
+	result.ErrorsOmitted++
+}
+
+// mapImportColumns возвращает индекс колонки CSV для каждого поля.
+// По умолчанию поле ищется по собственному имени, mapping переопределяет имя колонки.
+func mapImportColumns(header, fields, required []string, mapping map[string]string) (map[string]int, error) {
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, errors.NewHTTPError(http.StatusBadRequest, "unknown field in mapping: "+field, "Import")
+		}
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := make(map[string]int)
+	for _, field := range fields {
+		column, mapped := mapping[field]
+		if !mapped {
+			column = field
+		}
+		i, ok := index[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			if mapped {
+				return nil, errors.NewHTTPError(http.StatusBadRequest, "column not found in CSV: "+column, "Import")
+			}
+			continue
+		}
+		columns[field] = i
+	}
+	for _, field := range required {
+		if _, ok := columns[field]; !ok {
+			return nil, errors.NewHTTPError(http.StatusBadRequest, "missing required column: "+field, "Import")
+		}
+	}
+	return columns, nil
+}
+
+func parseImportAuthor(values map[string]string) (entity.Author, error) {
+	author := entity.Author{
+		FirstName: values["first_name"],
+		LastName:  values["last_name"],
+		Biography: values["biography"],
+	}
+	if err := checkImportText("first_name", author.FirstName, 100, true); err != nil {
+		return author, err
+	}
+	if err := checkImportText("last_name", author.LastName, 100, true); err != nil {
+		return author, err
+	}
+	if v := values["birth_date"]; v != "" {
+		birthDate, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return author, &fieldError{"birth_date", "expected date in YYYY-MM-DD format"}
+		}
+		if birthDate.After(time.Now()) {
+			return author, &fieldError{"birth_date", "date is in the future"}
+		}
+		author.BirthDate = entity.Date{Time: birthDate}
+	}
+	return author, nil
+}
+
+func parseImportBook(values map[string]string, authorIDs map[int]bool) (entity.Book, error) {
+	book := entity.Book{Title: values["title"]}
+	if err := checkImportText("title", book.Title, 255, true); err != nil {
+		return book, err
+	}
+
+	authorID, err := strconv.Atoi(values["author_id"])
+	if err != nil {
+		return book, &fieldError{"author_id", "expected integer"}
+	}
+	if !authorIDs[authorID] {
+		return book, &fieldError{"author_id", "author not found"}
+	}
+	book.AuthorID = authorID
+
+	if v := values["year"]; v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil {
+			return book, &fieldError{"year", "expected integer"}
+		}
+		if year <= 0 || year > time.Now().Year() {
+			return book, &fieldError{"year", "year out of range"}
+		}
+		book.Year = year
+	}
+
+	if v := values["isbn"]; v != "" {
+		isbn := strings.NewReplacer("-", "", " ", "").Replace(v)
+		if !validISBN(isbn) {
+			return book, &fieldError{"isbn", "expected ISBN-10 or ISBN-13"}
+		}
+		book.ISBN = isbn
+	}
+	return book, nil
+}
+
+func checkImportText(field, value string, maxLen int, required bool) error {
+	if required && value == "" {
+		return &fieldError{field, "value is required"}
+	}
+	if utf8.RuneCountInString(value) > maxLen {
+		return &fieldError{field, fmt.Sprintf("longer than %d characters", maxLen)}
+	}
+	return nil
+}
+
+// validISBN проверяет длину и состав ISBN без дефисов; X допускается только последним символом ISBN-10
+func validISBN(isbn string) bool {
+	if len(isbn) != 10 && len(isbn) != 13 {
+		return false
+	}
+	for i, c := range isbn {
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		if (c == 'X' || c == 'x') && len(isbn) == 10 && i == 9 {
+			continue
+		}
+		return false
+	}
+	return true
+}
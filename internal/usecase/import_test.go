@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/repository"
+	"context"
+	"strings"
+	"testing"
+)
+
+// drainImportRepository читает все записи, как COPY, и считает их импортированными
+type drainImportRepository struct {
+	repository.ImportRepository
+}
+
+func (r *drainImportRepository) ImportAuthors(ctx context.Context, next repository.AuthorRows, dryRun bool) (int, error) {
+	imported := 0
+	for {
+		_, ok, err := next()
+		if err != nil || !ok {
+			return imported, err
+		}
+		imported++
+	}
+}
+
+func TestImportErrorsCapped(t *testing.T) {
+	var input strings.Builder
+	input.WriteString("first_name,last_name\nЛев,Толстой\n")
+	for i := 0; i < maxImportErrors+5; i++ {
+		input.WriteString("Без фамилии,\n")
+	}
+
+	result, err := NewImportService(&drainImportRepository{}).Import(context.Background(), strings.NewReader(input.String()), entity.ImportOptions{Entity: entity.ImportAuthors})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != maxImportErrors+6 || result.Imported != 1 || result.Rejected != maxImportErrors+5 {
+		t.Errorf("total %d, imported %d, rejected %d", result.Total, result.Imported, result.Rejected)
+	}
+	if len(result.Errors) != maxImportErrors || result.ErrorsOmitted != 5 {
+		t.Errorf("errors %d, omitted %d; want %d and 5", len(result.Errors), result.ErrorsOmitted, maxImportErrors)
+	}
+	if first := result.Errors[0]; first.Line != 3 || first.Column != "last_name" {
+		t.Errorf("first error %+v, want line 3, column last_name", first)
+	}
+}
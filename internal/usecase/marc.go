@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/marc"
+	"api_library/internal/repository"
+	"context"
+	stderrors "errors"
+	"io"
+	"strings"
+	"time"
+)
+
+type MARCService interface {
+	Import(ctx context.Context, records marc.RecordReader, dryRun bool) (entity.ImportResult, error)
+}
+
+type marcService struct {
+	repo repository.Repository
+}
+
+func NewMARCService(repo repository.Repository) MARCService {
+	return &marcService{repo: repo}
+}
+
+// Import создаёт книги из записей MARC. Автор ищется по имени и фамилии среди существующих
+// и создаётся, только если не найден. Ошибка в записи не прерывает импорт остальных.
+func (s *marcService) Import(ctx context.Context, records marc.RecordReader, dryRun bool) (entity.ImportResult, error) {
+	result := entity.ImportResult{Entity: entity.ImportBooks, DryRun: dryRun, Errors: []entity.ImportRowError{}}
+	// авторы, уже найденные или созданные в этом импорте, по нормализованному имени
+	authorIDs := make(map[string]int)
+	// при dryRun авторы не создаются, им выдаются временные отрицательные ID
+	nextDryRunID := -1
+
+	reject := func(number int, tag, message string) {
+		rejectRow(&result, entity.ImportRowError{Line: number, Column: tag, Message: message})
+	}
+
+	for number := 1; ; number++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *marc.ParseError
+		if stderrors.As(err, &parseErr) {
+			result.Total++
+			reject(number, "", parseErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			// поток дальше не разобрать: уже импортированные записи остаются, отчёт это отражает
+			result.Total++
+			reject(number, "", "unreadable MARC data: "+err.Error())
+			break
+		}
+
+		result.Total++
+		book, author := record.Book()
+		if err := validateMARCBook(book, author); err != nil {
+			var fe *fieldError
+			if stderrors.As(err, &fe) {
+				reject(number, fe.field, fe.message)
+			} else {
+				reject(number, "", err.Error())
+			}
+			continue
+		}
+
+		key := strings.ToLower(author.FirstName) + "\x00" + strings.ToLower(author.LastName)
+		authorID, ok := authorIDs[key]
+		if !ok {
+			existing, err := s.repo.FindAuthorByName(ctx, author.FirstName, author.LastName)
+			switch {
+			case err == nil:
+				authorID = existing.ID
+			case err != errors.ErrNotFound:
+				return result, err
+			case !canCreateAuthor(ctx):
+				reject(number, "100", "author not found and permission to create authors is missing")
+				continue
+			case dryRun:
+				authorID = nextDryRunID
+				nextDryRunID--
+				result.AuthorsCreated++
+			default:
+				authorID, err = s.repo.CreateAuthor(ctx, author.FirstName, author.LastName, "", time.Time{})
+				if err != nil {
+					return result, err
+				}
+				result.AuthorsCreated++
+			}
+			authorIDs[key] = authorID
+		}
+
+		if !dryRun {
+			if _, err := s.repo.CreateBook(ctx, book.Title, book.Year, book.ISBN, authorID); err != nil {
+				return result, err
+			}
+		}
+		result.Imported++
+	}
+	return result, nil
+}
+
+// validateMARCBook применяет к записи MARC те же правила, что и CSV-импорт; field - тег MARC
+func validateMARCBook(book entity.Book, author entity.Author) error {
+	if err := checkImportText("245", book.Title, 255, true); err != nil {
+		return err
+	}
+	if author.FirstName == "" && author.LastName == "" {
+		return &fieldError{"100", "main author is required"}
+	}
+	if err := checkImportText("100", author.FirstName, 100, false); err != nil {
+		return err
+	}
+	if err := checkImportText("100", author.LastName, 100, false); err != nil {
+		return err
+	}
+	if book.ISBN != "" && !validISBN(book.ISBN) {
+		return &fieldError{"020", "expected ISBN-10 or ISBN-13"}
+	}
+	if book.Year > time.Now().Year() {
+		return &fieldError{"264", "year out of range"}
+	}
+	return nil
+}
+
+// canCreateAuthor - импорт требует права на книги, а новых авторов может создавать только тот,
+// у кого есть и право на авторов; вызовы без пользователя (фоновые) не ограничены
+func canCreateAuthor(ctx context.Context) bool {
+	identity, ok := auth.FromContext(ctx)
+	return !ok || identity.Can(auth.PermAuthorsCreate)
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/repository"
+	"context"
+	"time"
+)
+
+type OAIService interface {
+	GetEarliestDatestamp(ctx context.Context) (time.Time, error)
+	GetRecords(ctx context.Context, filter entity.OAIFilter) ([]entity.OAIRecord, error)
+	GetRecord(ctx context.Context, bookID int) (entity.OAIRecord, error)
+}
+
+type oaiService struct {
+	repo repository.OAIRepository
+}
+
+func NewOAIService(repo repository.OAIRepository) OAIService {
+	return &oaiService{repo: repo}
+}
+
+func (s *oaiService) GetEarliestDatestamp(ctx context.Context) (time.Time, error) {
+	return s.repo.GetEarliestDatestamp(ctx)
+}
+
+func (s *oaiService) GetRecords(ctx context.Context, filter entity.OAIFilter) ([]entity.OAIRecord, error) {
+	return s.repo.GetOAIRecords(ctx, filter)
+}
+
+func (s *oaiService) GetRecord(ctx context.Context, bookID int) (entity.OAIRecord, error) {
+	return s.repo.GetOAIRecord(ctx, bookID)
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"api_library/internal/auth"
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"api_library/internal/repository"
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+)
+
+type RBACService interface {
+	ResolveAccess(ctx context.Context, userID int) ([]string, []string, error)
+	GetRoles(ctx context.Context) ([]entity.Role, error)
+	GetRole(ctx context.Context, name string) (entity.Role, error)
+	CreateRole(ctx context.Context, role entity.Role) error
+	UpdateRole(ctx context.Context, role entity.Role) error
+	DeleteRole(ctx context.Context, name string) error
+	GetUserRoles(ctx context.Context, userID int) (entity.UserRoles, error)
+	SetUserRoles(ctx context.Context, userID int, roles []string) error
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+type rbacService struct {
+	repo     repository.RBACRepository
+	authRepo repository.AuthRepository
+}
+
+func NewRBACService(repo repository.RBACRepository, authRepo repository.AuthRepository) RBACService {
+	return &rbacService{repo: repo, authRepo: authRepo}
+}
+
+func (s *rbacService) ResolveAccess(ctx context.Context, userID int) ([]string, []string, error) {
+	roles, err := s.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	permissions, err := s.repo.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return roles, permissions, nil
+}
+
+func (s *rbacService) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	return s.repo.GetRoles(ctx)
+}
+
+func (s *rbacService) GetRole(ctx context.Context, name string) (entity.Role, error) {
+	return s.repo.GetRole(ctx, name)
+}
+
+func (s *rbacService) CreateRole(ctx context.Context, role entity.Role) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	return s.repo.CreateRole(ctx, role)
+}
+
+func (s *rbacService) UpdateRole(ctx context.Context, role entity.Role) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	// как и при удалении: admin - последняя роль, через которую можно вернуть любые права
+	if role.Name == auth.RoleAdmin && !contains(role.Permissions, "*") {
+		return errors.NewHTTPError(http.StatusBadRequest, "admin role must keep the * permission", "UpdateRole")
+	}
+	return s.repo.UpdateRole(ctx, role)
+}
+
+func (s *rbacService) DeleteRole(ctx context.Context, name string) error {
+	// без роли admin управлять ролями станет некому
+	if name == auth.RoleAdmin {
+		return errors.NewHTTPError(http.StatusBadRequest, "admin role cannot be deleted", "DeleteRole")
+	}
+	return s.repo.DeleteRole(ctx, name)
+}
+
+func (s *rbacService) GetUserRoles(ctx context.Context, userID int) (entity.UserRoles, error) {
+	if _, err := s.authRepo.GetUser(ctx, userID); err != nil {
+		return entity.UserRoles{}, err
+	}
+	roles, err := s.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return entity.UserRoles{}, err
+	}
+	return entity.UserRoles{UserID: userID, Roles: roles}, nil
+}
+
+func (s *rbacService) SetUserRoles(ctx context.Context, userID int, roles []string) error {
+	if _, err := s.authRepo.GetUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.repo.SetUserRoles(ctx, userID, uniqueStrings(roles))
+}
+
+func validateRole(role entity.Role) error {
+	if !roleNamePattern.MatchString(role.Name) {
+		return errors.NewHTTPError(http.StatusBadRequest, "invalid role name", "validateRole")
+	}
+	for _, permission := range role.Permissions {
+		if !auth.ValidPermission(permission) {
+			return errors.NewHTTPError(http.StatusBadRequest, "unknown permission "+permission, "validateRole")
+		}
+	}
+	return nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
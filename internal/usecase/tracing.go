@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"api_library/internal/entity"
+	"api_library/internal/errors"
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedService создаёт спан на каждый вызов метода Service
+type tracedService struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+func NewTracedService(next Service) Service {
+	return &tracedService{next: next, tracer: otel.Tracer("api_library/internal/usecase")}
+}
+
+// start открывает спан метода; возвращаемая функция закрывает его. Ошибки клиента (4xx)
+// записываются в спан, но статус ошибки получают только сбои сервиса.
+func (s *tracedService) start(ctx context.Context, method string) (context.Context, func(error)) {
+	ctx, span := s.tracer.Start(ctx, "Service."+method)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			if httpErr := errors.MapErrorToHTTP(err); httpErr.Code >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, httpErr.Message)
+			}
+		}
+		span.End()
+	}
+}
+
+func (s *tracedService) GetAllAuthors(ctx context.Context, filter entity.AuthorFilter) (authors []entity.Author, err error) {
+	ctx, end := s.start(ctx, "GetAllAuthors")
+	defer func() { end(err) }()
+	return s.next.GetAllAuthors(ctx, filter)
+}
+
+func (s *tracedService) GetAuthor(ctx context.Context, id int) (author entity.Author, err error) {
+	ctx, end := s.start(ctx, "GetAuthor")
+	defer func() { end(err) }()
+	return s.next.GetAuthor(ctx, id)
+}
+
+func (s *tracedService) GetAuthorsByIDs(ctx context.Context, ids []int) (authors map[int]entity.Author, err error) {
+	ctx, end := s.start(ctx, "GetAuthorsByIDs")
+	defer func() { end(err) }()
+	return s.next.GetAuthorsByIDs(ctx, ids)
+}
+
+func (s *tracedService) CreateAuthor(ctx context.Context, firstName, lastName, biography string, birthDate time.Time) (id int, err error) {
+	ctx, end := s.start(ctx, "CreateAuthor")
+	defer func() { end(err) }()
+	return s.next.CreateAuthor(ctx, firstName, lastName, biography, birthDate)
+}
+
+func (s *tracedService) UpdateAuthor(ctx context.Context, id int, firstName, lastName, biography string, birthDate time.Time) (err error) {
+	ctx, end := s.start(ctx, "UpdateAuthor")
+	defer func() { end(err) }()
+	return s.next.UpdateAuthor(ctx, id, firstName, lastName, biography, birthDate)
+}
+
+func (s *tracedService) DeleteAuthor(ctx context.Context, id int) (err error) {
+	ctx, end := s.start(ctx, "DeleteAuthor")
+	defer func() { end(err) }()
+	return s.next.DeleteAuthor(ctx, id)
+}
+
+func (s *tracedService) GetAllBooks(ctx context.Context, filter entity.BookFilter) (books []entity.Book, err error) {
+	ctx, end := s.start(ctx, "GetAllBooks")
+	defer func() { end(err) }()
+	return s.next.GetAllBooks(ctx, filter)
+}
+
+func (s *tracedService) GetBook(ctx context.Context, id int) (book entity.Book, err error) {
+	ctx, end := s.start(ctx, "GetBook")
+	defer func() { end(err) }()
+	return s.next.GetBook(ctx, id)
+}
+
+func (s *tracedService) GetBooksByAuthors(ctx context.Context, authorIDs []int) (books map[int][]entity.Book, err error) {
+	ctx, end := s.start(ctx, "GetBooksByAuthors")
+	defer func() { end(err) }()
+	return s.next.GetBooksByAuthors(ctx, authorIDs)
+}
+
+func (s *tracedService) CreateBook(ctx context.Context, title string, year int, isbn string, authorID int) (id int, err error) {
+	ctx, end := s.start(ctx, "CreateBook")
+	defer func() { end(err) }()
+	return s.next.CreateBook(ctx, title, year, isbn, authorID)
+}
+
+func (s *tracedService) UpdateBook(ctx context.Context, id int, title string, year int, isbn string, authorID int) (err error) {
+	ctx, end := s.start(ctx, "UpdateBook")
+	defer func() { end(err) }()
+	return s.next.UpdateBook(ctx, id, title, year, isbn, authorID)
+}
+
+func (s *tracedService) DeleteBook(ctx context.Context, id int) (err error) {
+	ctx, end := s.start(ctx, "DeleteBook")
+	defer func() { end(err) }()
+	return s.next.DeleteBook(ctx, id)
+}
+
+func (s *tracedService) UpdateBookWithAuthor(ctx context.Context, bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) (err error) {
+	ctx, end := s.start(ctx, "UpdateBookWithAuthor")
+	defer func() { end(err) }()
+	return s.next.UpdateBookWithAuthor(ctx, bookID, newTitle, newYear, newISBN, authorID, newFirstName, newLastName, newBiography, newBirthDate)
+}
+
+func (s *tracedService) RestoreAuthor(ctx context.Context, id int) (err error) {
+	ctx, end := s.start(ctx, "RestoreAuthor")
+	defer func() { end(err) }()
+	return s.next.RestoreAuthor(ctx, id)
+}
+
+func (s *tracedService) RestoreBook(ctx context.Context, id int) (err error) {
+	ctx, end := s.start(ctx, "RestoreBook")
+	defer func() { end(err) }()
+	return s.next.RestoreBook(ctx, id)
+}
+
+func (s *tracedService) GetTrash(ctx context.Context) (trash entity.Trash, err error) {
+	ctx, end := s.start(ctx, "GetTrash")
+	defer func() { end(err) }()
+	return s.next.GetTrash(ctx)
+}
+
+func (s *tracedService) PurgeDeleted(ctx context.Context, retention time.Duration) (purged int, err error) {
+	ctx, end := s.start(ctx, "PurgeDeleted")
+	defer func() { end(err) }()
+	return s.next.PurgeDeleted(ctx, retention)
+}
+
+func (s *tracedService) GetAuthorVersions(ctx context.Context, id int) (versions []entity.AuthorVersion, err error) {
+	ctx, end := s.start(ctx, "GetAuthorVersions")
+	defer func() { end(err) }()
+	return s.next.GetAuthorVersions(ctx, id)
+}
+
+func (s *tracedService) GetAuthorAsOf(ctx context.Context, id int, asOf time.Time) (author entity.Author, err error) {
+	ctx, end := s.start(ctx, "GetAuthorAsOf")
+	defer func() { end(err) }()
+	return s.next.GetAuthorAsOf(ctx, id, asOf)
+}
+
+func (s *tracedService) RevertAuthor(ctx context.Context, id, version int) (err error) {
+	ctx, end := s.start(ctx, "RevertAuthor")
+	defer func() { end(err) }()
+	return s.next.RevertAuthor(ctx, id, version)
+}
+
+func (s *tracedService) GetBookVersions(ctx context.Context, id int) (versions []entity.BookVersion, err error) {
+	ctx, end := s.start(ctx, "GetBookVersions")
+	defer func() { end(err) }()
+	return s.next.GetBookVersions(ctx, id)
+}
+
+func (s *tracedService) GetBookAsOf(ctx context.Context, id int, asOf time.Time) (book entity.Book, err error) {
+	ctx, end := s.start(ctx, "GetBookAsOf")
+	defer func() { end(err) }()
+	return s.next.GetBookAsOf(ctx, id, asOf)
+}
+
+func (s *tracedService) RevertBook(ctx context.Context, id, version int) (err error) {
+	ctx, end := s.start(ctx, "RevertBook")
+	defer func() { end(err) }()
+	return s.next.RevertBook(ctx, id, version)
+}
+
+func (s *tracedService) ExportAuthors(ctx context.Context, filter entity.AuthorFilter, fn func(entity.Author) error) (err error) {
+	ctx, end := s.start(ctx, "ExportAuthors")
+	defer func() { end(err) }()
+	return s.next.ExportAuthors(ctx, filter, fn)
+}
+
+func (s *tracedService) ExportBooks(ctx context.Context, filter entity.BookFilter, fn func(entity.BookRecord) error) (err error) {
+	ctx, end := s.start(ctx, "ExportBooks")
+	defer func() { end(err) }()
+	return s.next.ExportBooks(ctx, filter, fn)
+}
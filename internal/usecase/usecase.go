@@ -3,22 +3,40 @@ package usecase
 import (
 	"api_library/internal/entity"
 	"api_library/internal/repository"
+	"context"
 	"time"
 )
 
 type Service interface {
-	GetAllAuthors() ([]entity.Author, error)
-	GetAuthor(id int) (entity.Author, error)
-	CreateAuthor(firstName, lastName, biography string, birthDate time.Time) (int, error)
-	UpdateAuthor(id int, firstName, lastName, biography string, birthDate time.Time) error
-	DeleteAuthor(id int) error
-
-	GetAllBooks() ([]entity.Book, error)
-	GetBook(id int) (entity.Book, error)
-	CreateBook(title string, year int, isbn string, authorID int) (int, error)
-	UpdateBook(id int, title string, year int, isbn string, authorID int) error
-	DeleteBook(id int) error
-	UpdateBookWithAuthor(bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error
+	GetAllAuthors(ctx context.Context, filter entity.AuthorFilter) ([]entity.Author, error)
+	GetAuthor(ctx context.Context, id int) (entity.Author, error)
+	GetAuthorsByIDs(ctx context.Context, ids []int) (map[int]entity.Author, error)
+	CreateAuthor(ctx context.Context, firstName, lastName, biography string, birthDate time.Time) (int, error)
+	UpdateAuthor(ctx context.Context, id int, firstName, lastName, biography string, birthDate time.Time) error
+	DeleteAuthor(ctx context.Context, id int) error
+
+	GetAllBooks(ctx context.Context, filter entity.BookFilter) ([]entity.Book, error)
+	GetBook(ctx context.Context, id int) (entity.Book, error)
+	GetBooksByAuthors(ctx context.Context, authorIDs []int) (map[int][]entity.Book, error)
+	CreateBook(ctx context.Context, title string, year int, isbn string, authorID int) (int, error)
+	UpdateBook(ctx context.Context, id int, title string, year int, isbn string, authorID int) error
+	DeleteBook(ctx context.Context, id int) error
+	UpdateBookWithAuthor(ctx context.Context, bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error
+
+	RestoreAuthor(ctx context.Context, id int) error
+	RestoreBook(ctx context.Context, id int) error
+	GetTrash(ctx context.Context) (entity.Trash, error)
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int, error)
+
+	GetAuthorVersions(ctx context.Context, id int) ([]entity.AuthorVersion, error)
+	GetAuthorAsOf(ctx context.Context, id int, asOf time.Time) (entity.Author, error)
+	RevertAuthor(ctx context.Context, id, version int) error
+	GetBookVersions(ctx context.Context, id int) ([]entity.BookVersion, error)
+	GetBookAsOf(ctx context.Context, id int, asOf time.Time) (entity.Book, error)
+	RevertBook(ctx context.Context, id, version int) error
+
+	ExportAuthors(ctx context.Context, filter entity.AuthorFilter, fn func(entity.Author) error) error
+	ExportBooks(ctx context.Context, filter entity.BookFilter, fn func(entity.BookRecord) error) error
 }
 
 type service struct {
@@ -29,50 +47,126 @@ func NewService(repo repository.Repository) Service {
 	return &service{repo: repo}
 }
 
-func (s *service) GetAllAuthors() ([]entity.Author, error) {
-	return s.repo.GetAllAuthors()
+func (s *service) GetAllAuthors(ctx context.Context, filter entity.AuthorFilter) ([]entity.Author, error) {
+	return s.repo.GetAllAuthors(ctx, filter)
+}
+
+func (s *service) GetAuthor(ctx context.Context, id int) (entity.Author, error) {
+	return s.repo.GetAuthor(ctx, id)
+}
+
+// GetAuthorsByIDs - авторы по ID одним запросом; найденные возвращаются по ключу ID
+func (s *service) GetAuthorsByIDs(ctx context.Context, ids []int) (map[int]entity.Author, error) {
+	authors, err := s.repo.GetAuthorsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]entity.Author, len(authors))
+	for _, author := range authors {
+		byID[author.ID] = author
+	}
+	return byID, nil
+}
+
+func (s *service) CreateAuthor(ctx context.Context, firstName, lastName, biography string, birthDate time.Time) (int, error) {
+	return s.repo.CreateAuthor(ctx, firstName, lastName, biography, birthDate)
+}
+
+func (s *service) UpdateAuthor(ctx context.Context, id int, firstName, lastName, biography string, birthDate time.Time) error {
+	return s.repo.UpdateAuthor(ctx, id, firstName, lastName, biography, birthDate)
+}
+
+func (s *service) DeleteAuthor(ctx context.Context, id int) error {
+	return s.repo.DeleteAuthor(ctx, id)
+}
+
+func (s *service) GetAllBooks(ctx context.Context, filter entity.BookFilter) ([]entity.Book, error) {
+	return s.repo.GetAllBooks(ctx, filter)
+}
+
+func (s *service) GetBook(ctx context.Context, id int) (entity.Book, error) {
+	return s.repo.GetBook(ctx, id)
+}
+
+func (s *service) CreateBook(ctx context.Context, title string, year int, isbn string, authorID int) (int, error) {
+	return s.repo.CreateBook(ctx, title, year, isbn, authorID)
+}
+
+func (s *service) UpdateBook(ctx context.Context, id int, title string, year int, isbn string, authorID int) error {
+	return s.repo.UpdateBook(ctx, id, title, year, isbn)
+}
+
+func (s *service) DeleteBook(ctx context.Context, id int) error {
+	return s.repo.DeleteBook(ctx, id)
+}
+
+func (s *service) GetBooksByAuthor(ctx context.Context, id int) ([]entity.Book, error) {
+	return s.repo.GetBooksByAuthor(ctx, id)
+}
+
+// GetBooksByAuthors - книги нескольких авторов одним запросом, сгруппированные по автору
+func (s *service) GetBooksByAuthors(ctx context.Context, authorIDs []int) (map[int][]entity.Book, error) {
+	books, err := s.repo.GetBooksByAuthors(ctx, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	byAuthor := make(map[int][]entity.Book, len(authorIDs))
+	for _, book := range books {
+		byAuthor[book.AuthorID] = append(byAuthor[book.AuthorID], book)
+	}
+	return byAuthor, nil
+}
+
+func (s *service) UpdateBookWithAuthor(ctx context.Context, bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error {
+	return s.repo.UpdateBookAndAuthor(ctx, bookID, newTitle, newYear, newISBN, authorID, newFirstName, newLastName, newBiography, newBirthDate)
+}
+
+func (s *service) RestoreAuthor(ctx context.Context, id int) error {
+	return s.repo.RestoreAuthor(ctx, id)
 }
 
-func (s *service) GetAuthor(id int) (entity.Author, error) {
-	return s.repo.GetAuthor(id)
+func (s *service) RestoreBook(ctx context.Context, id int) error {
+	return s.repo.RestoreBook(ctx, id)
 }
 
-func (s *service) CreateAuthor(firstName, lastName, biography string, birthDate time.Time) (int, error) {
-	return s.repo.CreateAuthor(firstName, lastName, biography, birthDate)
+func (s *service) GetTrash(ctx context.Context) (entity.Trash, error) {
+	return s.repo.GetTrash(ctx)
 }
 
-func (s *service) UpdateAuthor(id int, firstName, lastName, biography string, birthDate time.Time) error {
-	return s.repo.UpdateAuthor(id, firstName, lastName, biography, birthDate)
+// PurgeDeleted окончательно удаляет записи, пролежавшие в корзине дольше retention
+func (s *service) PurgeDeleted(ctx context.Context, retention time.Duration) (int, error) {
+	return s.repo.PurgeDeleted(ctx, time.Now().Add(-retention))
 }
 
-func (s *service) DeleteAuthor(id int) error {
-	return s.repo.DeleteAuthor(id)
+func (s *service) GetAuthorVersions(ctx context.Context, id int) ([]entity.AuthorVersion, error) {
+	return s.repo.GetAuthorVersions(ctx, id)
 }
 
-func (s *service) GetAllBooks() ([]entity.Book, error) {
-	return s.repo.GetAllBooks()
+func (s *service) GetAuthorAsOf(ctx context.Context, id int, asOf time.Time) (entity.Author, error) {
+	return s.repo.GetAuthorAsOf(ctx, id, asOf)
 }
 
-func (s *service) GetBook(id int) (entity.Book, error) {
-	return s.repo.GetBook(id)
+func (s *service) RevertAuthor(ctx context.Context, id, version int) error {
+	return s.repo.RevertAuthor(ctx, id, version)
 }
 
-func (s *service) CreateBook(title string, year int, isbn string, authorID int) (int, error) {
-	return s.repo.CreateBook(title, year, isbn, authorID)
+func (s *service) GetBookVersions(ctx context.Context, id int) ([]entity.BookVersion, error) {
+	return s.repo.GetBookVersions(ctx, id)
 }
 
-func (s *service) UpdateBook(id int, title string, year int, isbn string, authorID int) error {
-	return s.repo.UpdateBook(id, title, year, isbn)
+func (s *service) GetBookAsOf(ctx context.Context, id int, asOf time.Time) (entity.Book, error) {
+	return s.repo.GetBookAsOf(ctx, id, asOf)
 }
 
-func (s *service) DeleteBook(id int) error {
-	return s.repo.DeleteBook(id)
+func (s *service) RevertBook(ctx context.Context, id, version int) error {
+	return s.repo.RevertBook(ctx, id, version)
 }
 
-func (s *service) GetBooksByAuthor(id int) ([]entity.Book, error) {
-	return s.repo.GetBooksByAuthor(id)
+// ExportAuthors и ExportBooks передают записи в fn потоком, по одной
+func (s *service) ExportAuthors(ctx context.Context, filter entity.AuthorFilter, fn func(entity.Author) error) error {
+	return s.repo.ExportAuthors(ctx, filter, fn)
 }
 
-func (s *service) UpdateBookWithAuthor(bookID int, newTitle string, newYear int, newISBN string, authorID int, newFirstName string, newLastName string, newBiography string, newBirthDate time.Time) error {
-	return s.repo.UpdateBookAndAuthor(bookID, newTitle, newYear, newISBN, authorID, newFirstName, newLastName, newBiography, newBirthDate)
+func (s *service) ExportBooks(ctx context.Context, filter entity.BookFilter, fn func(entity.BookRecord) error) error {
+	return s.repo.ExportBooks(ctx, filter, fn)
 }
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"api_library/internal/entity"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// JobInfo - фоновая задача планировщика, как её отдаёт GET /admin/jobs
+type JobInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Schedule    string         `json:"schedule"`
+	NextRun     *time.Time     `json:"next_run,omitempty"`
+	Running     bool           `json:"running"`
+	LastRun     *entity.JobRun `json:"last_run,omitempty"`
+}
+
+// IssueToken выдаёт JWT по логину и паролю; токен передаётся в Config.Token нового клиента
+func (c *Client) IssueToken(ctx context.Context, username, password string) (entity.Token, error) {
+	var token entity.Token
+	req, err := jsonRequest(http.MethodPost, "/auth/token", entity.Credentials{Username: username, Password: password})
+	if err != nil {
+		return token, err
+	}
+	err = c.do(ctx, req, &token)
+	return token, err
+}
+
+// ListAPIKeys - API-ключи текущего пользователя
+func (c *Client) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+	err := c.do(ctx, request{method: http.MethodGet, path: "/auth/api-keys"}, &keys)
+	return keys, err
+}
+
+// CreateAPIKey выпускает ключ; значение ключа возвращается только один раз
+func (c *Client) CreateAPIKey(ctx context.Context, name string, expiresAt *time.Time) (entity.APIKey, string, error) {
+	var created struct {
+		APIKey entity.APIKey `json:"api_key"`
+		Key    string        `json:"key"`
+	}
+	req, err := jsonRequest(http.MethodPost, "/auth/api-keys", map[string]interface{}{"name": name, "expires_at": expiresAt})
+	if err != nil {
+		return created.APIKey, "", err
+	}
+	err = c.do(ctx, req, &created)
+	return created.APIKey, created.Key, err
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: pathID("/auth/api-keys/", id)}, nil)
+}
+
+func (c *Client) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	var roles []entity.Role
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/roles"}, &roles)
+	return roles, err
+}
+
+func (c *Client) GetRole(ctx context.Context, name string) (entity.Role, error) {
+	var role entity.Role
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/roles/" + url.PathEscape(name)}, &role)
+	return role, err
+}
+
+func (c *Client) CreateRole(ctx context.Context, role entity.Role) error {
+	req, err := jsonRequest(http.MethodPost, "/admin/roles", role)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+func (c *Client) UpdateRole(ctx context.Context, role entity.Role) error {
+	req, err := jsonRequest(http.MethodPut, "/admin/roles/"+url.PathEscape(role.Name), role)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+func (c *Client) DeleteRole(ctx context.Context, name string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/admin/roles/" + url.PathEscape(name)}, nil)
+}
+
+// CreateUser создаёт пользователя без ролей; роли назначает SetUserRoles
+func (c *Client) CreateUser(ctx context.Context, username, password string) (entity.User, error) {
+	var user entity.User
+	req, err := jsonRequest(http.MethodPost, "/admin/users", entity.Credentials{Username: username, Password: password})
+	if err != nil {
+		return user, err
+	}
+	err = c.do(ctx, req, &user)
+	return user, err
+}
+
+func (c *Client) GetUserRoles(ctx context.Context, userID int) (entity.UserRoles, error) {
+	var userRoles entity.UserRoles
+	err := c.do(ctx, request{method: http.MethodGet, path: pathID("/admin/users/", userID, "/roles")}, &userRoles)
+	return userRoles, err
+}
+
+func (c *Client) SetUserRoles(ctx context.Context, userID int, roles []string) error {
+	req, err := jsonRequest(http.MethodPut, pathID("/admin/users/", userID, "/roles"), entity.UserRoles{Roles: roles})
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+// AuditLog - журнал изменений, подходящий под фильтр
+func (c *Client) AuditLog(ctx context.Context, filter entity.AuditFilter) *Iterator[entity.AuditEntry] {
+	query := limitQuery(filter.Limit)
+	if filter.Entity != "" {
+		query.Set("entity", filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		query.Set("id", strconv.Itoa(filter.EntityID))
+	}
+	if filter.Actor != "" {
+		query.Set("actor", filter.Actor)
+	}
+	return newIterator[entity.AuditEntry](ctx, c, request{method: http.MethodGet, path: "/audit", query: query})
+}
+
+func (c *Client) ListJobs(ctx context.Context) ([]JobInfo, error) {
+	var jobs []JobInfo
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/jobs"}, &jobs)
+	return jobs, err
+}
+
+// GetJobHistory - последние запуски задачи; limit 0 - значение сервера по умолчанию
+func (c *Client) GetJobHistory(ctx context.Context, name string, limit int) ([]entity.JobRun, error) {
+	var runs []entity.JobRun
+	err := c.do(ctx, request{method: http.MethodGet, path: "/admin/jobs/" + url.PathEscape(name), query: limitQuery(limit)}, &runs)
+	return runs, err
+}
+
+// RunJob запускает задачу вручную и возвращает запуск со статусом running, не дожидаясь завершения;
+// итог запуска - в GetJobHistory
+func (c *Client) RunJob(ctx context.Context, name string) (entity.JobRun, error) {
+	var run entity.JobRun
+	err := c.do(ctx, request{method: http.MethodPost, path: "/admin/jobs/" + url.PathEscape(name) + "/run"}, &run)
+	return run, err
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"api_library/internal/entity"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func authorFilterQuery(filter entity.AuthorFilter) url.Values {
+	query := url.Values{}
+	if filter.Name != "" {
+		query.Set("name", filter.Name)
+	}
+	return query
+}
+
+func bookFilterQuery(filter entity.BookFilter) url.Values {
+	query := url.Values{}
+	if filter.AuthorID != 0 {
+		query.Set("author_id", strconv.Itoa(filter.AuthorID))
+	}
+	if filter.Year != 0 {
+		query.Set("year", strconv.Itoa(filter.Year))
+	}
+	if filter.Title != "" {
+		query.Set("title", filter.Title)
+	}
+	if filter.ISBN != "" {
+		query.Set("isbn", filter.ISBN)
+	}
+	return query
+}
+
+func asOfQuery(asOf time.Time) url.Values {
+	return url.Values{"as_of": {asOf.UTC().Format(time.RFC3339)}}
+}
+
+// ListAuthors - все авторы; выборка по фильтру доступна через ExportAuthors
+func (c *Client) ListAuthors(ctx context.Context) *Iterator[entity.Author] {
+	return newIterator[entity.Author](ctx, c, request{method: http.MethodGet, path: "/authors"})
+}
+
+func (c *Client) GetAuthor(ctx context.Context, id int) (entity.Author, error) {
+	var author entity.Author
+	err := c.do(ctx, request{method: http.MethodGet, path: pathID("/authors/", id)}, &author)
+	return author, err
+}
+
+// GetAuthorAsOf - состояние автора на момент asOf
+func (c *Client) GetAuthorAsOf(ctx context.Context, id int, asOf time.Time) (entity.Author, error) {
+	var author entity.Author
+	err := c.do(ctx, request{method: http.MethodGet, path: pathID("/authors/", id), query: asOfQuery(asOf)}, &author)
+	return author, err
+}
+
+// CreateAuthor создаёт автора и возвращает его ID. Запрос отправляется с Idempotency-Key,
+// поэтому повтор после сетевой ошибки не создаёт дубликат.
+func (c *Client) CreateAuthor(ctx context.Context, author entity.Author) (int, error) {
+	req, err := jsonRequest(http.MethodPost, "/authors", author)
+	if err != nil {
+		return 0, err
+	}
+	req.idempotent = true
+	message, err := c.doText(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	return createdID(message)
+}
+
+func (c *Client) UpdateAuthor(ctx context.Context, id int, author entity.Author) error {
+	req, err := jsonRequest(http.MethodPut, pathID("/authors/", id), author)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+// DeleteAuthor перемещает автора в корзину
+func (c *Client) DeleteAuthor(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: pathID("/authors/", id)}, nil)
+}
+
+func (c *Client) RestoreAuthor(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodPost, path: pathID("/authors/", id, "/restore")}, nil)
+}
+
+func (c *Client) GetAuthorVersions(ctx context.Context, id int) ([]entity.AuthorVersion, error) {
+	var versions []entity.AuthorVersion
+	err := c.do(ctx, request{method: http.MethodGet, path: pathID("/authors/", id, "/versions")}, &versions)
+	return versions, err
+}
+
+func (c *Client) RevertAuthor(ctx context.Context, id, version int) error {
+	return c.do(ctx, request{method: http.MethodPost, path: pathID("/authors/", id, "/versions/", strconv.Itoa(version), "/revert")}, nil)
+}
+
+// ListBooks - все книги; выборка по фильтру доступна через ExportBooks
+func (c *Client) ListBooks(ctx context.Context) *Iterator[entity.Book] {
+	return newIterator[entity.Book](ctx, c, request{method: http.MethodGet, path: "/books"})
+}
+
+func (c *Client) GetBook(ctx context.Context, id int) (entity.Book, error) {
+	var book entity.Book
+	err := c.do(ctx, request{method: http.MethodGet, path: pathID("/books/", id)}, &book)
+	return book, err
+}
+
+// GetBookAsOf - состояние книги на момент asOf
+func (c *Client) GetBookAsOf(ctx context.Context, id int, asOf time.Time) (entity.Book, error) {
+	var book entity.Book
+	err := c.do(ctx, request{method: http.MethodGet, path: pathID("/books/", id), query: asOfQuery(asOf)}, &book)
+	return book, err
+}
+
+// CreateBook создаёт книгу и возвращает её ID; как и CreateAuthor, безопасно повторяется
+func (c *Client) CreateBook(ctx context.Context, book entity.Book) (int, error) {
+	req, err := jsonRequest(http.MethodPost, "/books", book)
+	if err != nil {
+		return 0, err
+	}
+	req.idempotent = true
+	message, err := c.doText(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	return createdID(message)
+}
+
+func (c *Client) UpdateBook(ctx context.Context, id int, book entity.Book) error {
+	req, err := jsonRequest(http.MethodPut, pathID("/books/", id), book)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, nil)
+}
+
+// DeleteBook перемещает книгу в корзину
+func (c *Client) DeleteBook(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: pathID("/books/", id)}, nil)
+}
+
+func (c *Client) RestoreBook(ctx context.Context, id int) error {
+	return c.do(ctx, request{method: http.MethodPost, path: pathID("/books/", id, "/restore")}, nil)
+}
+
+func (c *Client) GetBookVersions(ctx context.Context, id int) ([]entity.BookVersion, error) {
+	var versions []entity.BookVersion
+	err := c.do(ctx, request{method: http.MethodGet, path: pathID("/books/", id, "/versions")}, &versions)
+	return versions, err
+}
+
+func (c *Client) RevertBook(ctx context.Context, id, version int) error {
+	return c.do(ctx, request{method: http.MethodPost, path: pathID("/books/", id, "/versions/", strconv.Itoa(version), "/revert")}, nil)
+}
+
+// GetBookMARC - запись книги в MARC 21 (ISO 2709)
+func (c *Client) GetBookMARC(ctx context.Context, id int) ([]byte, error) {
+	return c.doBytes(ctx, request{method: http.MethodGet, path: pathID("/books/", id, ".mrc")})
+}
+
+// GetBookMARCXML - запись книги в MARCXML
+func (c *Client) GetBookMARCXML(ctx context.Context, id int) ([]byte, error) {
+	return c.doBytes(ctx, request{method: http.MethodGet, path: pathID("/books/", id, ".xml")})
+}
+
+// GetTrash - удалённые авторы и книги, которые ещё можно восстановить
+func (c *Client) GetTrash(ctx context.Context) (entity.Trash, error) {
+	var trash entity.Trash
+	err := c.do(ctx, request{method: http.MethodGet, path: "/trash"}, &trash)
+	return trash, err
+}
@@ -0,0 +1,291 @@
+// Package client - типизированный клиент API библиотеки для других сервисов.
+// Методы принимают context, используют типы из entity, повторяют запросы при 5xx и 429
+// и возвращают ошибки API как *Error.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultMaxRetryDelay  = 5 * time.Second
+
+	apiKeyHeader         = "X-API-Key"
+	idempotencyKeyHeader = "Idempotency-Key"
+	requestIDHeader      = "X-Request-ID"
+	lastWriteHeader      = "X-Last-Write"
+)
+
+// Config - параметры клиента; достаточно BaseURL и одного из Token/APIKey
+type Config struct {
+	// адрес API, например http://library:8080
+	BaseURL string
+	// JWT для заголовка Authorization
+	Token string
+	// API-ключ для заголовка X-API-Key; используется вместо Token
+	APIKey string
+	// по умолчанию http.Client без таймаута - время запроса ограничивается через context
+	HTTPClient *http.Client
+	// число повторов при 5xx, 429 и сетевых ошибках; 0 - по умолчанию (3), отрицательное - без повторов
+	MaxRetries int
+	// задержка перед первым повтором, дальше удваивается до MaxRetryDelay
+	RetryBaseDelay time.Duration
+	MaxRetryDelay  time.Duration
+}
+
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	apiKey     string
+	httpClient *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	// время последнего изменения из ответа сервера; возвращается в запросах,
+	// чтобы клиент читал свои изменения, а не данные отстающей реплики
+	lastWrite atomic.Value
+}
+
+func New(cfg Config) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimRight(cfg.BaseURL, "/"))
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q", cfg.BaseURL)
+	}
+	c := &Client{
+		baseURL:    baseURL,
+		token:      cfg.Token,
+		apiKey:     cfg.APIKey,
+		httpClient: cfg.HTTPClient,
+		maxRetries: cfg.MaxRetries,
+		baseDelay:  cfg.RetryBaseDelay,
+		maxDelay:   cfg.MaxRetryDelay,
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{}
+	}
+	switch {
+	case c.maxRetries == 0:
+		c.maxRetries = defaultMaxRetries
+	case c.maxRetries < 0:
+		c.maxRetries = 0
+	}
+	if c.baseDelay <= 0 {
+		c.baseDelay = defaultRetryBaseDelay
+	}
+	if c.maxDelay <= 0 {
+		c.maxDelay = defaultMaxRetryDelay
+	}
+	return c, nil
+}
+
+// request - описание запроса. Тело body можно отправить повторно;
+// stream отправляется один раз, такие запросы не повторяются.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	stream      io.Reader
+	contentType string
+	accept      string
+	// POST повторяется только с ключом идемпотентности, иначе повтор может создать дубликат
+	idempotent bool
+	// код ошибки, ответ с которым вызывающий разбирает сам (422 у откаченного пакета)
+	passStatus int
+}
+
+func jsonRequest(method, path string, v interface{}) (request, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return request{}, err
+	}
+	return request{method: method, path: path, body: body, contentType: "application/json"}, nil
+}
+
+// send выполняет запрос с повторами. Ответы с кодом 4xx и 5xx превращаются в *Error;
+// при успехе вызывающий обязан закрыть тело ответа.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var idempotencyKey string
+	if req.method == http.MethodPost && req.idempotent {
+		idempotencyKey = newIdempotencyKey()
+	}
+	retryable := req.stream == nil && (req.method != http.MethodPost || idempotencyKey != "")
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, req, idempotencyKey)
+		canRetry := retryable && attempt < c.maxRetries
+		if err != nil {
+			if ctx.Err() != nil || !canRetry {
+				return nil, err
+			}
+			if err := c.wait(ctx, attempt, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode < http.StatusBadRequest || resp.StatusCode == req.passStatus {
+			return resp, nil
+		}
+		if canRetry && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError) {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+			resp.Body.Close()
+			if err := c.wait(ctx, attempt, resp.Header.Get("Retry-After")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, decodeError(resp)
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, req request, idempotencyKey string) (*http.Response, error) {
+	// сегменты пути экранируются вызывающими методами
+	target := c.baseURL.String() + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.stream != nil {
+		body = req.stream
+	} else if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if req.accept != "" {
+		httpReq.Header.Set("Accept", req.accept)
+	}
+	if idempotencyKey != "" {
+		httpReq.Header.Set(idempotencyKeyHeader, idempotencyKey)
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set(apiKeyHeader, c.apiKey)
+	} else if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if lastWrite, ok := c.lastWrite.Load().(string); ok {
+		httpReq.Header.Set(lastWriteHeader, lastWrite)
+	}
+	resp, err := c.httpClient.Do(httpReq)
+	if err == nil && resp.Header.Get(lastWriteHeader) != "" {
+		c.lastWrite.Store(resp.Header.Get(lastWriteHeader))
+	}
+	return resp, err
+}
+
+// wait ждёт перед повтором: Retry-After сервера или экспоненциальная задержка со случайной добавкой
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter string) error {
+	delay := c.baseDelay << attempt
+	if delay <= 0 || delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	delay = delay/2 + time.Duration(mathrand.Int63n(int64(delay/2)+1))
+	if d, ok := parseRetryAfter(retryAfter); ok {
+		delay = d
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в виде HTTP-даты
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// do выполняет запрос и разбирает JSON-ответ в out (если out не nil)
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decode %s %s response: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// doText выполняет запрос, ответ на который - текстовое сообщение («Author created with ID: 1»)
+func (c *Client) doText(ctx context.Context, req request) (string, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+// doBytes выполняет запрос и возвращает тело ответа целиком
+func (c *Client) doBytes(ctx context.Context, req request) ([]byte, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// createdID достаёт ID из сообщения о создании: «Book created with ID: 42»
+func createdID(message string) (int, error) {
+	i := strings.LastIndex(message, ":")
+	id, err := strconv.Atoi(strings.TrimSpace(message[i+1:]))
+	if err != nil {
+		return 0, fmt.Errorf("client: unexpected response %q", message)
+	}
+	return id, nil
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func pathID(prefix string, id int, suffix ...string) string {
+	return prefix + strconv.Itoa(id) + strings.Join(suffix, "")
+}
@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedServer отвечает по очереди заданными ответами и запоминает пришедшие запросы
+type scriptedServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses []scriptedResponse
+	requests  []*http.Request
+}
+
+type scriptedResponse struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+func newScriptedServer(t *testing.T, responses ...scriptedResponse) *scriptedServer {
+	t.Helper()
+	s := &scriptedServer{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Clone(context.Background()))
+		resp := s.responses[0]
+		// последний ответ повторяется для всех следующих запросов
+		if len(s.responses) > 1 {
+			s.responses = s.responses[1:]
+		}
+		s.mu.Unlock()
+		for k, v := range resp.header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(resp.status)
+		fmt.Fprint(w, resp.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *scriptedServer) received() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*http.Request(nil), s.requests...)
+}
+
+func newTestClient(t *testing.T, baseURL string) *Client {
+	t.Helper()
+	c, err := New(Config{BaseURL: baseURL, APIKey: "test-key", RetryBaseDelay: time.Millisecond, MaxRetryDelay: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRetries(t *testing.T) {
+	getBook := func(c *Client) error {
+		_, err := c.GetBook(context.Background(), 1)
+		return err
+	}
+	book := scriptedResponse{status: http.StatusOK, body: `{"id":1,"title":"Dune"}`}
+	unavailable := scriptedResponse{status: http.StatusServiceUnavailable}
+
+	tests := []struct {
+		name         string
+		responses    []scriptedResponse
+		call         func(c *Client) error
+		wantAttempts int
+		wantErr      error
+	}{
+		{"5xx then success", []scriptedResponse{unavailable, book}, getBook, 2, nil},
+		{"429 with Retry-After", []scriptedResponse{{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "0"}}, book}, getBook, 2, nil},
+		{"retries exhausted", []scriptedResponse{{status: http.StatusInternalServerError}}, getBook, 1 + defaultMaxRetries, ErrServer},
+		{"4xx is not retried", []scriptedResponse{{status: http.StatusNotFound}}, getBook, 1, ErrNotFound},
+		{"POST without idempotency key is not retried", []scriptedResponse{unavailable}, func(c *Client) error {
+			return c.RestoreBook(context.Background(), 1)
+		}, 1, ErrServer},
+		{"idempotent POST is retried", []scriptedResponse{unavailable, {status: http.StatusCreated, body: "Book created with ID: 7"}}, func(c *Client) error {
+			id, err := c.CreateBook(context.Background(), Book{Title: "Dune", AuthorID: 1})
+			if err == nil && id != 7 {
+				return fmt.Errorf("id = %d, want 7", id)
+			}
+			return err
+		}, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newScriptedServer(t, tt.responses...)
+			err := tt.call(newTestClient(t, server.URL))
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			requests := server.received()
+			if len(requests) != tt.wantAttempts {
+				t.Fatalf("attempts = %d, want %d", len(requests), tt.wantAttempts)
+			}
+			// повтор POST должен нести тот же ключ, иначе сервер создаст дубликат
+			key := requests[0].Header.Get(idempotencyKeyHeader)
+			for _, r := range requests[1:] {
+				if got := r.Header.Get(idempotencyKeyHeader); got != key {
+					t.Errorf("Idempotency-Key changed between attempts: %q -> %q", key, got)
+				}
+			}
+			for _, r := range requests {
+				if r.Header.Get(apiKeyHeader) != "test-key" {
+					t.Errorf("request without API key")
+				}
+			}
+		})
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	server := newScriptedServer(t,
+		scriptedResponse{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "1"}},
+		scriptedResponse{status: http.StatusOK, body: `{"id":1,"title":"Dune"}`},
+	)
+	// без Retry-After клиент ждал бы не дольше MaxRetryDelay (10ms)
+	start := time.Now()
+	if _, err := newTestClient(t, server.URL).GetBook(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least 1s", elapsed)
+	}
+}
+
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	server := newScriptedServer(t, scriptedResponse{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "60"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := newTestClient(t, server.URL).GetBook(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context deadline", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   scriptedResponse
+		wantIs     error
+		wantMsg    string
+		wantSource string
+	}{
+		{
+			name:       "HTTPError envelope",
+			response:   scriptedResponse{status: http.StatusNotFound, body: `{"Code":404,"Message":"author not found","Source":"GetAuthor"}`},
+			wantIs:     ErrNotFound,
+			wantMsg:    "author not found",
+			wantSource: "GetAuthor",
+		},
+		{
+			name:     "book handler envelope",
+			response: scriptedResponse{status: http.StatusBadRequest, body: `{"message":"Invalid book ID"}`},
+			wantIs:   ErrBadRequest,
+			wantMsg:  "Invalid book ID",
+		},
+		{
+			name:     "plain text",
+			response: scriptedResponse{status: http.StatusForbidden, body: "forbidden\n"},
+			wantIs:   ErrForbidden,
+			wantMsg:  "forbidden",
+		},
+		{
+			name:       "validation error",
+			response:   scriptedResponse{status: http.StatusUnprocessableEntity, body: `{"Code":422,"Message":"author not found","Source":"CreateBook"}`},
+			wantIs:     ErrBadRequest,
+			wantMsg:    "author not found",
+			wantSource: "CreateBook",
+		},
+		{
+			name:     "conflict",
+			response: scriptedResponse{status: http.StatusConflict, body: `{"Code":409,"Message":"version conflict"}`},
+			wantIs:   ErrConflict,
+			wantMsg:  "version conflict",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.response.header = map[string]string{requestIDHeader: "req-42"}
+			server := newScriptedServer(t, tt.response)
+			_, err := newTestClient(t, server.URL).GetAuthor(context.Background(), 1)
+
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v (%T), want *Error", err, err)
+			}
+			if !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantIs)
+			}
+			if apiErr.StatusCode != tt.response.status || apiErr.Message != tt.wantMsg || apiErr.Source != tt.wantSource {
+				t.Errorf("Error = %+v", apiErr)
+			}
+			if apiErr.RequestID != "req-42" {
+				t.Errorf("RequestID = %q, want req-42", apiErr.RequestID)
+			}
+		})
+	}
+}
+
+// время изменения из ответа возвращается в следующих запросах, чтобы чтения шли на основную базу
+func TestLastWriteEchoed(t *testing.T) {
+	s := newScriptedServer(t,
+		scriptedResponse{status: http.StatusOK},
+		scriptedResponse{status: http.StatusOK, header: map[string]string{lastWriteHeader: "1700000000000"}},
+		scriptedResponse{status: http.StatusOK},
+	)
+	c := newTestClient(t, s.URL)
+	for i := 0; i < 3; i++ {
+		if err := c.do(context.Background(), request{method: http.MethodGet, path: "/authors"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for _, r := range s.received() {
+		got = append(got, r.Header.Get(lastWriteHeader))
+	}
+	if want := []string{"", "", "1700000000000"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s sent = %q, want %q", lastWriteHeader, got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// максимальный размер тела ответа с ошибкой, который читает клиент
+const maxErrorBody = 1 << 20
+
+// Ошибки для проверки через errors.Is: errors.Is(err, client.ErrNotFound)
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// Error - ответ API с кодом 4xx или 5xx. Сервер отвечает {"Code", "Message", "Source"},
+// обработчики книг - {"message"}; оба варианта разбираются в Message и Source.
+type Error struct {
+	StatusCode int
+	Message    string
+	Source     string
+	// X-Request-ID запроса - по нему ошибку можно найти в логах сервера
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	msg := "api: " + strings.ToLower(http.StatusText(e.StatusCode))
+	if e.Message != "" && !strings.EqualFold(e.Message, http.StatusText(e.StatusCode)) {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+func decodeError(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return errorFromBody(resp, body)
+}
+
+func errorFromBody(resp *http.Response, body []byte) *Error {
+	apiErr := &Error{StatusCode: resp.StatusCode, RequestID: resp.Header.Get(requestIDHeader)}
+
+	var envelope struct {
+		Code        int
+		Message     string
+		Source      string
+		BookMessage string `json:"message"`
+	}
+	if json.Unmarshal(body, &envelope) == nil {
+		apiErr.Message = envelope.Message
+		if apiErr.Message == "" {
+			apiErr.Message = envelope.BookMessage
+		}
+		apiErr.Source = envelope.Source
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
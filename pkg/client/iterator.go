@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Iterator перебирает элементы списка, не загружая его в память целиком.
+// API отдаёт списки одним JSON-массивом, и итератор разбирает его по мере чтения ответа;
+// запрос отправляется при первом вызове Next.
+//
+//	it := c.ListBooks(ctx)
+//	defer it.Close()
+//	for it.Next() {
+//		book := it.Value()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator[T any] struct {
+	ctx    context.Context
+	client *Client
+	req    request
+
+	resp    *http.Response
+	decoder *json.Decoder
+	value   T
+	err     error
+	done    bool
+}
+
+func newIterator[T any](ctx context.Context, c *Client, req request) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, client: c, req: req}
+}
+
+// Next переходит к следующему элементу; false - элементы закончились или произошла ошибка
+func (it *Iterator[T]) Next() bool {
+	if it.done {
+		return false
+	}
+	if it.decoder == nil && !it.open() {
+		return false
+	}
+	if !it.decoder.More() {
+		// закрывающая скобка массива
+		if _, err := it.decoder.Token(); err != nil {
+			it.fail(err)
+			return false
+		}
+		it.Close()
+		return false
+	}
+	var value T
+	if err := it.decoder.Decode(&value); err != nil {
+		it.fail(err)
+		return false
+	}
+	it.value = value
+	return true
+}
+
+func (it *Iterator[T]) open() bool {
+	resp, err := it.client.send(it.ctx, it.req)
+	if err != nil {
+		it.err = err
+		it.done = true
+		return false
+	}
+	it.resp = resp
+	it.decoder = json.NewDecoder(resp.Body)
+
+	token, err := it.decoder.Token()
+	if err != nil {
+		it.fail(err)
+		return false
+	}
+	// пустой список сервер может отдать как null
+	if token == nil {
+		it.Close()
+		return false
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		it.fail(fmt.Errorf("expected JSON array, got %v", token))
+		return false
+	}
+	return true
+}
+
+func (it *Iterator[T]) fail(err error) {
+	it.err = fmt.Errorf("client: decode %s %s response: %w", it.req.method, it.req.path, err)
+	it.Close()
+}
+
+// Value - текущий элемент
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err - ошибка, прервавшая перебор
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close освобождает соединение; после полного перебора вызывается автоматически
+func (it *Iterator[T]) Close() error {
+	it.done = true
+	if it.resp == nil {
+		return nil
+	}
+	io.Copy(io.Discard, it.resp.Body)
+	err := it.resp.Body.Close()
+	it.resp = nil
+	return err
+}
+
+// All читает все оставшиеся элементы
+func (it *Iterator[T]) All() ([]T, error) {
+	defer it.Close()
+	var values []T
+	for it.Next() {
+		values = append(values, it.Value())
+	}
+	return values, it.Err()
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestIterator(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  scriptedResponse
+		wantTitle []string
+		wantErr   bool
+		wantIs    error
+	}{
+		{"array", scriptedResponse{status: http.StatusOK, body: `[{"id":1,"title":"Dune"},{"id":2,"title":"Solaris"}]`}, []string{"Dune", "Solaris"}, false, nil},
+		{"empty array", scriptedResponse{status: http.StatusOK, body: `[]`}, nil, false, nil},
+		{"null", scriptedResponse{status: http.StatusOK, body: `null`}, nil, false, nil},
+		{"not an array", scriptedResponse{status: http.StatusOK, body: `{"id":1}`}, nil, true, nil},
+		{"truncated", scriptedResponse{status: http.StatusOK, body: `[{"id":1,"title":"Dune"},{"id":2,`}, []string{"Dune"}, true, nil},
+		{"bad element", scriptedResponse{status: http.StatusOK, body: `[{"id":"one"}]`}, nil, true, nil},
+		{"API error", scriptedResponse{status: http.StatusUnauthorized, body: `{"Code":401,"Message":"unauthorized"}`}, nil, true, ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newScriptedServer(t, tt.response)
+			books, err := newTestClient(t, server.URL).ListBooks(context.Background()).All()
+
+			var titles []string
+			for _, book := range books {
+				titles = append(titles, book.Title)
+			}
+			if len(titles) != len(tt.wantTitle) {
+				t.Fatalf("titles = %v, want %v", titles, tt.wantTitle)
+			}
+			for i := range titles {
+				if titles[i] != tt.wantTitle[i] {
+					t.Errorf("titles = %v, want %v", titles, tt.wantTitle)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error: %v", err, tt.wantErr)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("error = %v, want %v", err, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestIteratorIsLazyAndStopsAfterClose(t *testing.T) {
+	server := newScriptedServer(t, scriptedResponse{status: http.StatusOK, body: `[{"id":1},{"id":2}]`})
+	it := newTestClient(t, server.URL).ListBooks(context.Background())
+	if n := len(server.received()); n != 0 {
+		t.Fatalf("request sent before Next: %d", n)
+	}
+	if !it.Next() || it.Value().ID != 1 {
+		t.Fatalf("first element = %+v, err = %v", it.Value(), it.Err())
+	}
+	if err := it.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if it.Next() {
+		t.Error("Next returned true after Close")
+	}
+	if n := len(server.received()); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
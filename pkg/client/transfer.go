@@ -0,0 +1,131 @@
+package client
+
+import (
+	"api_library/internal/entity"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Форматы выгрузки каталога
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// ExportAuthors выгружает авторов в формате format; вызывающий обязан закрыть результат
+func (c *Client) ExportAuthors(ctx context.Context, filter entity.AuthorFilter, format string) (io.ReadCloser, error) {
+	query := authorFilterQuery(filter)
+	query.Set("format", format)
+	return c.stream(ctx, request{method: http.MethodGet, path: "/export/authors", query: query})
+}
+
+// ExportBooks выгружает книги вместе с авторами; вызывающий обязан закрыть результат
+func (c *Client) ExportBooks(ctx context.Context, filter entity.BookFilter, format string) (io.ReadCloser, error) {
+	query := bookFilterQuery(filter)
+	query.Set("format", format)
+	return c.stream(ctx, request{method: http.MethodGet, path: "/export/books", query: query})
+}
+
+func (c *Client) stream(ctx context.Context, req request) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ImportCSV загружает CSV из r. Данные передаются потоком, поэтому запрос не повторяется.
+func (c *Client) ImportCSV(ctx context.Context, r io.Reader, opts entity.ImportOptions) (entity.ImportResult, error) {
+	query := url.Values{"type": {opts.Entity}}
+	if opts.DryRun {
+		query.Set("dry_run", "true")
+	}
+	if opts.Delimiter != 0 {
+		query.Set("delimiter", string(opts.Delimiter))
+	}
+	for field, column := range opts.Mapping {
+		query.Set("map."+field, column)
+	}
+	var result entity.ImportResult
+	err := c.do(ctx, request{method: http.MethodPost, path: "/import", query: query, stream: r, contentType: "text/csv"}, &result)
+	return result, err
+}
+
+// ImportMARC загружает записи MARC 21 в ISO 2709 или MARCXML из r
+func (c *Client) ImportMARC(ctx context.Context, r io.Reader, dryRun bool) (entity.ImportResult, error) {
+	query := url.Values{}
+	if dryRun {
+		query.Set("dry_run", "true")
+	}
+	var result entity.ImportResult
+	err := c.do(ctx, request{method: http.MethodPost, path: "/import/marc", query: query, stream: r, contentType: "application/octet-stream"}, &result)
+	return result, err
+}
+
+// Batch выполняет пакет операций. Частично выполненный (207) и откаченный атомарный (422) пакеты
+// не считаются ошибкой: результат каждой операции - в BatchResponse.Results.
+func (c *Client) Batch(ctx context.Context, batch entity.BatchRequest) (entity.BatchResponse, error) {
+	var response entity.BatchResponse
+	req, err := jsonRequest(http.MethodPost, "/batch", batch)
+	if err != nil {
+		return response, err
+	}
+	req.idempotent = true
+	req.passStatus = http.StatusUnprocessableEntity
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return response, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response, err
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Results == nil {
+		if resp.StatusCode == http.StatusUnprocessableEntity {
+			return response, errorFromBody(resp, body)
+		}
+		return response, fmt.Errorf("client: decode POST /batch response: %v", err)
+	}
+	return response, nil
+}
+
+// NewBatchOperation собирает операцию пакета; data - автор или книга для create и update
+func NewBatchOperation(op, entityName string, id int, data interface{}) (entity.BatchOperation, error) {
+	operation := entity.BatchOperation{Op: op, Entity: entityName, ID: id}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return operation, err
+		}
+		operation.Data = raw
+	}
+	return operation, nil
+}
+
+// OAI выполняет запрос OAI-PMH и возвращает XML-ответ как есть, например
+// OAI(ctx, url.Values{"verb": {"ListRecords"}, "metadataPrefix": {"oai_dc"}})
+func (c *Client) OAI(ctx context.Context, params url.Values) ([]byte, error) {
+	return c.doBytes(ctx, request{method: http.MethodGet, path: "/oai", query: params})
+}
+
+// OpenAPI - описание API в формате OpenAPI 3.1
+func (c *Client) OpenAPI(ctx context.Context) ([]byte, error) {
+	return c.doBytes(ctx, request{method: http.MethodGet, path: "/openapi.json"})
+}
+
+// limitQuery - параметр limit, если он задан
+func limitQuery(limit int) url.Values {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	return query
+}
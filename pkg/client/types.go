@@ -0,0 +1,41 @@
+package client
+
+import "api_library/internal/entity"
+
+// Псевдонимы типов entity: сервисы вне модуля не могут импортировать internal/entity,
+// но через псевдонимы работают с теми же типами, что и сервер.
+type (
+	Author         = entity.Author
+	Book           = entity.Book
+	Date           = entity.Date
+	AuthorFilter   = entity.AuthorFilter
+	BookFilter     = entity.BookFilter
+	AuthorVersion  = entity.AuthorVersion
+	BookVersion    = entity.BookVersion
+	Trash          = entity.Trash
+	JobRun         = entity.JobRun
+	Token          = entity.Token
+	APIKey         = entity.APIKey
+	Role           = entity.Role
+	UserRoles      = entity.UserRoles
+	AuditEntry     = entity.AuditEntry
+	AuditFilter    = entity.AuditFilter
+	ImportOptions  = entity.ImportOptions
+	ImportResult   = entity.ImportResult
+	ImportRowError = entity.ImportRowError
+	BatchRequest   = entity.BatchRequest
+	BatchOperation = entity.BatchOperation
+	BatchResult    = entity.BatchResult
+	BatchResponse  = entity.BatchResponse
+)
+
+const (
+	ImportAuthors = entity.ImportAuthors
+	ImportBooks   = entity.ImportBooks
+
+	BatchCreate       = entity.BatchCreate
+	BatchUpdate       = entity.BatchUpdate
+	BatchDelete       = entity.BatchDelete
+	BatchEntityAuthor = entity.BatchEntityAuthor
+	BatchEntityBook   = entity.BatchEntityBook
+)